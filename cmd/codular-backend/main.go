@@ -3,10 +3,24 @@ package main
 import (
 	_ "codular-backend/docs"
 	"codular-backend/internal/config"
+	"codular-backend/internal/experiments"
+	"codular-backend/internal/http_server/handlers/account"
+	admin_experiments "codular-backend/internal/http_server/handlers/admin/experiments"
+	"codular-backend/internal/http_server/handlers/admin/llm_cache_stats"
+	"codular-backend/internal/http_server/handlers/admin/llm_calls"
+	"codular-backend/internal/http_server/handlers/admin/prompt_versions"
+	"codular-backend/internal/http_server/handlers/admin/unlock_login"
+	"codular-backend/internal/http_server/handlers/api_tokens"
 	"codular-backend/internal/http_server/handlers/auth"
 	"codular-backend/internal/http_server/handlers/edit_task"
+	"codular-backend/internal/http_server/handlers/generate/bugs"
+	"codular-backend/internal/http_server/handlers/generate/explain"
 	"codular-backend/internal/http_server/handlers/generate/noises"
+	"codular-backend/internal/http_server/handlers/generate/output"
+	"codular-backend/internal/http_server/handlers/generate/parsons"
+	"codular-backend/internal/http_server/handlers/generate/quiz"
 	"codular-backend/internal/http_server/handlers/generate/skips"
+	"codular-backend/internal/http_server/handlers/generate/translate"
 	"codular-backend/internal/http_server/handlers/get_status/submission_status"
 	"codular-backend/internal/http_server/handlers/get_status/task_status"
 	"codular-backend/internal/http_server/handlers/get_task/get_random_task"
@@ -14,11 +28,32 @@ import (
 	"codular-backend/internal/http_server/handlers/get_task_list"
 	"codular-backend/internal/http_server/handlers/get_user_email"
 	"codular-backend/internal/http_server/handlers/get_user_tasks"
+	"codular-backend/internal/http_server/handlers/get_user_usage"
+	"codular-backend/internal/http_server/handlers/jwks"
 	"codular-backend/internal/http_server/handlers/regenerate"
+	"codular-backend/internal/http_server/handlers/report_hints"
+	"codular-backend/internal/http_server/handlers/solve/bugs_check"
+	"codular-backend/internal/http_server/handlers/solve/explain_check"
 	"codular-backend/internal/http_server/handlers/solve/noises_check"
+	"codular-backend/internal/http_server/handlers/solve/output_check"
+	"codular-backend/internal/http_server/handlers/solve/parsons_check"
+	"codular-backend/internal/http_server/handlers/solve/quiz_check"
 	"codular-backend/internal/http_server/handlers/solve/skips_check"
+	"codular-backend/internal/http_server/handlers/solve/translate_check"
+	"codular-backend/internal/http_server/handlers/task_hint"
+	"codular-backend/internal/http_server/handlers/tutor_chat"
+	"codular-backend/internal/http_server/handlers/two_factor"
 	"codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/jwt_keys"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/llm_quota"
+	"codular-backend/internal/login_guard"
+	"codular-backend/internal/mailer"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/sandbox"
 	"codular-backend/internal/storage/database"
+	"codular-backend/internal/task_progress"
+	"codular-backend/lib/api_token"
 	"codular-backend/lib/logger/handlers/slogpretty"
 	"fmt"
 	"github.com/go-chi/chi/v5"
@@ -72,11 +107,37 @@ func main() {
 	storage := database.DB
 	defer database.CloseDB()
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		logger.Error("JWT_SECRET environment variable is missing")
-		log.Fatal("JWT_SECRET is required")
+	jwtKeys, err := jwt_keys.New(cfg.JWT, os.Getenv("JWT_SECRET"), storage)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Error while initializing JWT keys: %s", err))
+		log.Fatalf("Failed to init JWT keys: %s", err)
+	}
+	jwtKeys.StartRotation(logger)
+
+	if err := storage.PromoteAdmins(cfg.AdminEmails); err != nil {
+		logger.Error(fmt.Sprintf("Error while promoting admins: %s", err))
+	}
+
+	if err := prompts.Init(storage); err != nil {
+		logger.Error(fmt.Sprintf("Error while initializing prompts: %s", err))
+		log.Fatalf("Failed to init prompts: %s", err)
+	}
+
+	llm.Init(storage, cfg.LLM, cfg.LLMCache, cfg.LLMAudit, logger)
+	llm.Default.StartAuditCleanup()
+
+	task_progress.Init(storage, cfg.TaskProgress)
+
+	if err := experiments.Init(storage); err != nil {
+		logger.Error(fmt.Sprintf("Error while initializing experiments: %s", err))
+		log.Fatalf("Failed to init experiments: %s", err)
 	}
+	llmQuota := llm_quota.New(storage, cfg.LLMQuotas)
+
+	loginGuard := login_guard.New(storage, cfg.LoginProtection)
+	codeSandbox := sandbox.New(cfg.Sandbox)
+	codeSandbox.StartImagePull(logger)
+	mail := mailer.New(cfg.Mail, logger)
 
 	router := chi.NewRouter()
 
@@ -93,6 +154,8 @@ func main() {
 	router.Use(chiMiddleware.Recoverer)
 	router.Use(chiMiddleware.URLFormat)
 
+	router.Get("/.well-known/jwks.json", jwks.New(jwtKeys))
+
 	router.Get("/swagger/doc.json", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "./docs/swagger.json")
 	})
@@ -105,28 +168,83 @@ func main() {
 	router.Route("/api/v1", func(r chi.Router) {
 		// Роуты без авторизации
 		r.Group(func(r chi.Router) {
-			r.Post("/auth/register", auth.Register(logger, storage, jwtSecret))
-			r.Post("/auth/login", auth.Login(logger, storage, jwtSecret))
-			r.Post("/auth/refresh", auth.Refresh(logger, storage, jwtSecret))
+			r.Post("/auth/register", auth.Register(logger, storage, jwtKeys))
+			r.Post("/auth/login", auth.Login(logger, storage, jwtKeys, loginGuard))
+			r.Post("/auth/login/2fa", auth.LoginTwoFactor(logger, storage, jwtKeys, loginGuard))
+			r.Post("/auth/refresh", auth.Refresh(logger, storage, jwtKeys))
 			r.Post("/auth/logout", auth.Logout(logger, storage))
+			r.Post("/auth/email/confirm", account.ConfirmEmailChange(logger, storage))
 			r.Get("/task/random", get_random_task.RandomTask(logger, storage))
 			r.Get("/tasks", get_task_list.ListTasks(logger, storage))
 		})
 
 		// Роуты с авторизацией
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware(jwtSecret, logger))
-			r.Get("/user/email", get_user_email.GetUserEmail(logger, storage))
-			r.Post("/skips/generate", skips.New(logger, storage, cfg))
-			r.Post("/noises/generate", noises.New(logger, storage, cfg))
-			r.Post("/skips/solve", skips_check.New(logger, storage))
-			r.Post("/noises/solve", noises_check.New(logger, storage))
-			r.Get("/task/{alias}", get_task.New(logger, storage))
-			r.Get("/user/tasks", get_user_tasks.UserTasks(logger, storage))
-			r.Patch("/task/{alias}/regenerate", regenerate.New(logger, storage))
-			r.Patch("/task/{alias}/set-access", edit_task.ChangeAccess(logger, storage))
-			r.Get("/submission-status/{submission_id}", submission_status.New(logger, storage))
-			r.Get("/task-status/{alias}", task_status.GetTaskStatus(logger))
+			r.Use(middleware.AuthMiddleware(jwtKeys, storage, logger))
+
+			// Только для интерактивных сессий (не для персональных токенов)
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireSession(logger))
+				r.Get("/user/email", get_user_email.GetUserEmail(logger, storage))
+				r.Post("/user/email", account.RequestEmailChange(logger, storage, mail, cfg, loginGuard))
+				r.Post("/user/password", auth.ChangePassword(logger, storage, jwtKeys, loginGuard))
+				r.Get("/user/export", account.ExportData(logger, storage))
+				r.Delete("/user", account.DeleteAccount(logger, storage, cfg, loginGuard))
+				r.Get("/user/usage", get_user_usage.GetUserUsage(logger, storage, llmQuota))
+				r.Post("/user/tokens", api_tokens.Create(logger, storage))
+				r.Get("/user/tokens", api_tokens.List(logger, storage))
+				r.Delete("/user/tokens/{token_id}", api_tokens.Revoke(logger, storage))
+				r.Post("/user/2fa/enroll", two_factor.Enroll(logger, storage))
+				r.Post("/user/2fa/confirm", two_factor.Confirm(logger, storage, loginGuard))
+				r.Post("/user/2fa/disable", two_factor.Disable(logger, storage, loginGuard))
+			})
+
+			// Администрирование
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireSession(logger))
+				r.Use(middleware.RequireAdmin(storage, logger))
+				r.Post("/admin/login/unlock", unlock_login.New(logger, storage, loginGuard))
+				r.Get("/admin/llm/cache-stats", llm_cache_stats.New(logger, storage))
+				r.Get("/admin/llm/calls", llm_calls.List(logger, storage))
+				r.Get("/admin/llm/calls/{id}", llm_calls.Get(logger, storage))
+				r.Get("/admin/prompts", prompt_versions.ListActive(logger, storage))
+				r.Get("/admin/prompts/{name}/versions", prompt_versions.List(logger, storage))
+				r.Post("/admin/prompts/{name}/versions", prompt_versions.Create(logger, storage))
+				r.Post("/admin/prompts/{name}/preview", prompt_versions.Preview(logger, storage))
+				r.Post("/admin/prompts/{name}/versions/{version}/activate", prompt_versions.Activate(logger, storage))
+				r.Get("/admin/experiments", admin_experiments.List(logger, storage))
+				r.Post("/admin/experiments", admin_experiments.Create(logger, storage))
+				r.Post("/admin/experiments/{id}/stop", admin_experiments.Stop(logger, storage))
+				r.Get("/admin/experiments/{id}/report", admin_experiments.Report(logger, storage))
+			})
+
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/skips/generate", skips.New(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/noises/generate", noises.New(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger)).Post("/parsons/generate", parsons.New(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/bugs/generate", bugs.New(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger)).Post("/output/generate", output.New(logger, storage, cfg, codeSandbox))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/quiz/generate", quiz.New(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger)).Post("/translate/generate", translate.New(logger, storage, cfg, codeSandbox))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/explain/generate", explain.New(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger)).Post("/skips/solve", skips_check.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger)).Post("/noises/solve", noises_check.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger)).Post("/parsons/solve", parsons_check.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/bugs/solve", bugs_check.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger)).Post("/output/solve", output_check.New(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger)).Post("/quiz/solve", quiz_check.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/translate/solve", translate_check.New(logger, storage, cfg, codeSandbox))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/explain/solve", explain_check.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeTasksRead, logger)).Get("/task/{alias}", get_task.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeTasksRead, logger)).Get("/user/tasks", get_user_tasks.UserTasks(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Patch("/task/{alias}/regenerate", regenerate.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger)).Patch("/task/{alias}/set-access", edit_task.ChangeAccess(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsRead, logger)).Get("/submission-status/{submission_id}", submission_status.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger)).Post("/submission/{submission_id}/report-hints", report_hints.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger)).Post("/task/{alias}/hint", task_hint.New(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsWrite, logger), middleware.RequireLLMQuota(llmQuota, logger)).Post("/task/{alias}/tutor", tutor_chat.Ask(logger, storage, cfg))
+			r.With(middleware.RequireScope(api_token.ScopeSubmissionsRead, logger)).Get("/task/{alias}/tutor", tutor_chat.History(logger, storage))
+			r.With(middleware.RequireScope(api_token.ScopeTasksRead, logger)).Get("/task-status/{alias}", task_status.GetTaskStatus(logger))
+			r.With(middleware.RequireScope(api_token.ScopeTasksWrite, logger)).Post("/task/{alias}/cancel", task_status.CancelTask(logger, storage))
 		})
 	})
 
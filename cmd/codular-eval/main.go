@@ -0,0 +1,152 @@
+// codular-eval прогоняет эталонный набор фрагментов кода через генерацию задач и проверку решений
+// и печатает сравнительный отчёт по версиям промптов и моделям.
+//
+//	go run ./cmd/codular-eval -models meta-llama/llama-4-scout:free,openai/gpt-4o-mini -prompt skips_generate=0,3
+//
+// Используется та же конфигурация (.env, CONFIG_PATH), что и у сервера: промпты берутся из базы,
+// запросы пишутся в журнал llm_calls с эндпоинтом "eval".
+package main
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/eval"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/storage/database"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// promptFlag — повторяемый флаг -prompt name=1,2 (0 — активная версия)
+type promptFlag map[string][]int
+
+func (p promptFlag) String() string {
+	return fmt.Sprint(map[string][]int(p))
+}
+
+func (p promptFlag) Set(value string) error {
+	name, versions, ok := strings.Cut(value, "=")
+	if !ok || !prompts.Known(name) {
+		return fmt.Errorf("expected <prompt>=<versions>, prompt one of %s", strings.Join(prompts.Names(), ", "))
+	}
+	for _, v := range strings.Split(versions, ",") {
+		version, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid prompt version %q", v)
+		}
+		p[name] = append(p[name], version)
+	}
+	return nil
+}
+
+func main() {
+	promptVersions := promptFlag{}
+	datasetPath := flag.String("dataset", "./config/eval/dataset.yaml", "path to the golden dataset")
+	flows := flag.String("flows", strings.Join(eval.Flows, ","), "comma-separated flows to run")
+	models := flag.String("models", "", "comma-separated models to compare (default: the configured fallback chain)")
+	jsonPath := flag.String("json", "", "write per-case results to this file")
+	verbose := flag.Bool("v", false, "verbose logging")
+	flag.Var(promptVersions, "prompt", "prompt versions to compare, e.g. skips_generate=0,3 (0 is the active version); repeatable")
+	flag.Parse()
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	dataset, err := eval.Load(*datasetPath)
+	if err != nil {
+		log.Fatalf("Failed to load dataset: %s", err)
+	}
+
+	cfg := config.MustLoad()
+	if err := database.New(); err != nil {
+		log.Fatalf("Failed to init DB: %s", err)
+	}
+	defer database.CloseDB()
+	storage := database.DB
+
+	if err := prompts.Init(storage); err != nil {
+		log.Fatalf("Failed to init prompts: %s", err)
+	}
+	llm.Init(storage, cfg.LLM, cfg.LLMCache, cfg.LLMAudit, logger)
+
+	variants, err := buildVariants(*flows, *models, promptVersions)
+	if err != nil {
+		log.Fatalf("Invalid flags: %s", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	runner := eval.NewRunner(logger)
+	var runs []eval.Run
+	var summaries []eval.Summary
+	for _, variant := range variants {
+		fmt.Fprintf(os.Stderr, "running %s, prompt %d, model %q...\n", variant.Flow, variant.PromptVersion, variant.Model)
+		run := runner.Run(ctx, dataset, variant)
+		runs = append(runs, run)
+		summaries = append(summaries, eval.Summarize(run))
+	}
+
+	if err := eval.WriteReport(os.Stdout, summaries); err != nil {
+		log.Fatalf("Failed to write report: %s", err)
+	}
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(struct {
+			Summaries []eval.Summary `json:"summaries"`
+			Runs      []eval.Run     `json:"runs"`
+		}{summaries, runs}, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to encode results: %s", err)
+		}
+		if err := os.WriteFile(*jsonPath, data, 0o644); err != nil {
+			log.Fatalf("Failed to write results: %s", err)
+		}
+	}
+}
+
+// buildVariants перемножает сценарии, версии их промптов и модели
+func buildVariants(flows, models string, promptVersions promptFlag) ([]eval.Variant, error) {
+	modelList := []string{""}
+	if models != "" {
+		modelList = splitList(models)
+	}
+
+	var variants []eval.Variant
+	for _, flow := range splitList(flows) {
+		if _, ok := eval.FlowMetrics[flow]; !ok {
+			return nil, fmt.Errorf("unknown flow %q, expected one of %s", flow, strings.Join(eval.Flows, ", "))
+		}
+		versions := promptVersions[flow]
+		if len(versions) == 0 {
+			versions = []int{0}
+		}
+		for _, version := range versions {
+			for _, model := range modelList {
+				variants = append(variants, eval.Variant{Flow: flow, PromptVersion: version, Model: model})
+			}
+		}
+	}
+	return variants, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
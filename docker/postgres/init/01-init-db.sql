@@ -21,9 +21,36 @@ CREATE TABLE IF NOT EXISTS users (
                                      id SERIAL PRIMARY KEY,
                                      email TEXT NOT NULL UNIQUE,
                                      password_hash TEXT NOT NULL,
-                                     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+                                     role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin')),
+                                     totp_secret TEXT,
+                                     totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+                                     totp_last_step BIGINT NOT NULL DEFAULT 0,
+                                     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP
 );
 
+-- Create the email_change_requests table if it doesn't exist
+CREATE TABLE IF NOT EXISTS email_change_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    new_email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+-- Create the two_factor_recovery_codes table if it doesn't exist
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+CREATE INDEX IF NOT EXISTS idx_two_factor_recovery_codes_user_id ON two_factor_recovery_codes(user_id);
+
 -- Create the tokens table if it doesn't exist
 CREATE TABLE IF NOT EXISTS tokens (
                                       id SERIAL PRIMARY KEY,
@@ -36,6 +63,74 @@ CREATE TABLE IF NOT EXISTS tokens (
     );
 CREATE INDEX idx_tokens_token ON tokens(token);
 
+-- Create the api_tokens table if it doesn't exist
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    token_prefix TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
+
+-- Create the jwt_signing_keys table if it doesn't exist
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL CHECK (algorithm IN ('RS256', 'EdDSA')),
+    private_key TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    retire_at TIMESTAMP NOT NULL
+    );
+
+-- Create the prompt_versions table if it doesn't exist
+CREATE TABLE IF NOT EXISTS prompt_versions (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    system_template TEXT NOT NULL,
+    user_template TEXT NOT NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    activated_at TIMESTAMP,
+    UNIQUE (name, version),
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+    );
+CREATE UNIQUE INDEX IF NOT EXISTS idx_prompt_versions_active ON prompt_versions(name) WHERE active;
+
+-- Create the experiments table if it doesn't exist
+CREATE TABLE IF NOT EXISTS experiments (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    flow TEXT NOT NULL CHECK (flow IN ('skips_generate', 'noises_generate', 'skips_check', 'noises_check', 'bugs_generate', 'bugs_check', 'quiz_generate', 'translate_check', 'explain_generate', 'explain_check', 'skips_describe')),
+    description TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+    );
+CREATE UNIQUE INDEX IF NOT EXISTS idx_experiments_active_flow ON experiments(flow) WHERE active;
+
+-- Create the experiment_variants table if it doesn't exist
+CREATE TABLE IF NOT EXISTS experiment_variants (
+    id SERIAL PRIMARY KEY,
+    experiment_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    prompt_version INTEGER NOT NULL DEFAULT 0,
+    model TEXT NOT NULL DEFAULT '',
+    weight INTEGER NOT NULL DEFAULT 1 CHECK (weight > 0),
+    UNIQUE (experiment_id, name),
+    FOREIGN KEY (experiment_id) REFERENCES experiments(id) ON DELETE CASCADE
+    );
+
 -- Create the programming_languages table if it doesn't exist
 CREATE TABLE IF NOT EXISTS programming_languages (
                                                      id SERIAL PRIMARY KEY,
@@ -49,7 +144,7 @@ INSERT INTO programming_languages (name) VALUES ('Java'), ('Python'), ('C++')
 CREATE TABLE IF NOT EXISTS tasks (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL,
-    type TEXT NOT NULL CHECK (type IN ('skips', 'noises')),
+    type TEXT NOT NULL CHECK (type IN ('skips', 'noises', 'parsons', 'bugs', 'output', 'quiz', 'translate', 'explain')),
     taskCode TEXT NOT NULL,
     userOriginalCode TEXT,
     description TEXT NOT NULL,
@@ -57,11 +152,27 @@ CREATE TABLE IF NOT EXISTS tasks (
     programming_language_id INTEGER NOT NULL,
     created_at TIMESTAMP NOT NULL,
     public BOOLEAN NOT NULL DEFAULT FALSE,
+    prompt_version_id INTEGER,
+    experiment_variant_id INTEGER,
+    scrub_report JSONB,
+    hint_ladders JSONB,
+    stdin TEXT,
+    output_normalization TEXT,
+    target_language_id INTEGER,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (prompt_version_id) REFERENCES prompt_versions(id) ON DELETE SET NULL,
+    FOREIGN KEY (experiment_variant_id) REFERENCES experiment_variants(id) ON DELETE SET NULL,
     FOREIGN KEY (programming_language_id) REFERENCES programming_languages(id) ON DELETE RESTRICT,
+    FOREIGN KEY (target_language_id) REFERENCES programming_languages(id) ON DELETE RESTRICT,
     CHECK (
-    (type = 'noises' AND array_length(answers, 1) = 1) OR
-(type = 'skips' AND array_length(answers, 1) >= 1)
+    (type = 'noises' AND array_length(answers, 1) >= 1) OR
+(type = 'skips' AND array_length(answers, 1) >= 1) OR
+(type = 'parsons' AND array_length(answers, 1) >= 2) OR
+(type = 'bugs' AND array_length(answers, 1) >= 1) OR
+(type = 'output' AND array_length(answers, 1) = 1) OR
+(type = 'quiz' AND array_length(answers, 1) >= 1) OR
+(type = 'translate' AND target_language_id IS NOT NULL) OR
+(type = 'explain' AND array_length(answers, 1) >= 1)
     )
     );
 
@@ -77,13 +188,115 @@ CREATE TABLE IF NOT EXISTS aliases (
 CREATE TABLE IF NOT EXISTS submissions (
     id SERIAL PRIMARY KEY,
     task_alias TEXT NOT NULL,
+    user_id INTEGER,
     submission_code TEXT[],
     status TEXT NOT NULL CHECK (status IN ('Pending', 'Success', 'Failed')),
     score INTEGER,
     hints TEXT[],
     submitted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (task_alias) REFERENCES aliases(alias) ON DELETE CASCADE
+    experiment_variant_id INTEGER,
+    FOREIGN KEY (task_alias) REFERENCES aliases(alias) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (experiment_variant_id) REFERENCES experiment_variants(id) ON DELETE SET NULL
+    );
+CREATE INDEX IF NOT EXISTS idx_submissions_user_id ON submissions(user_id);
+
+-- Create the experiment_generations table if it doesn't exist
+CREATE TABLE IF NOT EXISTS experiment_generations (
+    id SERIAL PRIMARY KEY,
+    variant_id INTEGER NOT NULL,
+    user_id INTEGER,
+    task_alias TEXT NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (variant_id) REFERENCES experiment_variants(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+    );
+CREATE INDEX IF NOT EXISTS idx_experiment_generations_variant ON experiment_generations(variant_id);
+
+-- Create the hint_reports table if it doesn't exist
+CREATE TABLE IF NOT EXISTS hint_reports (
+    id SERIAL PRIMARY KEY,
+    submission_id INTEGER NOT NULL,
+    user_id INTEGER,
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (submission_id, user_id),
+    FOREIGN KEY (submission_id) REFERENCES submissions(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+    );
+
+-- Create the hint_usage table if it doesn't exist
+CREATE TABLE IF NOT EXISTS hint_usage (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    item INTEGER NOT NULL,
+    level INTEGER NOT NULL CHECK (level BETWEEN 1 AND 3),
+    max_score INTEGER NOT NULL,
+    used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (task_id, user_id, item, level),
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );
+
+-- Create the tutor_messages table if it doesn't exist
+CREATE TABLE IF NOT EXISTS tutor_messages (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('student', 'tutor')),
+    content TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL CHECK (status IN ('Pending', 'Done', 'Failed')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
     );
+CREATE INDEX IF NOT EXISTS idx_tutor_messages_task_user ON tutor_messages(task_id, user_id, id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tutor_messages_pending ON tutor_messages(task_id, user_id) WHERE status = 'Pending';
+
+-- Create the llm_usage table if it doesn't exist
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER,
+    endpoint TEXT NOT NULL,
+    model TEXT NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cost NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+    );
+CREATE INDEX IF NOT EXISTS idx_llm_usage_user_created ON llm_usage(user_id, created_at);
+
+-- Create the llm_calls table if it doesn't exist
+CREATE TABLE IF NOT EXISTS llm_calls (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER,
+    endpoint TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    task_alias TEXT,
+    submission_id INTEGER,
+    prompt_version_id INTEGER,
+    model TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('ok', 'invalid', 'error', 'cached')),
+    error TEXT,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    cost NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    request_hash TEXT NOT NULL,
+    system_prompt TEXT NOT NULL,
+    user_prompt TEXT NOT NULL,
+    response TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+    );
+
+CREATE INDEX IF NOT EXISTS idx_llm_calls_created_at ON llm_calls(created_at);
+CREATE INDEX IF NOT EXISTS idx_llm_calls_task_alias ON llm_calls(task_alias);
+CREATE INDEX IF NOT EXISTS idx_llm_calls_submission_id ON llm_calls(submission_id);
+CREATE INDEX IF NOT EXISTS idx_llm_calls_request_hash ON llm_calls(request_hash, model);
 
 \echo 'Created tables'
 
@@ -24,349 +24,466 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Authenticates a user with email and password, returning access token and setting refresh token in a secure cookie.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Returns the public keys (RFC 7517) that currently verify access tokens issued by this service. Keys are identified by the kid header of a token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Auth"
                 ],
-                "summary": "Login user",
-                "parameters": [
-                    {
-                        "description": "User email and password",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                "summary": "JSON Web Key Set",
+                "responses": {
+                    "200": {
+                        "description": "Active verification keys",
                         "schema": {
-                            "$ref": "#/definitions/auth.LoginRequest"
+                            "$ref": "#/definitions/codular-backend_internal_jwt_keys.JWKSet"
                         }
                     }
+                }
+            }
+        },
+        "/admin/experiments": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all A/B experiments with their variants, newest first. Requires admin role.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
                 ],
+                "summary": "List experiments",
                 "responses": {
                     "200": {
-                        "description": "User logged in successfully",
+                        "description": "Experiments",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.ListResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request or empty body",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
-                    "401": {
-                        "description": "Invalid credentials",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     }
                 }
-            }
-        },
-        "/auth/logout": {
+            },
             "post": {
-                "description": "Invalidates the refresh token by clearing the secure cookie and removing it from the database.",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Starts an A/B experiment for one flow (skips_generate, noises_generate, skips_check, noises_check, bugs_generate, bugs_check, quiz_generate, translate_check, explain_generate, explain_check, skips_describe). Each variant pins a prompt version (0 is the active one) and optionally a model; users are assigned to variants by weight and stay in their variant until the experiment is stopped. Only one experiment per flow can be active. Requires admin role.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Admin"
+                ],
+                "summary": "Create experiment",
+                "parameters": [
+                    {
+                        "description": "Experiment and its variants",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.CreateRequest"
+                        }
+                    }
                 ],
-                "summary": "Logout user",
                 "responses": {
                     "200": {
-                        "description": "User logged out successfully",
+                        "description": "Experiment started",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
                     "400": {
-                        "description": "Missing refresh token cookie",
+                        "description": "Invalid request, flow or prompt version",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Flow already has an active experiment or the name is taken",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     }
                 }
             }
         },
-        "/auth/refresh": {
-            "post": {
-                "description": "Refreshes the access token using a valid refresh token provided in a secure cookie.",
+        "/admin/experiments/{id}/report": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns outcome metrics per variant: generation attempts and failures (generation flows), submissions and grading failures, solve rate over (task, user) pairs, average attempts per pair, and hint reports from users. Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Admin"
+                ],
+                "summary": "Experiment report",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Experiment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Refresh access token",
                 "responses": {
                     "200": {
-                        "description": "Access token refreshed successfully",
+                        "description": "Per-variant metrics",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.ReportResponse"
                         }
                     },
                     "400": {
-                        "description": "Missing refresh token cookie",
+                        "description": "Invalid ID",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
                     "401": {
-                        "description": "Invalid or expired refresh token",
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Experiment not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     }
                 }
             }
         },
-        "/auth/register": {
+        "/admin/experiments/{id}/stop": {
             "post": {
-                "description": "Registers a new user with email and password, returning access token and setting refresh token in a secure cookie.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Stops an active experiment. Variants recorded on tasks and submissions are kept, so the report stays available. Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Admin"
                 ],
-                "summary": "Register a new user",
+                "summary": "Stop experiment",
                 "parameters": [
                     {
-                        "description": "User email and password",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/auth.RegisterRequest"
-                        }
+                        "type": "integer",
+                        "description": "Experiment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User registered successfully",
+                        "description": "Experiment stopped",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
                     "400": {
-                        "description": "Invalid request or empty body",
+                        "description": "Invalid ID",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
-                    "409": {
-                        "description": "Email already exists",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Active experiment not found",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/auth.AuthResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.Response"
                         }
                     }
                 }
             }
         },
-        "/noises/generate": {
-            "post": {
+        "/admin/llm/cache-stats": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Processes the provided source code with a specified noise level, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. Returns the task alias for retrieving the task code and description.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns LLM response cache hits, misses and hit rate per request kind (skips_generate, noises_generate, skips_check, noises_check). Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Noises"
-                ],
-                "summary": "Generate and save noised code",
-                "parameters": [
-                    {
-                        "description": "Source code, noise level, and programming language",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/noises.Request"
-                        }
-                    }
+                    "Admin"
                 ],
+                "summary": "LLM cache statistics",
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "description": "Cache statistics",
                         "schema": {
-                            "$ref": "#/definitions/noises.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_cache_stats.Response"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request, empty body, or invalid programming language",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/noises.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_cache_stats.Response"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/noises.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_cache_stats.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/noises.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_cache_stats.Response"
                         }
                     }
                 }
             }
         },
-        "/noises/solve": {
-            "post": {
-                "description": "Submits a user's answer for a noises task identified by its alias, saves the submission, and processes it asynchronously. Returns a submission ID for status tracking.",
-                "consumes": [
-                    "application/json"
+        "/admin/llm/calls": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
                 ],
+                "description": "Returns the LLM call journal, newest first, without prompt and response bodies. Every exchange is recorded with its task alias, submission id, prompt version, model, latency and token usage; cache hits are recorded with status \"cached\". Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Noises"
+                    "Admin"
                 ],
-                "summary": "Submit answer for a noises task",
+                "summary": "List LLM calls",
                 "parameters": [
                     {
-                        "description": "Task alias and user answer",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/noises_check.ClientRequest"
-                        }
+                        "type": "integer",
+                        "description": "Filter by user ID",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by task alias",
+                        "name": "taskAlias",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter by submission ID",
+                        "name": "submissionId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by status (ok, invalid, error, cached)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "description": "LLM calls",
                         "schema": {
-                            "$ref": "#/definitions/noises_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.ListResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or validation error",
+                        "description": "Invalid query parameter",
                         "schema": {
-                            "$ref": "#/definitions/noises_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.ListResponse"
                         }
                     },
-                    "404": {
-                        "description": "Task not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/noises_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.ListResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.ListResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/noises_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.ListResponse"
                         }
                     }
                 }
             }
         },
-        "/skips/generate": {
-            "post": {
+        "/admin/llm/calls/{id}": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Processes the provided source code with a specified number of skips, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. Returns the task alias for retrieving the task code and description.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns a single LLM call with the raw system and user prompts and the raw model response. Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Skips"
+                    "Admin"
                 ],
-                "summary": "Generate and save skips code",
+                "summary": "Get LLM call",
                 "parameters": [
                     {
-                        "description": "Source code, number of skips, and programming language",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/skips.Request"
-                        }
+                        "type": "integer",
+                        "description": "LLM call ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "description": "LLM call",
                         "schema": {
-                            "$ref": "#/definitions/skips.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.Response"
                         }
                     },
                     "400": {
-                        "description": "Invalid request, empty body, or invalid programming language",
+                        "description": "Invalid ID",
                         "schema": {
-                            "$ref": "#/definitions/skips.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.Response"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/skips.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/skips.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_llm_calls.Response"
                         }
                     }
                 }
             }
         },
-        "/skips/solve": {
+        "/admin/login/unlock": {
             "post": {
-                "description": "Receives user answers for a given task alias, saves the submission, and asynchronously processes it.",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Clears failed login counters and any temporary lockout for the given email, including the two-factor step of the account with this email. Requires admin role.",
                 "consumes": [
                     "application/json"
                 ],
@@ -374,340 +491,379 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Skips"
+                    "Admin"
                 ],
-                "summary": "Submit answers for a skips task",
+                "summary": "Unlock login for an account",
                 "parameters": [
                     {
-                        "description": "Task alias and user's answers",
+                        "description": "Account email",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/skips_check.ClientRequest"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_unlock_login.Request"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Example response for successful submission\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "description": "Account unlocked",
                         "schema": {
-                            "$ref": "#/definitions/skips_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_unlock_login.Response"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or validation error",
+                        "description": "Invalid request",
                         "schema": {
-                            "$ref": "#/definitions/skips_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_unlock_login.Response"
                         }
                     },
-                    "404": {
-                        "description": "Task not found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/skips_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_unlock_login.Response"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_unlock_login.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/skips_check.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_unlock_login.Response"
                         }
                     }
                 }
             }
         },
-        "/submission-status/{submission_id}": {
+        "/admin/prompts": {
             "get": {
-                "description": "Retrieves the current status of a submission by its ID, including score and hints if available. The score indicates the correctness of the submission (e.g., 100 for success, \u003c100 for failure).",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the currently active version of every prompt. Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Submissions"
-                ],
-                "summary": "Get submission status",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Submission ID",
-                        "name": "submission_id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Admin"
                 ],
+                "summary": "List active prompt versions",
                 "responses": {
                     "200": {
-                        "description": "Example response for noises failure with hints\" Example({\"responseInfo\":{\"status\":\"OK\"},\"isCorrect\":\"Failed\",\"score\":50,\"hints\":[\"Check string concatenation order\",\"Avoid extra variables\"]})",
+                        "description": "Active prompt versions",
                         "schema": {
-                            "$ref": "#/definitions/submission_status.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.ListResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid submission ID format",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/submission_status.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
-                    "404": {
-                        "description": "Submission not found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/submission_status.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/submission_status.ServerResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     }
                 }
             }
         },
-        "/task-status/{alias}": {
-            "get": {
-                "description": "Returns the current status of a task by its alias.",
+        "/admin/prompts/{name}/preview": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Renders a draft (when templates are given), a specific version, or the active version with the supplied variables. Requires admin role.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Skips"
+                    "Admin"
                 ],
-                "summary": "Get task status",
+                "summary": "Preview prompt",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Task alias",
-                        "name": "alias",
+                        "description": "Prompt name",
+                        "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Variables and optional draft templates or version",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.PreviewRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"status\":\"Done\",\"result\":\"processed code\"})",
+                        "description": "Rendered prompt",
                         "schema": {
-                            "$ref": "#/definitions/task_status.StatusResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.PreviewResponse"
                         }
                     },
                     "400": {
-                        "description": "Alias parameter is missing",
+                        "description": "Invalid request, template or variables",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.PreviewResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.PreviewResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/task_status.StatusResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.PreviewResponse"
                         }
                     },
                     "404": {
-                        "description": "Task not found",
+                        "description": "Unknown prompt or version",
                         "schema": {
-                            "$ref": "#/definitions/task_status.StatusResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.PreviewResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/task_status.StatusResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.PreviewResponse"
                         }
                     }
                 }
             }
         },
-        "/task/random": {
+        "/admin/prompts/{name}/versions": {
             "get": {
-                "description": "Redirects to a random public task with public = true, filtered by task type (skips, noises, or any), use this syntax: .../random?type=[type]. The redirected endpoint returns the task code and description.",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns all versions of a prompt, newest first. Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Task"
+                    "Admin"
                 ],
-                "summary": "Get random public task",
+                "summary": "List prompt versions",
                 "parameters": [
                     {
-                        "enum": [
-                            "skips",
-                            "noises",
-                            "any"
-                        ],
                         "type": "string",
-                        "default": "any",
-                        "description": "Task type (skips, noises, or any)",
-                        "name": "type",
-                        "in": "query"
+                        "description": "Prompt name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "302": {
-                        "description": "Redirect to /api/v1/task/{alias}",
+                    "200": {
+                        "description": "Prompt versions",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.ListResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid task type",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "404": {
-                        "description": "No public tasks found for the specified type",
+                        "description": "Unknown prompt",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     }
                 }
-            }
-        },
-        "/task/{alias}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Retrieves a task by its alias, returning the task code, description (title), and edit permissions for the authenticated user. Requires user authorization.",
+                "description": "Saves a new inactive version of a prompt. Templates use Go text/template syntax, e.g. {{.SkipsCount}}, {{.NoiseLevel}}, {{.Language}}, {{.Locale}}, {{.Code}}. Requires admin role.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
+                    "Admin"
                 ],
-                "summary": "Get task by alias",
+                "summary": "Create prompt version",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Task alias",
-                        "name": "alias",
+                        "description": "Prompt name",
+                        "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "System and user templates",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.CreateRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"description\":\"String concatenation task\",\"codeToSolve\":\"s1 + s2\",\"canEdit\":true})",
+                        "description": "Version created",
                         "schema": {
-                            "$ref": "#/definitions/get_task.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "400": {
-                        "description": "Task alias is empty",
+                        "description": "Invalid request or template syntax",
                         "schema": {
-                            "$ref": "#/definitions/get_task.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/get_task.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "404": {
-                        "description": "Task not found or error retrieving task data",
+                        "description": "Unknown prompt",
                         "schema": {
-                            "$ref": "#/definitions/get_task.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/get_task.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     }
                 }
             }
         },
-        "/task/{alias}/regenerate": {
-            "patch": {
+        "/admin/prompts/{name}/versions/{version}/activate": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Regenerates an existing task (skips or noises) by its alias with optional new parameters (skips number or noise level). Updates the task code and description in the database. Requires user authorization and edit permissions. Returns the task alias for retrieving the updated task code and description.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Makes the given version the active one and invalidates the in-memory prompt cache. Requires admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tasks"
+                    "Admin"
                 ],
-                "summary": "Regenerate task by alias",
+                "summary": "Activate prompt version",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Task alias",
-                        "name": "alias",
+                        "description": "Prompt name",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Optional skips number or noise level",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/regenerate.Request"
-                        }
+                        "type": "integer",
+                        "description": "Version number",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "description": "Version activated",
                         "schema": {
-                            "$ref": "#/definitions/regenerate.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "400": {
-                        "description": "Invalid request, task alias is empty, or required parameters missing",
+                        "description": "Invalid version",
                         "schema": {
-                            "$ref": "#/definitions/regenerate.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/regenerate.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "403": {
-                        "description": "Forbidden: user does not have edit permissions",
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/regenerate.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "404": {
-                        "description": "Task not found",
+                        "description": "Unknown prompt or version",
                         "schema": {
-                            "$ref": "#/definitions/regenerate.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/regenerate.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_admin_prompt_versions.Response"
                         }
                     }
                 }
             }
         },
-        "/task/{alias}/set-public": {
-            "patch": {
-                "description": "Updates the public status of a task identified by its alias. Requires user authorization and edit permissions.",
+        "/auth/email/confirm": {
+            "post": {
+                "description": "Applies a pending email change using the token from the confirmation link.",
                 "consumes": [
                     "application/json"
                 ],
@@ -715,330 +871,4553 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Task"
+                    "Auth"
                 ],
-                "summary": "Set task public status",
+                "summary": "Confirm email change",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Task alias",
-                        "name": "alias",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Public status",
+                        "description": "Token from the confirmation link",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/edit_task.SetPublicRequest"
+                            "$ref": "#/definitions/internal_http_server_handlers_account.ConfirmEmailRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"response_info\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "description": "Email changed",
                         "schema": {
-                            "$ref": "#/definitions/edit_task.SetPublicResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
                         }
                     },
                     "400": {
-                        "description": "Invalid request or task alias is empty",
-                        "schema": {
-                            "$ref": "#/definitions/edit_task.SetPublicResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/edit_task.SetPublicResponse"
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden: user does not have edit permissions",
+                        "description": "Invalid or expired token",
                         "schema": {
-                            "$ref": "#/definitions/edit_task.SetPublicResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
                         }
                     },
-                    "404": {
-                        "description": "Task not found",
+                    "409": {
+                        "description": "Email already exists",
                         "schema": {
-                            "$ref": "#/definitions/edit_task.SetPublicResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/edit_task.SetPublicResponse"
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
                         }
                     }
                 }
             }
         },
-        "/user/email": {
-            "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
+        "/auth/login": {
+            "post": {
+                "description": "Authenticates a user with email and password, returning access token and setting refresh token in a secure cookie. If two-factor authentication is enabled, no tokens are issued; instead the response contains two_factor_required=true and a short-lived challenge_token for /auth/login/2fa.",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Retrieves the email of the authenticated user based on the access token provided in the Authorization header.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "Auth"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "User email and password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.LoginRequest"
+                        }
+                    }
                 ],
-                "summary": "Get user email",
                 "responses": {
                     "200": {
-                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"email\":\"user@example.com\"})",
+                        "description": "User logged in successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or empty body",
                         "schema": {
-                            "$ref": "#/definitions/get_user_email.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Invalid credentials",
                         "schema": {
-                            "$ref": "#/definitions/get_user_email.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After header",
                         "schema": {
-                            "$ref": "#/definitions/get_user_email.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/get_user_email.Response"
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.AuthResponse": {
-            "type": "object",
-            "properties": {
-                "access_token": {
-                    "type": "string"
-                },
-                "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
-                }
-            }
-        },
-        "auth.LoginRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
-            "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "password": {
-                    "type": "string"
-                }
-            }
         },
-        "auth.RegisterRequest": {
-            "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
-            "properties": {
-                "email": {
+        "/auth/login/2fa": {
+            "post": {
+                "description": "Exchanges the challenge token returned by /auth/login and a TOTP or recovery code for an access token, setting refresh token in a secure cookie.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Complete two-factor login",
+                "parameters": [
+                    {
+                        "description": "Challenge token and TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.LoginTwoFactorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User logged in successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or empty body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired challenge, or invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "Invalidates the refresh token by clearing the secure cookie and removing it from the database.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Logout user",
+                "responses": {
+                    "200": {
+                        "description": "User logged out successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing refresh token cookie",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Refreshes the access token using a valid refresh token provided in a secure cookie.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Refresh access token",
+                "responses": {
+                    "200": {
+                        "description": "Access token refreshed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing refresh token cookie",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Registers a new user with email and password, returning access token and setting refresh token in a secure cookie.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "User email and password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User registered successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or empty body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bugs/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Asks the LLM to inject the given number of bugs into the provided source code, one line per bug, and saves the task asynchronously. The buggy code is built from the original code, so the recorded line numbers and fixed lines are exact; bugs the model proposes for blank lines, for the same line twice or without changing the line are dropped, so the task may contain fewer bugs than requested. Returns the task alias for retrieving the task code and description.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bugs"
+                ],
+                "summary": "Generate and save find-the-bug code",
+                "parameters": [
+                    {
+                        "description": "Source code, number of bugs, and programming language",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_bugs.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_bugs.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, empty body, or invalid programming language",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_bugs.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_bugs.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_bugs.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/bugs/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Receives the line numbers the user believes contain bugs, each with an optional fixed line, saves the submission and grades it asynchronously. Grading is deterministic per line: a found bug with a correct fix (compared ignoring whitespace) or without a fix earns a full share, a found bug with a wrong fix earns half, and every line without a bug costs half a share. Hints are requested from the LLM only for the lines the user got wrong; hints for missed bugs do not reveal the line. Submissions are rejected while the user's LLM usage quota is exhausted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Bugs"
+                ],
+                "summary": "Submit bug lines for a find-the-bug task",
+                "parameters": [
+                    {
+                        "description": "Task alias and bug lines",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_bugs_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_bugs_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, validation error, or not a find-the-bug task",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_bugs_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_bugs_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_bugs_check.ServerResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_bugs_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/explain/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Asks the LLM for a description of the provided source code and a rubric: 3 to 6 key points, each weighted 1 to 3, that a good explanation of the code must mention. The task is saved asynchronously together with the rubric; the rubric is not shown to the student. Submissions are free-text explanations graded by the LLM point by point. Returns the task alias for retrieving the task code and description.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Explain"
+                ],
+                "summary": "Generate and save an explain-this-code task",
+                "parameters": [
+                    {
+                        "description": "Source code and programming language",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_explain.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_explain.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, empty body, or invalid programming language",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_explain.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_explain.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_explain.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/explain/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Receives the student's free-text explanation of the task code, saves the submission and grades it asynchronously. The LLM decides for every point of the rubric stored with the task whether the explanation covers it; the score is the covered share of the rubric weight and is computed by the server. Hints list each point: covered points are named, missed points get a hint that does not reveal them. Status, score and hints are available from /submission-status/{submission_id}. Submissions are rejected while the user's LLM usage quota is exhausted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Explain"
+                ],
+                "summary": "Submit an explanation of the code",
+                "parameters": [
+                    {
+                        "description": "Task alias and explanation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_explain_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_explain_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, validation error, or not an explain task",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_explain_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_explain_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_explain_check.ServerResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_explain_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/noises/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Processes the provided source code with a specified noise level, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. The edits the model made are recorded as a line diff against the original code: inserted lines are noise, changed or removed lines are semantic changes; submissions are graded against them. A ladder of hints (nudge, pointer, near_answer) is generated for every edit and revealed through POST /task/{alias}/hint. Returns the task alias for retrieving the task code and description.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Noises"
+                ],
+                "summary": "Generate and save noised code",
+                "parameters": [
+                    {
+                        "description": "Source code, noise level, and programming language",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_noises.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_noises.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, empty body, or invalid programming language",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_noises.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_noises.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_noises.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/noises/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Submits a user's answer for a noises task identified by its alias, saves the submission, and processes it asynchronously. Returns a submission ID for status tracking. The answer is the cleaned code. It is compared line by line (ignoring blank lines and whitespace) with the original code: every edit recorded at generation is either reverted or missed, and other differences count as unnecessary changes. The score is the share of reverted edits among all edits and unnecessary changes; hints name the line ranges of the task code and of the solution. Tasks created before edits were recorded are graded by the LLM. Hints revealed through POST /task/{alias}/hint before the submission lower the score.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Noises"
+                ],
+                "summary": "Submit answer for a noises task",
+                "parameters": [
+                    {
+                        "description": "Task alias and user answer",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_noises_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_noises_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_noises_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_noises_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_noises_check.ServerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_noises_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/output/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Runs the provided source code with the given stdin in a local sandbox (no network, limited time, memory and output) and saves the output it prints as the expected answer; the LLM is not involved. The program is run twice and must print the same non-empty output both times and exit with code 0, otherwise the task status becomes Error with the reason. Normalization (exact, trailing or collapse) sets how whitespace is compared when grading; the server default is used if omitted. Returns the task alias; poll /task-status/{alias} until it is Done.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Output"
+                ],
+                "summary": "Generate a predict-the-output task",
+                "parameters": [
+                    {
+                        "description": "Source code, stdin, normalization, and programming language",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_output.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_output.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, invalid programming language, or language not supported by the sandbox",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_output.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_output.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_output.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/output/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Compares the predicted output with the output recorded by running the program at generation time, using the whitespace normalization chosen for the task (exact, trailing or collapse). The submission is graded before the response is sent. On a mismatch the score is the share of matching lines and the hints contain the first mismatching line and a diff: lines starting with \"-\" were expected, lines starting with \"+\" were typed, spaces and tabs in changed lines are shown as · and →.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Output"
+                ],
+                "summary": "Submit predicted output",
+                "parameters": [
+                    {
+                        "description": "Task alias and predicted output",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_output_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_output_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, validation error, or not a predict-the-output task",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_output_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_output_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_output_check.ServerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_output_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/parsons/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Splits the provided source code into logical blocks (a line with its continuation lines; Python keeps its indentation, other languages have it stripped), optionally adds up to 5 distractor lines with a plausible mistake, shuffles the blocks and saves the task. No LLM is involved, so the task is ready immediately: its status is Done and codeToSolve of GET /task/{alias} is a JSON array of the shuffled blocks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Parsons"
+                ],
+                "summary": "Generate a Parsons problem",
+                "parameters": [
+                    {
+                        "description": "Source code, number of distractors, and programming language",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_parsons.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_parsons.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, invalid programming language, or code with fewer than two lines",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_parsons.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_parsons.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_parsons.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/parsons/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Receives the order of blocks (indexes into the codeToSolve array) chosen by the user and grades it deterministically, without an LLM. Identical blocks are interchangeable. The score is the length of the longest common subsequence with the correct order divided by the length of the longer sequence, so misplaced, missing and distractor blocks all lower it. The submission is graded before the response is sent; its status and hints are available from /submission-status/{submission_id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Parsons"
+                ],
+                "summary": "Submit block order for a Parsons problem",
+                "parameters": [
+                    {
+                        "description": "Task alias and block order",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_parsons_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_parsons_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, validation error, not a Parsons problem, or invalid block index",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_parsons_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_parsons_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_parsons_check.ServerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_parsons_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Asks the LLM for a description of the provided source code and the given number of multiple-choice questions about it (what a function returns, complexity, the purpose of a variable), each with one correct option and plausible distractors. The task is saved asynchronously. Questions with fewer than 2 or more than 6 options, duplicate options or an invalid correct option are dropped, so the quiz may contain fewer questions than requested. The questions are returned by /task/{alias} in random order for every attempt and are graded without an LLM. Returns the task alias for retrieving the task code and description.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Quiz"
+                ],
+                "summary": "Generate and save a code quiz",
+                "parameters": [
+                    {
+                        "description": "Source code, number of questions, and programming language",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_quiz.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_quiz.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, empty body, or invalid programming language",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_quiz.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_quiz.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_quiz.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiz/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Receives the option chosen for each question (question and option ids as returned by /task/{alias}) and grades the answers deterministically, without an LLM. Ids do not depend on the order in which questions and options were shown, so answers are valid for any attempt. Every question is worth the same share of the score; unanswered questions count as wrong. The submission is graded before the response is sent; its status and hints are available from /submission-status/{submission_id}. Hints name the questions answered incorrectly without revealing the correct options.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Quiz"
+                ],
+                "summary": "Submit answers to a code quiz",
+                "parameters": [
+                    {
+                        "description": "Task alias and chosen options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_quiz_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_quiz_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, validation error, not a quiz, or invalid question or option id",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_quiz_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_quiz_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_quiz_check.ServerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_quiz_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/skips/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Processes the provided source code with a specified number of skips, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. Returns the task alias for retrieving the task code and description. The generator field selects who places the skips: \"llm\" (default) lets the model choose them; \"ast\" parses the code (Python, Java or C++) into syntax trees and deterministically blanks exactly skipsNumber fragments — conditions, loop bounds, call arguments and return expressions — so the model only writes the description. A ladder of hints (nudge, pointer, near_answer) is generated for every skip and revealed through POST /task/{alias}/hint. With \"ast\" the request fails with 400 if the language is not supported or the code has fewer suitable fragments than requested.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Skips"
+                ],
+                "summary": "Generate and save skips code",
+                "parameters": [
+                    {
+                        "description": "Source code, number of skips, and programming language",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_skips.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_skips.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, empty body, invalid programming language, or too few fragments for the ast generator",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_skips.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_skips.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_skips.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/skips/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Receives user answers for a given task alias, saves the submission, and asynchronously processes it. A correct submission scores 100, lowered by hints revealed through POST /task/{alias}/hint before the submission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Skips"
+                ],
+                "summary": "Submit answers for a skips task",
+                "parameters": [
+                    {
+                        "description": "Task alias and user's answers",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_skips_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response for successful submission\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_skips_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_skips_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_skips_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_skips_check.ServerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_skips_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission-status/{submission_id}": {
+            "get": {
+                "description": "Retrieves the current status of a submission by its ID, including score and hints if available. The score indicates the correctness of the submission (e.g., 100 for success, \u003c100 for failure).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Submissions"
+                ],
+                "summary": "Get submission status",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Submission ID",
+                        "name": "submission_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response for noises failure with hints\" Example({\"responseInfo\":{\"status\":\"OK\"},\"isCorrect\":\"Failed\",\"score\":50,\"hints\":[\"Check string concatenation order\",\"Avoid extra variables\"]})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_submission_status.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid submission ID format",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_submission_status.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Submission not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_submission_status.ServerResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_submission_status.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/submission/{submission_id}/report-hints": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Marks the hints of the user's own submission as unhelpful or wrong, with an optional comment. Reporting again replaces the comment. Reports are counted per variant in experiment reports.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Submissions"
+                ],
+                "summary": "Report bad hints",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Submission ID",
+                        "name": "submission_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional comment",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_report_hints.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report saved",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_report_hints.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid submission ID or request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_report_hints.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_report_hints.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Submission not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_report_hints.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_report_hints.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/task-status/{alias}": {
+            "get": {
+                "description": "Returns the current status of a task by its alias: Processing, Done, Error or Cancelled. While processing, progress reports the model, streamed tokens received, elapsed time and the task description once it has been generated; updatedAt stops advancing if the generation stalls.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Skips"
+                ],
+                "summary": "Get task status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response while processing\" Example({\"status\":\"Processing\",\"progress\":{\"model\":\"openai/gpt-4o-mini\",\"tokensReceived\":84,\"elapsedMs\":5200,\"description\":\"Factorial of a number\",\"updatedAt\":\"2025-05-01T12:00:05Z\"}})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Alias parameter is missing",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/random": {
+            "get": {
+                "description": "Redirects to a random public task with public = true, filtered by task type (skips, noises, parsons, bugs, output, quiz, translate, explain, or any), use this syntax: .../random?type=[type]. The redirected endpoint returns the task code and description.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Get random public task",
+                "parameters": [
+                    {
+                        "enum": [
+                            "skips",
+                            "noises",
+                            "parsons",
+                            "bugs",
+                            "output",
+                            "quiz",
+                            "translate",
+                            "explain",
+                            "any"
+                        ],
+                        "type": "string",
+                        "default": "any",
+                        "description": "Task type (skips, noises, parsons, bugs, output, quiz, translate, explain, or any)",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to /api/v1/task/{alias}",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid task type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "No public tasks found for the specified type",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/task/{alias}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieves a task by its alias, returning the task code, description (title), and edit permissions for the authenticated user. Predict-the-output tasks also return the stdin the program reads. Quiz tasks also return the questions without the correct options; questions and options are shuffled on every request, while their ids stay the same. Translate tasks also return the target language. Requires user authorization.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Get task by alias",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"description\":\"String concatenation task\",\"codeToSolve\":\"s1 + s2\",\"canEdit\":true})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_get_task.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Task alias is empty",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_get_task.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_get_task.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found or error retrieving task data",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_get_task.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_get_task.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/{alias}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Cancels a generation or regeneration started by the current user. The worker stops within about a second and the status becomes Cancelled; a generation whose progress has not been updated for a while (e.g. its server restarted) is marked Cancelled immediately. A cancelled regeneration keeps the previous version of the task.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Skips"
+                ],
+                "summary": "Cancel task generation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Generation cancelled",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Cancellation requested",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Generation was started by another user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Task is not being generated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_status_task_status.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/{alias}/hint": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reveals the next hint for one skip of a skips task or one edit of a noises task. Every skip and edit has a ladder of three hints generated with the task: nudge, pointer and near_answer; each call reveals the next level for the requested item (or, without item, for the first item that still has hidden hints). Revealed hints are recorded per user and lower the maximum score of the user's subsequent submissions: revealing up to nudge, pointer or near_answer costs 20%, 50% or 80% of that item's share of the score. Regenerating the task replaces its hints and resets revealed ones.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Reveal the next hint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional item number, starting from 1",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_task_hint.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"item\":1,\"level\":2,\"levelName\":\"pointer\",\"hint\":\"Skip 1: the answer is a function call, 8 characters long.\",\"maxScore\":84})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_task_hint.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, the task type has no hints, unknown item, or all hints already revealed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_task_hint.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_task_hint.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found or the task has no hints",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_task_hint.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_task_hint.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/{alias}/regenerate": {
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Regenerates an existing task (skips, noises, bugs, quiz or explain) by its alias with optional new parameters (skips number, noise level, bugs count or questions count; explain tasks take none). Parsons problems, predict-the-output and translate tasks cannot be regenerated. Updates the task code and description in the database. Always requests a fresh variant from the LLM (the response cache is bypassed). Skips tasks accept the same generator field as /skips/generate; the ast generator is deterministic, so it changes the skips only when skipsNumber changes. Skips and noises tasks get new hint ladders, and hints users already revealed are reset. Requires user authorization and edit permissions. Returns the task alias for retrieving the updated task code and description.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Regenerate task by alias",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional skips number or noise level",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_regenerate.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_regenerate.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, task alias is empty, or required parameters missing",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_regenerate.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_regenerate.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: user does not have edit permissions",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_regenerate.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_regenerate.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_regenerate.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/{alias}/set-public": {
+            "patch": {
+                "description": "Updates the public status of a task identified by its alias. Requires user authorization and edit permissions.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Task"
+                ],
+                "summary": "Set task public status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Public status",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_edit_task.SetPublicRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"response_info\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_edit_task.SetPublicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or task alias is empty",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_edit_task.SetPublicResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_edit_task.SetPublicResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden: user does not have edit permissions",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_edit_task.SetPublicResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_edit_task.SetPublicResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_edit_task.SetPublicResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/task/{alias}/tutor": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the user's conversation with the AI tutor of a task in chronological order (up to the last 200 messages). Student messages have role \"student\", tutor replies role \"tutor\"; a reply is Pending while it is being generated, then Done or Failed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Get the tutor conversation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conversation history",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.HistoryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid alias",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.HistoryResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.HistoryResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.HistoryResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.HistoryResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Sends a follow-up question to the AI tutor of a task. The tutor sees the task code, the user's latest submission with its result and hints, and the hint-ladder hints the user has revealed, and is instructed never to reveal answers verbatim. The question and a pending tutor reply are saved to the conversation history at once; the reply is generated asynchronously and appears in GET /task/{alias}/tutor with status Done (or Failed). Only one reply per task can be pending. Questions are limited per user (tutor.max_messages per tutor.window; 429 with Retry-After when exceeded) and count towards the LLM usage quota.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "Ask the task tutor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task alias",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Question and optional reply locale",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.AskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"replyId\":42})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.AskResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.AskResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.AskResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.AskResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Previous reply is still being generated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.AskResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_tutor_chat.AskResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "description": "Retrieves a paginated list of public tasks filtered by task type, sorted by creation date (descending). Requires query parameters for pagination (offset, limit) and optional task type.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "List public tasks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "any",
+                        "description": "Task type (e.g., skips, noises, parsons, bugs, output, quiz, translate, explain, or any for all types)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit for pagination",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"tasks\":[{\"alias\":\"abc123\",\"task_id\":1,\"type\":\"skips\",\"description\":\"String concatenation task\",\"programming_language\":\"Python\",\"created_at\":\"2025-06-16T12:00:00Z\"}],\"total\":1})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_list.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_list.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_task_list.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/translate/generate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a task where the student receives the provided code and must write the equivalent program in the target language (Java, Python or C++). If the sandbox supports the source language, the code is run on each test input (or once with empty stdin when no inputs are given) and the outputs are saved as tests; submissions are then graded by running them on the same inputs. A program that cannot be run (e.g. a fragment without an entry point) is saved without tests, unless test inputs were given explicitly, and its submissions are graded by an LLM equivalence check. The source and target languages are stored on the task. Returns the task alias; poll /task-status/{alias} until it is Done.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translate"
+                ],
+                "summary": "Generate a translate task",
+                "parameters": [
+                    {
+                        "description": "Source code, source and target programming languages, and optional test inputs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_translate.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"taskAlias\":\"abc123\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_translate.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, invalid programming language, the same source and target language, or test inputs for a language not supported by the sandbox",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_translate.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_translate.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_generate_translate.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/translate/solve": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Receives the program written in the task's target language, saves the submission and grades it asynchronously. If the task has tests and the sandbox supports the target language, the program is run on every test input and its output is compared with the output of the original program (trailing whitespace ignored by default); the score is the share of passed tests, and hints name the failed tests with a diff of the first one. A program that does not compile gets score 0 and the compiler output as a hint. Tasks without tests, and submissions the sandbox cannot run, are graded by an LLM equivalence check with hints. Status, score and hints are available from /submission-status/{submission_id}. Submissions are rejected while the user's LLM usage quota is exhausted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Translate"
+                ],
+                "summary": "Submit a translation",
+                "parameters": [
+                    {
+                        "description": "Task alias and translated program",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_translate_check.ClientRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"submissionId\":123})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_translate_check.ServerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body, validation error, or not a translate task",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_translate_check.ServerResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_translate_check.ServerResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Task not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_translate_check.ServerResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "LLM usage quota exceeded; see resetAt and Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_http_server_middleware.QuotaResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_solve_translate_check.ServerResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Deletes the account after re-authentication (password, plus TOTP or recovery code when two-factor authentication is enabled). Depending on server policy, tasks and submissions are either deleted (\"cascade\") or public tasks and submissions are kept detached from the account (\"anonymize\").",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete account",
+                "parameters": [
+                    {
+                        "description": "Current password and optional second factor code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.DeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Account deleted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or empty body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Verifies a code from the authenticator app, enables two-factor authentication and returns one-time recovery codes. Recovery codes are shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Confirm TOTP enrollment",
+                "parameters": [
+                    {
+                        "description": "TOTP code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.ConfirmRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication enabled",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or enrollment not started",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Disables TOTP after re-authentication with the current password and a valid TOTP or recovery code. Removes the secret and all recovery codes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Disable two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Current password and TOTP or recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.DisableRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication disabled",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or two-factor authentication is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Generates a new TOTP secret and returns it together with an otpauth:// provisioning URI for authenticator apps. Two-factor authentication is enabled only after confirmation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Start TOTP enrollment",
+                "responses": {
+                    "200": {
+                        "description": "Enrollment started",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_two_factor.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieves the email of the authenticated user based on the access token provided in the Authorization header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get user email",
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"email\":\"user@example.com\"})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_email.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_email.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_email.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_email.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Re-checks the current password and sends a confirmation link to the new address. The email is changed only after the link is confirmed (valid for 24 hours).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Request email change",
+                "parameters": [
+                    {
+                        "description": "New email and current password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.ChangeEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation email sent",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or empty body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid password",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/export": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Downloads a JSON archive with the user profile, created tasks, submissions, revealed hints, tutor chat messages and personal access token metadata (token values are never stored).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Export account data",
+                "responses": {
+                    "200": {
+                        "description": "Account data",
+                        "schema": {
+                            "$ref": "#/definitions/codular-backend_internal_storage_database.UserExport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_account.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Changes the password after re-checking the current one. All existing sessions are revoked; the caller receives a fresh access token and refresh token cookie.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "Current and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or empty body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized or invalid current password",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts; see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_auth.AuthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Retrieves a paginated list of tasks associated with the authenticated user, sorted by creation date (descending). Requires query parameters for pagination (offset, limit). Authentication is required via Bearer token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tasks"
+                ],
+                "summary": "List user tasks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset for pagination",
+                        "name": "offset",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Limit for pagination",
+                        "name": "limit",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Example response\" Example({\"responseInfo\":{\"status\":\"OK\"},\"tasks\":[{\"alias\":\"xyz789\",\"task_id\":1,\"type\":\"skips\",\"description\":\"String concatenation task\",\"programming_language\":\"Python\",\"created_at\":\"2025-06-16T12:00:00Z\"}],\"total\":1})",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_tasks.UserTasksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_tasks.UserTasksResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_tasks.UserTasksResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_tasks.UserTasksResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns metadata (name, prefix, scopes, expiry, last usage, revocation) of the user's personal access tokens. Token values are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "List personal access tokens",
+                "responses": {
+                    "200": {
+                        "description": "Tokens retrieved",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.ListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.ListResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Creates a long-lived, scoped personal access token for scripting. The token value is returned only once; only its hash is stored. Available scopes: tasks:read, tasks:write, submissions:read, submissions:write.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Create personal access token",
+                "parameters": [
+                    {
+                        "description": "Token name, scopes and optional expiry",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.CreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token created",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.CreateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.CreateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.CreateResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Api tokens cannot manage tokens",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.CreateResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.CreateResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/tokens/{token_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revokes one of the user's personal access tokens by its ID. Revoked tokens are rejected immediately.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Revoke personal access token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Token ID",
+                        "name": "token_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token revoked",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.RevokeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.RevokeResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.RevokeResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Token not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.RevokeResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_api_tokens.RevokeResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/usage": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Returns the authenticated user's LLM usage (requests, prompt/completion tokens, cost) for the current UTC day and month, broken down by endpoint, together with the quota limits for the user's role and reset times.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get LLM usage",
+                "responses": {
+                    "200": {
+                        "description": "Usage and limits",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_usage.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_usage.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_http_server_handlers_get_user_usage.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "codular-backend_internal_http_server_middleware.QuotaResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "string"
+                },
+                "resetAt": {
+                    "type": "string"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "codular-backend_internal_jwt_keys.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_jwt_keys.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_jwt_keys.JWK"
+                    }
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.APIToken": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.Experiment": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "endedAt": {
+                    "type": "string"
+                },
+                "flow": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.ExperimentVariant"
+                    }
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.ExperimentVariant": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "promptVersion": {
+                    "type": "integer"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.ExperimentVariantMetrics": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts — пары (задача, пользователь), Solved — из них решённые",
+                    "type": "integer"
+                },
+                "averageAttempts": {
+                    "type": "number"
+                },
+                "badHintReports": {
+                    "type": "integer"
+                },
+                "generationFailures": {
+                    "type": "integer"
+                },
+                "generations": {
+                    "description": "Generations и GenerationFailures заполняются для экспериментов с генерацией задач",
+                    "type": "integer"
+                },
+                "gradingFailures": {
+                    "type": "integer"
+                },
+                "solved": {
+                    "type": "integer"
+                },
+                "submissions": {
+                    "description": "Submissions — посылки к задачам варианта (генерация) или оценённые вариантом (проверка);\nGradingFailures — посылки, которые не удалось оценить",
+                    "type": "integer"
+                },
+                "variant": {
+                    "type": "string"
+                },
+                "variantId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.ExportHintUsage": {
+            "type": "object",
+            "properties": {
+                "item": {
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "task_alias": {
+                    "type": "string"
+                },
+                "used_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.ExportProfile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "two_factor_enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.ExportSubmission": {
+            "type": "object",
+            "properties": {
+                "hints": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "submission_code": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "submitted_at": {
+                    "type": "string"
+                },
+                "task_alias": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.ExportTask": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "is_public": {
+                    "type": "boolean"
+                },
+                "original_code": {
+                    "type": "string"
+                },
+                "programming_language": {
+                    "type": "string"
+                },
+                "prompt_version_id": {
+                    "type": "integer"
+                },
+                "task_code": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.ExportTutorMessage": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "task_alias": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.LLMCall": {
+            "type": "object",
+            "properties": {
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "latencyMs": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "promptTokens": {
+                    "type": "integer"
+                },
+                "promptVersionId": {
+                    "type": "integer"
+                },
+                "requestHash": {
+                    "type": "string"
+                },
+                "response": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "submissionId": {
+                    "type": "integer"
+                },
+                "systemPrompt": {
+                    "type": "string"
+                },
+                "taskAlias": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "userPrompt": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.LLMEndpointUsage": {
+            "type": "object",
+            "properties": {
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "promptTokens": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.PromptVersion": {
+            "type": "object",
+            "properties": {
+                "activatedAt": {
+                    "type": "string"
+                },
+                "active": {
+                    "type": "boolean"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "systemTemplate": {
+                    "type": "string"
+                },
+                "userTemplate": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.Task": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "programming_language": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.TaskProgress": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "Description — описание задачи, как только оно разобрано из ответа",
+                    "type": "string"
+                },
+                "elapsedMs": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "tokensReceived": {
+                    "description": "TokensReceived — число полученных фрагментов потокового ответа текущей модели",
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.TutorMessage": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_internal_storage_database.UserExport": {
+            "type": "object",
+            "properties": {
+                "api_tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.APIToken"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "hint_usage": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.ExportHintUsage"
+                    }
+                },
+                "profile": {
+                    "$ref": "#/definitions/codular-backend_internal_storage_database.ExportProfile"
+                },
+                "submissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.ExportSubmission"
+                    }
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.ExportTask"
+                    }
+                },
+                "tutor_messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.ExportTutorMessage"
+                    }
+                }
+            }
+        },
+        "codular-backend_lib_api_response.ResponseInfo": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_lib_bugs.Answer": {
+            "type": "object",
+            "properties": {
+                "fix": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "codular-backend_lib_quiz.Answer": {
+            "type": "object",
+            "properties": {
+                "option": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "question": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "codular-backend_lib_quiz.Option": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "codular-backend_lib_quiz.View": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_lib_quiz.Option"
+                    }
+                },
+                "question": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_account.ChangeEmailRequest": {
+            "type": "object",
+            "required": [
+                "new_email",
+                "password"
+            ],
+            "properties": {
+                "new_email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_account.ConfirmEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_account.DeleteRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code — TOTP-код или код восстановления, обязателен при включённой 2FA",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_account.Response": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_experiments.CreateRequest": {
+            "type": "object",
+            "required": [
+                "flow",
+                "name",
+                "variants"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "flow": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "variants": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 2,
+                    "items": {
+                        "$ref": "#/definitions/internal_http_server_handlers_admin_experiments.VariantRequest"
+                    }
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_experiments.ListResponse": {
+            "type": "object",
+            "properties": {
+                "experiments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.Experiment"
+                    }
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_experiments.ReportResponse": {
+            "type": "object",
+            "properties": {
+                "experiment": {
+                    "$ref": "#/definitions/codular-backend_internal_storage_database.Experiment"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.ExperimentVariantMetrics"
+                    }
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_experiments.Response": {
+            "type": "object",
+            "properties": {
+                "experiment": {
+                    "$ref": "#/definitions/codular-backend_internal_storage_database.Experiment"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_experiments.VariantRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "model": {
+                    "description": "Model — модель вместо настроенной цепочки",
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "promptVersion": {
+                    "description": "PromptVersion — номер версии промпта сценария (0 — активная)",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "weight": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 0
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_llm_cache_stats.KindStats": {
+            "type": "object",
+            "properties": {
+                "hitRate": {
+                    "type": "number"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_llm_cache_stats.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "stats": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/internal_http_server_handlers_admin_llm_cache_stats.KindStats"
+                    }
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_llm_calls.ListResponse": {
+            "type": "object",
+            "properties": {
+                "calls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.LLMCall"
+                    }
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_llm_calls.Response": {
+            "type": "object",
+            "properties": {
+                "call": {
+                    "$ref": "#/definitions/codular-backend_internal_storage_database.LLMCall"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_prompt_versions.CreateRequest": {
+            "type": "object",
+            "required": [
+                "systemTemplate",
+                "userTemplate"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "systemTemplate": {
+                    "type": "string"
+                },
+                "userTemplate": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_prompt_versions.ListResponse": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.PromptVersion"
+                    }
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_prompt_versions.PreviewRequest": {
+            "type": "object",
+            "properties": {
+                "systemTemplate": {
+                    "type": "string"
+                },
+                "userTemplate": {
+                    "type": "string"
+                },
+                "vars": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "version": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_prompt_versions.PreviewResponse": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "systemPrompt": {
+                    "type": "string"
+                },
+                "userPrompt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_prompt_versions.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "version": {
+                    "$ref": "#/definitions/codular-backend_internal_storage_database.PromptVersion"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_unlock_login.Request": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_admin_unlock_login.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_api_tokens.CreateRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresInDays": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 1
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_http_server_handlers_api_tokens.CreateResponse": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "tokenInfo": {
+                    "$ref": "#/definitions/codular-backend_internal_storage_database.APIToken"
+                }
+            }
+        },
+        "internal_http_server_handlers_api_tokens.ListResponse": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.APIToken"
+                    }
+                }
+            }
+        },
+        "internal_http_server_handlers_api_tokens.RevokeResponse": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_auth.AuthResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "challenge_token": {
+                    "type": "string"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "two_factor_required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_http_server_handlers_auth.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "internal_http_server_handlers_auth.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_auth.LoginTwoFactorRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_auth.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "internal_http_server_handlers_edit_task.SetPublicRequest": {
+            "type": "object",
+            "required": [
+                "public"
+            ],
+            "properties": {
+                "public": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "internal_http_server_handlers_edit_task.SetPublicResponse": {
+            "type": "object",
+            "properties": {
+                "response_info": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_bugs.Request": {
+            "type": "object",
+            "required": [
+                "bugsCount",
+                "programmingLanguage",
+                "sourceCode"
+            ],
+            "properties": {
+                "bugsCount": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "sourceCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_bugs.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_explain.Request": {
+            "type": "object",
+            "required": [
+                "programmingLanguage",
+                "sourceCode"
+            ],
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "sourceCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_explain.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_noises.Request": {
+            "type": "object",
+            "required": [
+                "noiseLevel",
+                "programmingLanguage",
+                "sourceCode"
+            ],
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "noiseLevel": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "sourceCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_noises.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_output.Request": {
+            "type": "object",
+            "required": [
+                "programmingLanguage",
+                "sourceCode"
+            ],
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "normalization": {
+                    "type": "string",
+                    "enum": [
+                        "exact",
+                        "trailing",
+                        "collapse"
+                    ]
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "sourceCode": {
+                    "type": "string"
+                },
+                "stdin": {
+                    "type": "string",
+                    "maxLength": 65536
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_output.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_parsons.Request": {
+            "type": "object",
+            "required": [
+                "programmingLanguage",
+                "sourceCode"
+            ],
+            "properties": {
+                "distractors": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 0
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "sourceCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_parsons.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_quiz.Request": {
+            "type": "object",
+            "required": [
+                "programmingLanguage",
+                "questionsCount",
+                "sourceCode"
+            ],
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "questionsCount": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
+                },
+                "sourceCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_quiz.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_skips.Request": {
+            "type": "object",
+            "required": [
+                "programmingLanguage",
+                "skipsNumber",
+                "sourceCode"
+            ],
+            "properties": {
+                "generator": {
+                    "description": "Generator — кто расставляет пропуски: модель (llm, по умолчанию) или разбор синтаксиса (ast)",
+                    "type": "string",
+                    "enum": [
+                        "llm",
+                        "ast"
+                    ]
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "skipsNumber": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "sourceCode": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_skips.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_translate.Request": {
+            "type": "object",
+            "required": [
+                "programmingLanguage",
+                "sourceCode",
+                "targetLanguage"
+            ],
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "programmingLanguage": {
+                    "type": "string"
+                },
+                "sourceCode": {
+                    "type": "string"
+                },
+                "targetLanguage": {
+                    "type": "string"
+                },
+                "testInputs": {
+                    "description": "TestInputs — входные данные, на которых сравниваются программы; если не заданы, программа запускается с пустым вводом",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_http_server_handlers_generate_translate.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_get_status_submission_status.ServerResponse": {
+            "type": "object",
+            "properties": {
+                "hints": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "isCorrect": {
+                    "type": "string"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "score": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_get_status_task_status.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "progress": {
+                    "description": "Progress — ход генерации, пока статус \"Processing\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codular-backend_internal_storage_database.TaskProgress"
+                        }
+                    ]
+                },
+                "result": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_get_task_get_task.Response": {
+            "type": "object",
+            "properties": {
+                "canEdit": {
+                    "type": "boolean"
+                },
+                "codeToSolve": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "isPublic": {
+                    "type": "boolean"
+                },
+                "programmingLang": {
+                    "type": "string"
+                },
+                "questions": {
+                    "description": "Questions — вопросы задачи quiz; порядок вопросов и вариантов новый при каждом запросе",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_lib_quiz.View"
+                    }
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "stdin": {
+                    "description": "Stdin — ввод программы (задачи output)",
+                    "type": "string"
+                },
+                "targetLanguage": {
+                    "description": "TargetLanguage — язык, на который нужно перевести код (задачи translate)",
+                    "type": "string"
+                },
+                "taskType": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_get_task_list.Response": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.Task"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_get_user_email.Response": {
+            "type": "object",
+            "properties": {
+                "email": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 8
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_get_user_tasks.UserTasksResponse": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.Task"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_get_user_usage.Limits": {
+            "type": "object",
+            "properties": {
+                "dailyRequests": {
+                    "type": "integer"
+                },
+                "dailyTokens": {
+                    "type": "integer"
+                },
+                "monthlyTokens": {
+                    "type": "integer"
                 }
             }
         },
-        "edit_task.SetPublicRequest": {
+        "internal_http_server_handlers_get_user_usage.Period": {
             "type": "object",
-            "required": [
-                "public"
-            ],
             "properties": {
-                "public": {
-                    "type": "boolean"
+                "byEndpoint": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.LLMEndpointUsage"
+                    }
+                },
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "promptTokens": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                },
+                "resetAt": {
+                    "type": "string"
                 }
             }
         },
-        "edit_task.SetPublicResponse": {
+        "internal_http_server_handlers_get_user_usage.Response": {
             "type": "object",
             "properties": {
-                "response_info": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                "limits": {
+                    "$ref": "#/definitions/internal_http_server_handlers_get_user_usage.Limits"
                 },
-                "taskAlias": {
+                "month": {
+                    "$ref": "#/definitions/internal_http_server_handlers_get_user_usage.Period"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "role": {
                     "type": "string"
+                },
+                "today": {
+                    "$ref": "#/definitions/internal_http_server_handlers_get_user_usage.Period"
                 }
             }
         },
-        "get_task.Response": {
+        "internal_http_server_handlers_regenerate.Request": {
             "type": "object",
             "properties": {
-                "canEdit": {
-                    "type": "boolean"
+                "bugsCount": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
                 },
-                "codeToSolve": {
-                    "type": "string"
+                "generator": {
+                    "description": "Generator — генератор пропусков для skips (llm или ast), по умолчанию llm",
+                    "type": "string",
+                    "enum": [
+                        "llm",
+                        "ast"
+                    ]
                 },
-                "description": {
-                    "type": "string"
+                "noiseLevel": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 0
                 },
-                "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                "questionsCount": {
+                    "type": "integer",
+                    "maximum": 10,
+                    "minimum": 1
+                },
+                "skipsNumber": {
+                    "type": "integer",
+                    "minimum": 0
                 }
             }
         },
-        "get_user_email.Response": {
+        "internal_http_server_handlers_regenerate.Response": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_report_hints.Request": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 1000
+                }
+            }
+        },
+        "internal_http_server_handlers_report_hints.Response": {
+            "type": "object",
+            "properties": {
                 "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 }
             }
         },
-        "noises.Request": {
+        "internal_http_server_handlers_solve_bugs_check.ClientRequest": {
             "type": "object",
             "required": [
-                "noiseLevel",
-                "programmingLanguage",
-                "sourceCode"
+                "answers",
+                "taskAlias"
             ],
             "properties": {
-                "noiseLevel": {
-                    "type": "integer",
-                    "maximum": 100,
-                    "minimum": 0
-                },
-                "programmingLanguage": {
-                    "type": "string"
+                "answers": {
+                    "description": "Answers — строки с ошибками и необязательные исправления",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_lib_bugs.Answer"
+                    }
                 },
-                "sourceCode": {
+                "taskAlias": {
                     "type": "string"
                 }
             }
         },
-        "noises.Response": {
+        "internal_http_server_handlers_solve_bugs_check.ServerResponse": {
             "type": "object",
             "properties": {
                 "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 },
-                "taskAlias": {
-                    "type": "string"
+                "submissionId": {
+                    "type": "integer"
                 }
             }
         },
-        "noises_check.ClientRequest": {
+        "internal_http_server_handlers_solve_explain_check.ClientRequest": {
             "type": "object",
             "required": [
-                "answer",
+                "explanation",
                 "taskAlias"
             ],
             "properties": {
-                "answer": {
-                    "type": "string"
+                "explanation": {
+                    "description": "Explanation — объяснение кода своими словами",
+                    "type": "string",
+                    "maxLength": 5000
                 },
                 "taskAlias": {
                     "type": "string"
                 }
             }
         },
-        "noises_check.ServerResponse": {
+        "internal_http_server_handlers_solve_explain_check.ServerResponse": {
             "type": "object",
             "properties": {
                 "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 },
                 "submissionId": {
                     "type": "integer"
                 }
             }
         },
-        "regenerate.Request": {
+        "internal_http_server_handlers_solve_noises_check.ClientRequest": {
             "type": "object",
+            "required": [
+                "answer",
+                "taskAlias"
+            ],
             "properties": {
-                "noiseLevel": {
-                    "type": "integer",
-                    "maximum": 10,
-                    "minimum": 0
+                "answer": {
+                    "type": "string",
+                    "maxLength": 65536
                 },
-                "skipsNumber": {
-                    "type": "integer",
-                    "minimum": 0
+                "taskAlias": {
+                    "type": "string"
                 }
             }
         },
-        "regenerate.Response": {
+        "internal_http_server_handlers_solve_noises_check.ServerResponse": {
             "type": "object",
             "properties": {
                 "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "submissionId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_solve_output_check.ClientRequest": {
+            "type": "object",
+            "required": [
+                "taskAlias"
+            ],
+            "properties": {
+                "output": {
+                    "description": "Output — вывод программы, как его представляет пользователь",
+                    "type": "string",
+                    "maxLength": 65536
                 },
                 "taskAlias": {
                     "type": "string"
                 }
             }
         },
-        "response_info.ResponseInfo": {
+        "internal_http_server_handlers_solve_output_check.ServerResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 },
-                "status": {
-                    "type": "string"
+                "submissionId": {
+                    "type": "integer"
                 }
             }
         },
-        "skips.Request": {
+        "internal_http_server_handlers_solve_parsons_check.ClientRequest": {
             "type": "object",
             "required": [
-                "programmingLanguage",
-                "skipsNumber",
-                "sourceCode"
+                "order",
+                "taskAlias"
             ],
             "properties": {
-                "programmingLanguage": {
-                    "type": "string"
-                },
-                "skipsNumber": {
-                    "type": "integer",
-                    "minimum": 0
+                "order": {
+                    "description": "Order — индексы блоков из codeToSolve в порядке, выбранном пользователем",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "sourceCode": {
+                "taskAlias": {
                     "type": "string"
                 }
             }
         },
-        "skips.Response": {
+        "internal_http_server_handlers_solve_parsons_check.ServerResponse": {
             "type": "object",
             "properties": {
                 "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 },
-                "taskAlias": {
-                    "type": "string"
+                "submissionId": {
+                    "type": "integer"
                 }
             }
         },
-        "skips_check.ClientRequest": {
+        "internal_http_server_handlers_solve_quiz_check.ClientRequest": {
             "type": "object",
             "required": [
                 "answers",
@@ -1046,9 +5425,11 @@ const docTemplate = `{
             ],
             "properties": {
                 "answers": {
+                    "description": "Answers — выбранные варианты; идентификаторы вопросов и вариантов берутся из /task/{alias}",
                     "type": "array",
+                    "minItems": 1,
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/codular-backend_lib_quiz.Answer"
                     }
                 },
                 "taskAlias": {
@@ -1056,50 +5437,194 @@ const docTemplate = `{
                 }
             }
         },
-        "skips_check.ServerResponse": {
+        "internal_http_server_handlers_solve_quiz_check.ServerResponse": {
             "type": "object",
             "properties": {
                 "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 },
                 "submissionId": {
                     "type": "integer"
                 }
             }
         },
-        "submission_status.ServerResponse": {
+        "internal_http_server_handlers_solve_skips_check.ClientRequest": {
             "type": "object",
+            "required": [
+                "answers",
+                "taskAlias"
+            ],
             "properties": {
-                "hints": {
+                "answers": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "isCorrect": {
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_solve_skips_check.ServerResponse": {
+            "type": "object",
+            "properties": {
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "submissionId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_http_server_handlers_solve_translate_check.ClientRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "taskAlias"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code — программа на целевом языке",
                     "type": "string"
                 },
+                "taskAlias": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_solve_translate_check.ServerResponse": {
+            "type": "object",
+            "properties": {
                 "responseInfo": {
-                    "$ref": "#/definitions/response_info.ResponseInfo"
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
                 },
-                "score": {
+                "submissionId": {
                     "type": "integer"
                 }
             }
         },
-        "task_status.StatusResponse": {
+        "internal_http_server_handlers_task_hint.Request": {
             "type": "object",
             "properties": {
-                "error": {
+                "item": {
+                    "description": "Item — номер пропуска или правки (с 1); без него берётся первый, у которого остались подсказки",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "internal_http_server_handlers_task_hint.Response": {
+            "type": "object",
+            "properties": {
+                "hint": {
+                    "type": "string"
+                },
+                "item": {
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "levelName": {
                     "type": "string"
                 },
+                "maxScore": {
+                    "description": "MaxScore — наибольшая оценка следующих посылок после этой подсказки",
+                    "type": "integer"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_tutor_chat.AskRequest": {
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
                 "message": {
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "internal_http_server_handlers_tutor_chat.AskResponse": {
+            "type": "object",
+            "properties": {
+                "replyId": {
+                    "description": "ReplyID — сообщение наставника, которое появится в истории, когда модель ответит",
+                    "type": "integer"
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_tutor_chat.HistoryResponse": {
+            "type": "object",
+            "properties": {
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codular-backend_internal_storage_database.TutorMessage"
+                    }
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                }
+            }
+        },
+        "internal_http_server_handlers_two_factor.ConfirmRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_two_factor.DisableRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "password"
+            ],
+            "properties": {
+                "code": {
                     "type": "string"
                 },
-                "result": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_http_server_handlers_two_factor.Response": {
+            "type": "object",
+            "properties": {
+                "provisioningUri": {
                     "type": "string"
                 },
-                "status": {
+                "recoveryCodes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "responseInfo": {
+                    "$ref": "#/definitions/codular-backend_lib_api_response.ResponseInfo"
+                },
+                "secret": {
                     "type": "string"
                 }
             }
@@ -20,6 +20,22 @@ type Config struct {
 	StoragePath string     `yaml:"storage_path" env-required:"true"`
 	HTTPServer  HTTPServer `yaml:"http_server"`
 	AliasLength int        `yaml:"alias_length"`
+	JWT         JWT        `yaml:"jwt"`
+	// AdminEmails — пользователи, которым при старте выдаётся роль admin
+	AdminEmails     []string        `yaml:"admin_emails"`
+	LoginProtection LoginProtection `yaml:"login_protection"`
+	// PublicURL — адрес фронтенда, используется в ссылках из писем
+	PublicURL       string          `yaml:"public_url" env-default:"http://localhost:3000"`
+	Mail            Mail            `yaml:"mail"`
+	AccountDeletion AccountDeletion `yaml:"account_deletion"`
+	LLM             LLM             `yaml:"llm"`
+	LLMCache        LLMCache        `yaml:"llm_cache"`
+	LLMAudit        LLMAudit        `yaml:"llm_audit"`
+	LLMQuotas       LLMQuotas       `yaml:"llm_quotas"`
+	TaskProgress    TaskProgress    `yaml:"task_progress"`
+	Sandbox         Sandbox         `yaml:"sandbox"`
+	OutputTasks     OutputTasks     `yaml:"output_tasks"`
+	Tutor           Tutor           `yaml:"tutor"`
 }
 
 type HTTPServer struct {
@@ -28,6 +44,166 @@ type HTTPServer struct {
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
 }
 
+type JWT struct {
+	// Algorithm — алгоритм подписи новых токенов: RS256, EdDSA или HS256
+	Algorithm string `yaml:"algorithm" env-default:"RS256"`
+	// RotationInterval — как часто выпускается новый ключ подписи
+	RotationInterval time.Duration `yaml:"rotation_interval" env-default:"720h"`
+	// VerificationGracePeriod — сколько старый ключ остаётся в JWKS после ротации
+	VerificationGracePeriod time.Duration `yaml:"verification_grace_period" env-default:"48h"`
+	// AcceptHS256 разрешает проверку токенов, подписанных JWT_SECRET
+	AcceptHS256 bool `yaml:"accept_hs256" env-default:"true"`
+}
+
+type LoginProtection struct {
+	// MaxAttemptsPerAccount — число неудачных попыток для одного email до временной блокировки
+	MaxAttemptsPerAccount int `yaml:"max_attempts_per_account" env-default:"5"`
+	// MaxAttemptsPerIP — число неудачных попыток с одного IP до временной блокировки
+	MaxAttemptsPerIP int `yaml:"max_attempts_per_ip" env-default:"30"`
+	// AttemptWindow — за какой период считаются неудачные попытки
+	AttemptWindow time.Duration `yaml:"attempt_window" env-default:"1h"`
+	// BaseDelay и MaxDelay задают экспоненциальную задержку между попытками
+	BaseDelay time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay  time.Duration `yaml:"max_delay" env-default:"1m"`
+	// LockoutDuration — длительность блокировки после превышения лимита
+	LockoutDuration time.Duration `yaml:"lockout_duration" env-default:"15m"`
+	// TrustProxyHeaders включает определение IP по X-Forwarded-For/X-Real-IP
+	TrustProxyHeaders bool `yaml:"trust_proxy_headers" env-default:"false"`
+}
+
+type Mail struct {
+	// SMTPHost — если пусто, письма не отправляются, а пишутся в лог
+	SMTPHost string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort int    `yaml:"smtp_port" env:"SMTP_PORT" env-default:"587"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" env-default:"no-reply@codular.ru"`
+}
+
+type AccountDeletion struct {
+	// Policy: "cascade" удаляет задачи и посылки пользователя,
+	// "anonymize" сохраняет публичные задачи и посылки, обезличивая аккаунт
+	Policy string `yaml:"policy" env-default:"anonymize"`
+}
+
+type LLM struct {
+	// Models — модели OpenRouter в порядке приоритета; если список пуст, используется переменная окружения MODEL
+	Models []string `yaml:"models"`
+	// RequestTimeout — дедлайн одного запроса к модели
+	RequestTimeout time.Duration `yaml:"request_timeout" env-default:"60s"`
+	// MaxRetries — число повторов на одной модели при 429 и 5xx
+	MaxRetries int `yaml:"max_retries" env-default:"2"`
+	// RetryBaseDelay и MaxRetryDelay — экспоненциальная задержка между повторами, если сервер не прислал Retry-After
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay" env-default:"1s"`
+	MaxRetryDelay  time.Duration `yaml:"max_retry_delay" env-default:"30s"`
+	// BreakerThreshold — число подряд неудачных вызовов, после которого модель временно пропускается
+	BreakerThreshold int `yaml:"breaker_threshold" env-default:"3"`
+	// BreakerCooldown — сколько модель пропускается после срабатывания предохранителя
+	BreakerCooldown time.Duration `yaml:"breaker_cooldown" env-default:"2m"`
+	// Provider — источник ответов: openrouter или replay (ответы из журнала llm_calls, для воспроизведения ошибок)
+	Provider string `yaml:"provider" env:"LLM_PROVIDER" env-default:"openrouter"`
+	// StructuredOutput — запрашивать у провайдера ответ по JSON-схеме (response_format)
+	StructuredOutput bool `yaml:"structured_output" env-default:"true"`
+	// Streaming — получать ответ потоком, если вызывающий следит за прогрессом (генерация задач)
+	Streaming bool `yaml:"streaming" env-default:"true"`
+	// StreamIdleTimeout — сколько поток может молчать, прежде чем запрос считается зависшим и повторяется
+	StreamIdleTimeout time.Duration `yaml:"stream_idle_timeout" env-default:"20s"`
+	// ScrubSecrets — заменять ключи, токены, приватные ключи, IP и email в коде метками перед отправкой модели
+	ScrubSecrets bool `yaml:"scrub_secrets" env-default:"true"`
+	// InjectionPolicy — что делать с подозрением на prompt injection в комментариях и строках кода:
+	// refuse (отказать в генерации), sanitize (заменить текст меткой) или flag (только записать в отчёт)
+	InjectionPolicy string `yaml:"injection_policy" env-default:"sanitize"`
+}
+
+type TaskProgress struct {
+	// Interval — как часто прогресс генерации публикуется в статус задачи
+	Interval time.Duration `yaml:"interval" env-default:"1s"`
+	// StaleAfter — статус без обновлений дольше этого срока считается брошенным (например, после падения инстанса)
+	// и отменяется сразу, не дожидаясь обработчика
+	StaleAfter time.Duration `yaml:"stale_after" env-default:"1m"`
+}
+
+type Sandbox struct {
+	// Runner — где запускается код: docker (одноразовый контейнер без сети и с лимитами памяти и процессов)
+	// или local (процесс на этой машине; только для разработки — код пользователя не изолирован)
+	Runner string `yaml:"runner" env:"SANDBOX_RUNNER" env-default:"docker"`
+	// Timeout — сколько может работать программа; CompileTimeout — компиляция
+	Timeout        time.Duration `yaml:"timeout" env-default:"5s"`
+	CompileTimeout time.Duration `yaml:"compile_timeout" env-default:"30s"`
+	// MaxOutputBytes — сколько программа может вывести; больший вывод считается ошибкой
+	MaxOutputBytes int `yaml:"max_output_bytes" env-default:"65536"`
+	// MemoryMB и MaxProcesses — лимиты контейнера (runner docker)
+	MemoryMB     int `yaml:"memory_mb" env-default:"256"`
+	MaxProcesses int `yaml:"max_processes" env-default:"64"`
+	// MaxConcurrent — сколько программ собирается и запускается одновременно; остальные ждут очереди
+	MaxConcurrent int `yaml:"max_concurrent" env-default:"4"`
+	// WorkDir — где создаются каталоги с кодом (пусто — системный временный каталог). Если backend сам
+	// запущен в контейнере, каталог должен быть смонтирован по тому же пути, что и на хосте с docker
+	WorkDir string `yaml:"work_dir" env:"SANDBOX_WORK_DIR"`
+	// Languages — как собирать и запускать программы; ключ — имя языка из programming_languages.
+	// Если не задано, используются настройки по умолчанию для Python, C++ и Java
+	Languages map[string]SandboxLanguage `yaml:"languages"`
+}
+
+type SandboxLanguage struct {
+	// Image — образ для runner docker
+	Image string `yaml:"image"`
+	// File — имя файла с исходным кодом
+	File string `yaml:"file"`
+	// Compile — команда сборки (пусто для интерпретируемых языков); Run — команда запуска.
+	// В File, Compile и Run {class} заменяется именем публичного класса (Java), по умолчанию Main
+	Compile []string `yaml:"compile"`
+	Run     []string `yaml:"run"`
+}
+
+type OutputTasks struct {
+	// Normalization — как сравнивается вывод, если при создании задачи не указано иное:
+	// exact (только переводы строк), trailing (без пробелов в конце строк и пустых строк в конце),
+	// collapse (дополнительно любые последовательности пробелов считаются одним пробелом)
+	Normalization string `yaml:"normalization" env-default:"trailing"`
+	// MaxDiffLines — сколько строк различия показывается в подсказке
+	MaxDiffLines int `yaml:"max_diff_lines" env-default:"20"`
+}
+
+// Tutor — чат с наставником по задаче
+type Tutor struct {
+	// MaxMessages — сколько вопросов пользователь может задать за Window по всем задачам
+	MaxMessages int           `yaml:"max_messages" env-default:"20"`
+	Window      time.Duration `yaml:"window" env-default:"1h"`
+	// HistoryMessages — сколько последних сообщений беседы передаётся модели
+	HistoryMessages int `yaml:"history_messages" env-default:"20"`
+	// ReplyTimeout — сколько ждать ответа модели; ответ, не готовый дольше, считается неудавшимся
+	ReplyTimeout time.Duration `yaml:"reply_timeout" env-default:"2m"`
+}
+
+type LLMAudit struct {
+	// Enabled включает запись каждого обращения к LLM в журнал llm_calls
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// Retention — срок хранения записей журнала
+	Retention time.Duration `yaml:"retention" env-default:"720h"`
+	// MaxPayloadBytes — максимальный размер сохраняемых промптов и ответа; длиннее обрезаются
+	MaxPayloadBytes int `yaml:"max_payload_bytes" env-default:"65536"`
+}
+
+type LLMCache struct {
+	// Enabled включает кэширование ответов LLM в Redis
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// TTL — срок хранения закэшированного ответа
+	TTL time.Duration `yaml:"ttl" env-default:"168h"`
+}
+
+// LLMQuotas — лимиты использования LLM по ролям; 0 означает отсутствие лимита
+type LLMQuotas struct {
+	User  LLMQuota `yaml:"user"`
+	Admin LLMQuota `yaml:"admin"`
+}
+
+type LLMQuota struct {
+	DailyRequests int   `yaml:"daily_requests"`
+	DailyTokens   int64 `yaml:"daily_tokens"`
+	MonthlyTokens int64 `yaml:"monthly_tokens"`
+}
+
 type DBCredentials struct {
 	Postgres PostgresCredentials
 	Redis    RedisCredentials
@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"os"
+)
+
+// Ожидаемые вердикты проверок
+const (
+	VerdictOK    = "ok"
+	VerdictError = "error"
+	VerdictPass  = "pass"
+	VerdictFail  = "fail"
+)
+
+// Dataset — эталонный набор фрагментов кода и ответов
+type Dataset struct {
+	Cases []Case `yaml:"cases"`
+}
+
+// Case — фрагмент исходного кода; на нём проверяются генерация задач и, если заданы эталонные задачи, проверка решений
+type Case struct {
+	ID          string           `yaml:"id"`
+	Language    string           `yaml:"language"`
+	Code        string           `yaml:"code"`
+	SkipsNumber int              `yaml:"skips_number"`
+	NoiseLevel  int              `yaml:"noise_level"`
+	SkipsCheck  *SkipsCheckCase  `yaml:"skips_check"`
+	NoisesCheck *NoisesCheckCase `yaml:"noises_check"`
+}
+
+// SkipsCheckCase — эталонная задача с пропусками и решения с ожидаемым вердиктом (ok или error)
+type SkipsCheckCase struct {
+	SkipsCode   string            `yaml:"skips_code"`
+	Answers     []string          `yaml:"answers"`
+	Submissions []SkipsSubmission `yaml:"submissions"`
+}
+
+type SkipsSubmission struct {
+	Answers  []string `yaml:"answers"`
+	Expected string   `yaml:"expected"`
+}
+
+// NoisesCheckCase — эталонная задача с шумами и решения с ожидаемым вердиктом (pass или fail)
+type NoisesCheckCase struct {
+	NoisedCode  string             `yaml:"noised_code"`
+	Submissions []NoisesSubmission `yaml:"submissions"`
+}
+
+type NoisesSubmission struct {
+	Solution string `yaml:"solution"`
+	Expected string `yaml:"expected"`
+}
+
+// Load читает набор из YAML-файла и проверяет его
+func Load(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %v", err)
+	}
+	var dataset Dataset
+	if err := yaml.Unmarshal(data, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset: %v", err)
+	}
+	if err := dataset.validate(); err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+func (d *Dataset) validate() error {
+	if len(d.Cases) == 0 {
+		return fmt.Errorf("dataset has no cases")
+	}
+	seen := make(map[string]bool, len(d.Cases))
+	for _, c := range d.Cases {
+		if c.ID == "" || c.Code == "" || c.Language == "" {
+			return fmt.Errorf("case %q: id, language and code are required", c.ID)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("case %q is duplicated", c.ID)
+		}
+		seen[c.ID] = true
+
+		if c.SkipsNumber <= 0 || c.NoiseLevel <= 0 || c.NoiseLevel > 100 {
+			return fmt.Errorf("case %q: skips_number must be positive and noise_level in 1..100", c.ID)
+		}
+		if c.SkipsCheck != nil {
+			for i, s := range c.SkipsCheck.Submissions {
+				if len(s.Answers) != len(c.SkipsCheck.Answers) {
+					return fmt.Errorf("case %q: skips submission %d must have %d answers", c.ID, i+1, len(c.SkipsCheck.Answers))
+				}
+				if s.Expected != VerdictOK && s.Expected != VerdictError {
+					return fmt.Errorf("case %q: skips submission %d: expected must be ok or error", c.ID, i+1)
+				}
+			}
+		}
+		if c.NoisesCheck != nil {
+			for i, s := range c.NoisesCheck.Submissions {
+				if s.Expected != VerdictPass && s.Expected != VerdictFail {
+					return fmt.Errorf("case %q: noises submission %d: expected must be pass or fail", c.ID, i+1)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Summary — агрегированные результаты варианта
+type Summary struct {
+	Variant
+	Runs         int            `json:"runs"`
+	Errors       int            `json:"errors"`
+	Passed       map[string]int `json:"passed"`
+	AvgLatencyMs int64          `json:"avgLatencyMs"`
+	// AnsweredBy — сколько ответов дала каждая модель (при цепочке моделей)
+	AnsweredBy map[string]int `json:"answeredBy,omitempty"`
+}
+
+func Summarize(run Run) Summary {
+	summary := Summary{Variant: run.Variant, Passed: map[string]int{}, AnsweredBy: map[string]int{}}
+	var latency int64
+	for _, res := range run.Results {
+		summary.Runs++
+		latency += res.LatencyMs
+		if res.Error != "" {
+			summary.Errors++
+			continue
+		}
+		if res.Model != "" {
+			summary.AnsweredBy[res.Model]++
+		}
+		for metric, ok := range res.Checks {
+			if ok {
+				summary.Passed[metric]++
+			}
+		}
+	}
+	if summary.Runs > 0 {
+		summary.AvgLatencyMs = latency / int64(summary.Runs)
+	}
+	return summary
+}
+
+// WriteReport печатает сравнительную таблицу по сценариям. Ошибки считаются непройденными метриками
+func WriteReport(w io.Writer, summaries []Summary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, flow := range Flows {
+		var rows []Summary
+		for _, s := range summaries {
+			if s.Flow == flow {
+				rows = append(rows, s)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		metrics := FlowMetrics[flow]
+		fmt.Fprintf(tw, "== %s\n", flow)
+		fmt.Fprintf(tw, "PROMPT\tMODEL\tRUNS\tERRORS\t%s\tAVG LATENCY\tANSWERED BY\n", strings.ToUpper(strings.Join(metrics, "\t")))
+		for _, s := range rows {
+			cells := make([]string, 0, len(metrics))
+			for _, metric := range metrics {
+				cells = append(cells, rate(s.Passed[metric], s.Runs))
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%dms\t%s\n",
+				promptLabel(s.PromptVersion), modelLabel(s.Model), s.Runs, s.Errors, strings.Join(cells, "\t"), s.AvgLatencyMs, answeredBy(s.AnsweredBy))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func rate(passed, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%% (%d/%d)", float64(passed)*100/float64(total), passed, total)
+}
+
+func promptLabel(version int) string {
+	if version == 0 {
+		return "active"
+	}
+	return fmt.Sprintf("v%d", version)
+}
+
+func modelLabel(model string) string {
+	if model == "" {
+		return "configured chain"
+	}
+	return model
+}
+
+func answeredBy(models map[string]int) string {
+	parts := make([]string, 0, len(models))
+	for model, count := range models {
+		parts = append(parts, fmt.Sprintf("%s=%d", model, count))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,193 @@
+package eval
+
+import (
+	"codular-backend/internal/http_server/handlers/generate/noises"
+	"codular-backend/internal/http_server/handlers/generate/skips"
+	"codular-backend/internal/http_server/handlers/solve/noises_check"
+	"codular-backend/internal/http_server/handlers/solve/skips_check"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxDescriptionLength — ограничение длины описания из промптов генерации
+const MaxDescriptionLength = 45
+
+// Метрики
+const (
+	MetricAnswerCount    = "answer_count"
+	MetricReconstruction = "reconstruction"
+	MetricCodeChanged    = "code_changed"
+	MetricDescription    = "description"
+	MetricVerdict        = "verdict"
+)
+
+// Flows — сценарии в порядке отчёта; совпадают с именами промптов
+var Flows = []string{prompts.SkipsGenerate, prompts.NoisesGenerate, prompts.SkipsCheck, prompts.NoisesCheck}
+
+// FlowMetrics — метрики каждого сценария
+var FlowMetrics = map[string][]string{
+	prompts.SkipsGenerate:  {MetricAnswerCount, MetricReconstruction, MetricDescription},
+	prompts.NoisesGenerate: {MetricCodeChanged, MetricDescription},
+	prompts.SkipsCheck:     {MetricVerdict},
+	prompts.NoisesCheck:    {MetricVerdict},
+}
+
+// Variant — сочетание сценария, версии промпта (0 — активная) и модели (пусто — настроенная цепочка)
+type Variant struct {
+	Flow          string `json:"flow"`
+	PromptVersion int    `json:"promptVersion"`
+	Model         string `json:"model,omitempty"`
+}
+
+// CaseResult — результат одного прогона; для проверок — одного решения из набора
+type CaseResult struct {
+	CaseID    string          `json:"caseId"`
+	Checks    map[string]bool `json:"checks,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Model     string          `json:"model,omitempty"`
+	LatencyMs int64           `json:"latencyMs"`
+}
+
+type Run struct {
+	Variant
+	Results []CaseResult `json:"results"`
+}
+
+type Runner struct {
+	log *slog.Logger
+}
+
+func NewRunner(log *slog.Logger) *Runner {
+	return &Runner{log: log}
+}
+
+// Run прогоняет весь набор через сценарий варианта. Запросы идут последовательно, кэш не читается
+func (r *Runner) Run(ctx context.Context, dataset *Dataset, variant Variant) Run {
+	run := Run{Variant: variant, Results: []CaseResult{}}
+	for _, c := range dataset.Cases {
+		if ctx.Err() != nil {
+			break
+		}
+		log := r.log.With(slog.String("flow", variant.Flow), slog.String("case", c.ID))
+		opts := llm.Options{
+			NoCache:       true,
+			Endpoint:      llm.EndpointEval,
+			TaskAlias:     "eval:" + c.ID,
+			PromptVersion: variant.PromptVersion,
+		}
+		if variant.Model != "" {
+			opts.Models = []string{variant.Model}
+		}
+
+		switch variant.Flow {
+		case prompts.SkipsGenerate:
+			run.Results = append(run.Results, r.skipsGenerate(ctx, c, opts, log))
+		case prompts.NoisesGenerate:
+			run.Results = append(run.Results, r.noisesGenerate(ctx, c, opts, log))
+		case prompts.SkipsCheck:
+			run.Results = append(run.Results, r.skipsCheck(ctx, c, opts, log)...)
+		case prompts.NoisesCheck:
+			run.Results = append(run.Results, r.noisesCheck(ctx, c, opts, log)...)
+		}
+	}
+	return run
+}
+
+func (r *Runner) skipsGenerate(ctx context.Context, c Case, opts llm.Options, log *slog.Logger) CaseResult {
+	started := time.Now()
+	result, err := skips.ProcessCode(ctx, c.Code, c.SkipsNumber, c.Language, prompts.DefaultLocale, opts, log)
+	res := CaseResult{CaseID: c.ID, LatencyMs: time.Since(started).Milliseconds()}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	placeholders := strings.Count(result.Code, skips.Placeholder)
+	res.Model = result.Model
+	res.Checks = map[string]bool{
+		MetricAnswerCount:    placeholders == c.SkipsNumber && len(result.Answers) == c.SkipsNumber,
+		MetricReconstruction: Reconstruct(result.Code, result.Answers) == llm.NormalizeCode(c.Code),
+		MetricDescription:    descriptionOK(result.Description),
+	}
+	return res
+}
+
+func (r *Runner) noisesGenerate(ctx context.Context, c Case, opts llm.Options, log *slog.Logger) CaseResult {
+	started := time.Now()
+	result, err := noises.ProcessCode(ctx, c.Code, c.NoiseLevel, c.Language, prompts.DefaultLocale, opts, log)
+	res := CaseResult{CaseID: c.ID, LatencyMs: time.Since(started).Milliseconds()}
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Model = result.Model
+	res.Checks = map[string]bool{
+		MetricCodeChanged: strings.TrimSpace(result.Code) != "" && llm.NormalizeCode(result.Code) != llm.NormalizeCode(c.Code),
+		MetricDescription: descriptionOK(result.Description),
+	}
+	return res
+}
+
+func (r *Runner) skipsCheck(ctx context.Context, c Case, opts llm.Options, log *slog.Logger) []CaseResult {
+	if c.SkipsCheck == nil {
+		return nil
+	}
+	var results []CaseResult
+	for i, submission := range c.SkipsCheck.Submissions {
+		started := time.Now()
+		response, err := skips_check.ProcessSubmission(ctx, opts.TaskAlias, c.SkipsCheck.Answers, submission.Answers, c.SkipsCheck.SkipsCode, opts, log)
+		res := CaseResult{CaseID: fmt.Sprintf("%s#%d", c.ID, i+1), LatencyMs: time.Since(started).Milliseconds()}
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Checks = map[string]bool{MetricVerdict: response.Status == submission.Expected}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func (r *Runner) noisesCheck(ctx context.Context, c Case, opts llm.Options, log *slog.Logger) []CaseResult {
+	if c.NoisesCheck == nil {
+		return nil
+	}
+	var results []CaseResult
+	for i, submission := range c.NoisesCheck.Submissions {
+		started := time.Now()
+		response, err := noises_check.ProcessSubmission(ctx, opts.TaskAlias, c.Code, c.NoisesCheck.NoisedCode, submission.Solution, opts, log)
+		res := CaseResult{CaseID: fmt.Sprintf("%s#%d", c.ID, i+1), LatencyMs: time.Since(started).Milliseconds()}
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			// Как и в обработчике, решение засчитывается при оценке 100
+			verdict := VerdictFail
+			if response.Score >= 100 {
+				verdict = VerdictPass
+			}
+			res.Checks = map[string]bool{MetricVerdict: verdict == submission.Expected}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// Reconstruct подставляет ответы в пропуски по порядку и возвращает код в каноническом виде
+func Reconstruct(skipsCode string, answers []string) string {
+	code := skipsCode
+	for _, answer := range answers {
+		code = strings.Replace(code, skips.Placeholder, answer, 1)
+	}
+	return llm.NormalizeCode(code)
+}
+
+func descriptionOK(description string) bool {
+	description = strings.TrimSpace(description)
+	return description != "" && utf8.RuneCountInString(description) <= MaxDescriptionLength
+}
@@ -0,0 +1,154 @@
+package experiments
+
+import (
+	"codular-backend/internal/llm"
+	"codular-backend/internal/storage/database"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// cacheTTL — как долго активные эксперименты берутся из памяти без обращения к базе
+// (создание и остановка через админку сбрасывают кэш сразу, TTL нужен для остальных инстансов)
+const cacheTTL = time.Minute
+
+type Storage interface {
+	ListExperiments(activeOnly bool) ([]database.Experiment, error)
+	RecordExperimentGeneration(variantID, userID int64, alias string, succeeded bool) error
+	SetSubmissionExperimentVariant(submissionID, variantID int64) error
+}
+
+// Manager хранит активные эксперименты в памяти и распределяет по ним пользователей
+type Manager struct {
+	mu       sync.RWMutex
+	storage  Storage
+	active   map[string]database.Experiment
+	loadedAt time.Time
+}
+
+// Default используется обработчиками; инициализируется в main через Init
+var Default *Manager
+
+// Init создаёт Default и загружает активные эксперименты
+func Init(storage Storage) error {
+	m := &Manager{storage: storage}
+	if err := m.load(); err != nil {
+		return err
+	}
+	Default = m
+	return nil
+}
+
+// Assign возвращает вариант активного эксперимента сценария flow для пользователя.
+// Назначение детерминировано (хэш эксперимента и пользователя), поэтому пользователь
+// остаётся в одном варианте до конца эксперимента. Анонимные запросы в экспериментах не участвуют
+func (m *Manager) Assign(flow string, userID int64) (database.ExperimentVariant, bool, error) {
+	if userID == 0 {
+		return database.ExperimentVariant{}, false, nil
+	}
+
+	m.mu.RLock()
+	stale := time.Since(m.loadedAt) > cacheTTL
+	m.mu.RUnlock()
+	if stale {
+		if err := m.load(); err != nil {
+			return database.ExperimentVariant{}, false, err
+		}
+	}
+
+	m.mu.RLock()
+	experiment, ok := m.active[flow]
+	m.mu.RUnlock()
+	if !ok || len(experiment.Variants) == 0 {
+		return database.ExperimentVariant{}, false, nil
+	}
+	return pick(experiment, userID), true, nil
+}
+
+// Apply назначает вариант и переносит его версию промпта и модель в opts.
+// Возвращает ID варианта или 0, если пользователь не участвует в эксперименте.
+// Ошибки не прерывают запрос: он выполняется с настройками по умолчанию
+func Apply(flow string, userID int64, opts *llm.Options, log *slog.Logger) int64 {
+	if Default == nil {
+		return 0
+	}
+	variant, ok, err := Default.Assign(flow, userID)
+	if err != nil {
+		log.Error("failed to assign experiment variant", slog.String("flow", flow), slog.String("error", err.Error()))
+		return 0
+	}
+	if !ok {
+		return 0
+	}
+	opts.PromptVersion = variant.PromptVersion
+	if variant.Model != "" {
+		opts.Models = []string{variant.Model}
+	}
+	log.Debug("experiment variant assigned", slog.String("flow", flow), slog.Int64("variant_id", variant.ID))
+	return variant.ID
+}
+
+// RecordGeneration учитывает исход генерации задачи вариантом
+func RecordGeneration(variantID, userID int64, alias string, succeeded bool, log *slog.Logger) {
+	if Default == nil || variantID == 0 {
+		return
+	}
+	if err := Default.storage.RecordExperimentGeneration(variantID, userID, alias, succeeded); err != nil {
+		log.Error("failed to record experiment generation", slog.String("error", err.Error()))
+	}
+}
+
+// RecordSubmission записывает вариант, которым оценивается посылка
+func RecordSubmission(variantID, submissionID int64, log *slog.Logger) {
+	if Default == nil || variantID == 0 {
+		return
+	}
+	if err := Default.storage.SetSubmissionExperimentVariant(submissionID, variantID); err != nil {
+		log.Error("failed to record submission experiment variant", slog.String("error", err.Error()))
+	}
+}
+
+// Invalidate сбрасывает кэш; следующее назначение перечитает активные эксперименты из базы
+func (m *Manager) Invalidate() {
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+func (m *Manager) load() error {
+	experiments, err := m.storage.ListExperiments(true)
+	if err != nil {
+		return fmt.Errorf("failed to load experiments: %v", err)
+	}
+
+	active := make(map[string]database.Experiment, len(experiments))
+	for _, e := range experiments {
+		active[e.Flow] = e
+	}
+
+	m.mu.Lock()
+	m.active = active
+	m.loadedAt = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// pick выбирает вариант пропорционально весам по хэшу пары (эксперимент, пользователь)
+func pick(experiment database.Experiment, userID int64) database.ExperimentVariant {
+	total := 0
+	for _, v := range experiment.Variants {
+		total += v.Weight
+	}
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%d", experiment.ID, userID)
+	point := int(h.Sum32() % uint32(total))
+	for _, v := range experiment.Variants {
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	return experiment.Variants[len(experiment.Variants)-1]
+}
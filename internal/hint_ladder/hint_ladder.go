@@ -0,0 +1,144 @@
+// Package hint_ladder генерирует лестницы подсказок при создании задачи и учитывает
+// открытые подсказки при проверке посылок.
+package hint_ladder
+
+import (
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/storage/database"
+	"codular-backend/lib/hintladder"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/noises"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+type LLMResponse struct {
+	Hints []LLMHint `json:"hints"`
+}
+
+type LLMHint struct {
+	Item int `json:"item"`
+	hintladder.Ladder
+}
+
+// responseSchema — схема ответа LLM при генерации подсказок
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"hints": llmjson.Array(llmjson.Object(map[string]*llmjson.Schema{
+		"item":       llmjson.Integer(1, 1000),
+		"nudge":      llmjson.String(),
+		"pointer":    llmjson.String(),
+		"nearAnswer": llmjson.String(),
+	})),
+})
+
+// ForSkips генерирует лестницы к пропускам задачи. Подсказки, которые модель не вернула,
+// и все подсказки при ошибке модели строятся без неё
+func ForSkips(ctx context.Context, taskCode string, answers []string, placeholder, locale string, opts llm.Options, logger *slog.Logger) []hintladder.Ladder {
+	items := make([]string, len(answers))
+	for i, answer := range answers {
+		items[i] = "answer: " + answer
+	}
+	fallback := hintladder.ForSkips(taskCode, answers, placeholder)
+	return generate(ctx, taskCode, items, fallback, locale, opts, logger)
+}
+
+// ForNoises генерирует лестницы к правкам задачи с шумами; noisedCode — код задачи
+func ForNoises(ctx context.Context, noisedCode string, edits []noises.Edit, locale string, opts llm.Options, logger *slog.Logger) []hintladder.Ladder {
+	items := make([]string, len(edits))
+	for i, edit := range edits {
+		kind := "change"
+		if edit.Kind == noises.KindNoise {
+			kind = "noise"
+		}
+		items[i] = fmt.Sprintf("%s, %s of the task code\noriginal:\n%s\ntask code:\n%s",
+			kind, noises.LineRange(edit.NoisedLine, edit.NoisedCount), strings.Join(edit.Original, "\n"), strings.Join(edit.Noised, "\n"))
+	}
+	fallback := hintladder.ForNoises(edits)
+	return generate(ctx, noisedCode, items, fallback, locale, opts, logger)
+}
+
+func generate(ctx context.Context, taskCode string, items []string, fallback []hintladder.Ladder, locale string, opts llm.Options, logger *slog.Logger) []hintladder.Ladder {
+	if len(items) == 0 {
+		return nil
+	}
+	// Версия промпта и модели эксперимента относятся к генерации задачи, а не к подсказкам;
+	// прогресс задачи показывает генерацию её кода, поэтому подсказки запрашиваются без него
+	opts.PromptVersion, opts.Models, opts.OnProgress = 0, nil, nil
+
+	var document strings.Builder
+	document.WriteString(taskCode)
+	document.WriteString("\n=== items ===\n")
+	for i, item := range items {
+		fmt.Fprintf(&document, "#%d %s\n", i+1, item)
+	}
+
+	// Ответы и строки правок взяты из кода пользователя: секреты скрываются и в них
+	scrubbed, err := llm.ScrubCode(document.String())
+	if err != nil {
+		logger.Warn("hints generated without llm: code refused", sl.Err(err))
+		return fallback
+	}
+
+	prompt, err := prompts.RenderAt(prompts.HintsGenerate, opts.PromptVersion, prompts.Vars{
+		"Code":   scrubbed.Code,
+		"Locale": locale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return fallback
+	}
+
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.3,
+		Kind:            llm.KindHintsGenerate,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), llm.NormalizeCode(scrubbed.Code), locale},
+		Schema:          responseSchema,
+	}, opts)
+	if err != nil {
+		logger.Warn("hints generated without llm: request failed", sl.Err(err))
+		return fallback
+	}
+
+	var decodedLLMResponse LLMResponse
+	if err := json.Unmarshal([]byte(response.Content), &decodedLLMResponse); err != nil {
+		logger.Warn("hints generated without llm: invalid response", sl.Err(err))
+		return fallback
+	}
+
+	ladders := make([]hintladder.Ladder, len(items))
+	for _, hint := range decodedLLMResponse.Hints {
+		if hint.Item < 1 || hint.Item > len(items) {
+			continue
+		}
+		ladders[hint.Item-1] = hintladder.Ladder{
+			Nudge:      scrubbed.Restore(hint.Nudge),
+			Pointer:    scrubbed.Restore(hint.Pointer),
+			NearAnswer: scrubbed.Restore(hint.NearAnswer),
+		}
+	}
+	logger.Info("hint ladders generated", slog.Int("items", len(items)), slog.Int("llm_hints", len(decodedLLMResponse.Hints)), slog.String("model", response.Model))
+	return hintladder.Fill(ladders, fallback)
+}
+
+// Penalize ограничивает оценку посылки с учётом подсказок, открытых автором до её отправки.
+// Если узнать открытые подсказки не удалось, оценка не меняется
+func Penalize(storage *database.Storage, submissionID int64, score int, logger *slog.Logger) int {
+	maxScore, err := storage.GetSubmissionMaxScore(submissionID)
+	if err != nil {
+		logger.Error("failed to get submission max score", sl.Err(err))
+		return score
+	}
+	if maxScore < 100 {
+		logger.Info("submission score limited by hints", slog.Int("score", score), slog.Int("max_score", maxScore))
+	}
+	return hintladder.Apply(score, maxScore)
+}
@@ -0,0 +1,392 @@
+package account
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/http_server/handlers/two_factor"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/login_guard"
+	"codular-backend/internal/mailer"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	PolicyCascade   = "cascade"
+	PolicyAnonymize = "anonymize"
+
+	emailChangeTTL = 24 * time.Hour
+)
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type DeleteRequest struct {
+	Password string `json:"password" validate:"required"`
+	// Code — TOTP-код или код восстановления, обязателен при включённой 2FA
+	Code string `json:"code,omitempty"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Email        string                     `json:"email,omitempty"`
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{ResponseInfo: response_info.Error(msg)}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *Response {
+	return &Response{ResponseInfo: response_info.ValidationError(validationErrors)}
+}
+
+// RequestEmailChange отправляет ссылку подтверждения на новый email
+// @Summary Request email change
+// @Description Re-checks the current password and sends a confirmation link to the new address. The email is changed only after the link is confirmed (valid for 24 hours).
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body ChangeEmailRequest true "New email and current password"
+// @Success 200 {object} Response "Confirmation email sent"
+// @Failure 400 {object} Response "Invalid request or empty body"
+// @Failure 401 {object} Response "Unauthorized or invalid password"
+// @Failure 409 {object} Response "Email already exists"
+// @Failure 429 {object} Response "Too many failed attempts; see Retry-After"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /user/email [post]
+func RequestEmailChange(logger *slog.Logger, storage *database.Storage, mail *mailer.Mailer, cfg *config.Config, guard *login_guard.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.account.RequestEmailChange"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var req ChangeEmailRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		if !checkPassword(w, r, log, storage, guard, userID, req.Password) {
+			return
+		}
+		two_factor.ResetAttempts(log, guard, userID)
+
+		if _, _, err := storage.GetUserByEmail(req.NewEmail); err == nil {
+			log.Error("email already exists")
+			w.WriteHeader(http.StatusConflict)
+			render.JSON(w, r, getErrorResponse("email already exists"))
+			return
+		}
+
+		token, err := generateToken()
+		if err != nil {
+			log.Error("failed to generate email change token", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if err := storage.CreateEmailChangeRequest(userID, req.NewEmail, hashToken(token), time.Now().UTC().Add(emailChangeTTL)); err != nil {
+			log.Error("failed to save email change request", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		link := strings.TrimRight(cfg.PublicURL, "/") + "/confirm-email?token=" + url.QueryEscape(token)
+		body := fmt.Sprintf("To confirm your new Codular email address, open the link below:\n\n%s\n\nThe link is valid for 24 hours. If you did not request this change, ignore this email.", link)
+		if err := mail.Send(req.NewEmail, "Confirm your new email", body); err != nil {
+			log.Error("failed to send confirmation email", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("failed to send confirmation email"))
+			return
+		}
+
+		log.Info("email change requested", slog.Int64("user_id", userID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK()})
+	}
+}
+
+// ConfirmEmailChange применяет смену email по токену из письма
+// @Summary Confirm email change
+// @Description Applies a pending email change using the token from the confirmation link.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body ConfirmEmailRequest true "Token from the confirmation link"
+// @Success 200 {object} Response "Email changed"
+// @Failure 400 {object} Response "Invalid or expired token"
+// @Failure 409 {object} Response "Email already exists"
+// @Failure 500 {object} Response "Internal server error"
+// @Router /auth/email/confirm [post]
+func ConfirmEmailChange(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.account.ConfirmEmailChange"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		var req ConfirmEmailRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		userID, email, err := storage.ConfirmEmailChange(hashToken(req.Token))
+		if err != nil {
+			log.Error("failed to confirm email change", sl.Err(err))
+			switch err.Error() {
+			case "email already exists":
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, getErrorResponse("email already exists"))
+			case "email change request not found", "email change request expired":
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, getErrorResponse("invalid or expired token"))
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, getErrorResponse("internal server error"))
+			}
+			return
+		}
+
+		log.Info("email changed", slog.Int64("user_id", userID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK(), Email: email})
+	}
+}
+
+// ExportData выгружает все данные пользователя одним JSON-файлом
+// @Summary Export account data
+// @Description Downloads a JSON archive with the user profile, created tasks, submissions, revealed hints, tutor chat messages and personal access token metadata (token values are never stored).
+// @Tags User
+// @Produce json
+// @Success 200 {object} database.UserExport "Account data"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /user/export [get]
+func ExportData(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.account.ExportData"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		export, err := storage.ExportUserData(userID)
+		if err != nil {
+			log.Error("failed to export user data", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			log.Error("failed to encode user data", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		filename := fmt.Sprintf("codular-export-%d-%s.json", userID, export.ExportedAt.Format("20060102"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(data); err != nil {
+			log.Error("failed to write export", sl.Err(err))
+			return
+		}
+		log.Info("user data exported", slog.Int64("user_id", userID))
+	}
+}
+
+// DeleteAccount удаляет аккаунт согласно настроенной политике
+// @Summary Delete account
+// @Description Deletes the account after re-authentication (password, plus TOTP or recovery code when two-factor authentication is enabled). Depending on server policy, tasks and submissions are either deleted ("cascade") or public tasks and submissions are kept detached from the account ("anonymize").
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body DeleteRequest true "Current password and optional second factor code"
+// @Success 200 {object} Response "Account deleted"
+// @Failure 400 {object} Response "Invalid request or empty body"
+// @Failure 401 {object} Response "Unauthorized or invalid credentials"
+// @Failure 429 {object} Response "Too many failed attempts; see Retry-After"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /user [delete]
+func DeleteAccount(logger *slog.Logger, storage *database.Storage, cfg *config.Config, guard *login_guard.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.account.DeleteAccount"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var req DeleteRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		if !checkPassword(w, r, log, storage, guard, userID, req.Password) {
+			return
+		}
+
+		state, err := storage.GetTwoFactorState(userID)
+		if err != nil {
+			log.Error("failed to get two-factor state", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		if state.Enabled {
+			valid, err := two_factor.VerifyCode(storage, userID, state.Secret, req.Code)
+			if err != nil {
+				log.Error("failed to verify second factor", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, getErrorResponse("internal server error"))
+				return
+			}
+			if !valid {
+				log.Error("invalid second factor code")
+				two_factor.RegisterFailure(r, log, guard, userID)
+				w.WriteHeader(http.StatusUnauthorized)
+				render.JSON(w, r, getErrorResponse("invalid credentials"))
+				return
+			}
+		}
+
+		if cfg.AccountDeletion.Policy == PolicyCascade {
+			err = storage.DeleteUserCascade(userID)
+		} else {
+			err = storage.AnonymizeUser(userID)
+		}
+		if err != nil {
+			log.Error("failed to delete account", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		log.Info("account deleted", slog.Int64("user_id", userID), slog.String("policy", cfg.AccountDeletion.Policy))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK()})
+	}
+}
+
+// checkPassword сверяет пароль пользователя с учётом ограничения попыток, при ошибке отправляет ответ клиенту
+func checkPassword(w http.ResponseWriter, r *http.Request, log *slog.Logger, storage *database.Storage, guard *login_guard.Guard, userID int64, password string) bool {
+	if !two_factor.CheckAttempts(w, r, log, guard, userID) {
+		return false
+	}
+	passwordHash, err := storage.GetPasswordHashByID(userID)
+	if err != nil {
+		log.Error("failed to get password hash", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, getErrorResponse("internal server error"))
+		return false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		log.Error("invalid password", slog.Int64("user_id", userID))
+		two_factor.RegisterFailure(r, log, guard, userID)
+		w.WriteHeader(http.StatusUnauthorized)
+		render.JSON(w, r, getErrorResponse("invalid credentials"))
+		return false
+	}
+	return true
+}
+
+// generateToken создаёт случайный токен для ссылки подтверждения
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken — в базе хранится только хэш токена подтверждения
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// decodeRequest декодирует и валидирует тело запроса, при ошибке отправляет ответ клиенту
+func decodeRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("empty request"))
+			return false
+		}
+		log.Error("failed to decode request body", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, getErrorResponse("invalid request body"))
+		return false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			log.Error("invalid request", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getValidationErrorResponse(validationErrs))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,329 @@
+package experiments
+
+import (
+	"codular-backend/internal/experiments"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type VariantRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+	// PromptVersion — номер версии промпта сценария (0 — активная)
+	PromptVersion int `json:"promptVersion" validate:"gte=0"`
+	// Model — модель вместо настроенной цепочки
+	Model  string `json:"model,omitempty" validate:"max=200"`
+	Weight int    `json:"weight,omitempty" validate:"gte=0,lte=1000"`
+}
+
+type CreateRequest struct {
+	Name        string           `json:"name" validate:"required,max=100"`
+	Flow        string           `json:"flow" validate:"required"`
+	Description string           `json:"description,omitempty" validate:"max=1000"`
+	Variants    []VariantRequest `json:"variants" validate:"required,min=2,max=10,dive"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Experiment   *database.Experiment       `json:"experiment,omitempty"`
+}
+
+type ListResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Experiments  []database.Experiment      `json:"experiments"`
+}
+
+type ReportResponse struct {
+	ResponseInfo response_info.ResponseInfo          `json:"responseInfo"`
+	Experiment   *database.Experiment                `json:"experiment,omitempty"`
+	Variants     []database.ExperimentVariantMetrics `json:"variants,omitempty"`
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{ResponseInfo: response_info.Error(msg)}
+}
+
+// List возвращает все эксперименты
+// @Summary List experiments
+// @Description Returns all A/B experiments with their variants, newest first. Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} ListResponse "Experiments"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/experiments [get]
+func List(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.experiments.List"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		list, err := storage.ListExperiments(false)
+		if err != nil {
+			log.Error("failed to list experiments", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ListResponse{ResponseInfo: response_info.OK(), Experiments: list})
+	}
+}
+
+// Create запускает эксперимент; пользователи распределяются по вариантам пропорционально весам
+// @Summary Create experiment
+// @Description Starts an A/B experiment for one flow (skips_generate, noises_generate, skips_check, noises_check, bugs_generate, bugs_check, quiz_generate, translate_check, explain_generate, explain_check, skips_describe). Each variant pins a prompt version (0 is the active one) and optionally a model; users are assigned to variants by weight and stay in their variant until the experiment is stopped. Only one experiment per flow can be active. Requires admin role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body CreateRequest true "Experiment and its variants"
+// @Success 200 {object} Response "Experiment started"
+// @Failure 400 {object} Response "Invalid request, flow or prompt version"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 409 {object} Response "Flow already has an active experiment or the name is taken"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/experiments [post]
+func Create(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.experiments.Create"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		var req CreateRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		if !prompts.Known(req.Flow) {
+			log.Error("unknown flow", slog.String("flow", req.Flow))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("unknown flow: "+req.Flow))
+			return
+		}
+
+		experiment := database.Experiment{Name: req.Name, Flow: req.Flow, Description: req.Description}
+		names := make(map[string]bool, len(req.Variants))
+		for _, v := range req.Variants {
+			if names[v.Name] {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, getErrorResponse("duplicate variant name: "+v.Name))
+				return
+			}
+			names[v.Name] = true
+
+			if v.PromptVersion > 0 {
+				if _, err := storage.GetPromptVersion(req.Flow, v.PromptVersion); err != nil {
+					log.Error("failed to get prompt version", sl.Err(err))
+					w.WriteHeader(http.StatusBadRequest)
+					render.JSON(w, r, getErrorResponse(fmt.Sprintf("prompt version %d not found", v.PromptVersion)))
+					return
+				}
+			}
+
+			weight := v.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			experiment.Variants = append(experiment.Variants, database.ExperimentVariant{
+				Name:          v.Name,
+				PromptVersion: v.PromptVersion,
+				Model:         v.Model,
+				Weight:        weight,
+			})
+		}
+
+		adminID, _ := r.Context().Value(my_middleware.UserIDKey).(int64)
+		experiment, err := storage.CreateExperiment(experiment, adminID)
+		if err != nil {
+			log.Error("failed to create experiment", sl.Err(err))
+			switch err.Error() {
+			case "active experiment already exists":
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, getErrorResponse("flow already has an active experiment"))
+			case "experiment already exists":
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, getErrorResponse("experiment name is taken"))
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, getErrorResponse("internal server error"))
+			}
+			return
+		}
+
+		if experiments.Default != nil {
+			experiments.Default.Invalidate()
+		}
+
+		log.Info("experiment started", slog.Int64("experiment_id", experiment.ID), slog.String("flow", experiment.Flow), slog.Int64("admin_id", adminID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK(), Experiment: &experiment})
+	}
+}
+
+// Stop завершает эксперимент; новые запросы снова идут с настройками по умолчанию
+// @Summary Stop experiment
+// @Description Stops an active experiment. Variants recorded on tasks and submissions are kept, so the report stays available. Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Experiment ID"
+// @Success 200 {object} Response "Experiment stopped"
+// @Failure 400 {object} Response "Invalid ID"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 404 {object} Response "Active experiment not found"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/experiments/{id}/stop [post]
+func Stop(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.experiments.Stop"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := experimentID(w, r, log)
+		if !ok {
+			return
+		}
+
+		if err := storage.StopExperiment(id); err != nil {
+			log.Error("failed to stop experiment", sl.Err(err))
+			if err.Error() == "experiment not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, getErrorResponse("active experiment not found"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if experiments.Default != nil {
+			experiments.Default.Invalidate()
+		}
+
+		adminID, _ := r.Context().Value(my_middleware.UserIDKey).(int64)
+		log.Info("experiment stopped", slog.Int64("experiment_id", id), slog.Int64("admin_id", adminID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK()})
+	}
+}
+
+// Report возвращает показатели каждого варианта эксперимента
+// @Summary Experiment report
+// @Description Returns outcome metrics per variant: generation attempts and failures (generation flows), submissions and grading failures, solve rate over (task, user) pairs, average attempts per pair, and hint reports from users. Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "Experiment ID"
+// @Success 200 {object} ReportResponse "Per-variant metrics"
+// @Failure 400 {object} Response "Invalid ID"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 404 {object} Response "Experiment not found"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/experiments/{id}/report [get]
+func Report(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.experiments.Report"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		id, ok := experimentID(w, r, log)
+		if !ok {
+			return
+		}
+
+		experiment, err := storage.GetExperiment(id)
+		if err != nil {
+			log.Error("failed to get experiment", sl.Err(err))
+			if err.Error() == "experiment not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, getErrorResponse("experiment not found"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		generation := experiment.Flow == prompts.SkipsGenerate || experiment.Flow == prompts.NoisesGenerate || experiment.Flow == prompts.BugsGenerate || experiment.Flow == prompts.QuizGenerate || experiment.Flow == prompts.ExplainGenerate || experiment.Flow == prompts.SkipsDescribe
+		metrics, err := storage.GetExperimentMetrics(experiment, generation)
+		if err != nil {
+			log.Error("failed to get experiment metrics", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ReportResponse{ResponseInfo: response_info.OK(), Experiment: &experiment, Variants: metrics})
+	}
+}
+
+// experimentID извлекает ID эксперимента из URL
+func experimentID(w http.ResponseWriter, r *http.Request, log *slog.Logger) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		log.Error("invalid experiment id", slog.String("id", chi.URLParam(r, "id")))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, getErrorResponse("invalid experiment id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeRequest декодирует и валидирует тело запроса, при ошибке отправляет ответ клиенту
+func decodeRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("empty request"))
+			return false
+		}
+		log.Error("failed to decode request body", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, getErrorResponse("invalid request body"))
+		return false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			log.Error("invalid request", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, &Response{ResponseInfo: response_info.ValidationError(validationErrs)})
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package llm_cache_stats
+
+import (
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type KindStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hitRate"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Stats        map[string]KindStats       `json:"stats,omitempty"`
+}
+
+// New возвращает статистику попаданий в кэш ответов LLM
+// @Summary LLM cache statistics
+// @Description Returns LLM response cache hits, misses and hit rate per request kind (skips_generate, noises_generate, skips_check, noises_check). Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} Response "Cache statistics"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/llm/cache-stats [get]
+func New(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.llm_cache_stats.New"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		stats, err := storage.GetLLMCacheStats()
+		if err != nil {
+			log.Error("failed to get llm cache stats", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, Response{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		result := make(map[string]KindStats, len(stats))
+		for kind, s := range stats {
+			entry := KindStats{Hits: s.Hits, Misses: s.Misses}
+			if total := s.Hits + s.Misses; total > 0 {
+				entry.HitRate = float64(s.Hits) / float64(total)
+			}
+			result[kind] = entry
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK(), Stats: result})
+	}
+}
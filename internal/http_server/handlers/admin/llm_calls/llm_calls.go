@@ -0,0 +1,164 @@
+package llm_calls
+
+import (
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+type ListResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Calls        []database.LLMCall         `json:"calls,omitempty"`
+	Total        int                        `json:"total"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Call         *database.LLMCall          `json:"call,omitempty"`
+}
+
+// List возвращает журнал обращений к LLM
+// @Summary List LLM calls
+// @Description Returns the LLM call journal, newest first, without prompt and response bodies. Every exchange is recorded with its task alias, submission id, prompt version, model, latency and token usage; cache hits are recorded with status "cached". Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Param userId query int false "Filter by user ID"
+// @Param taskAlias query string false "Filter by task alias"
+// @Param submissionId query int false "Filter by submission ID"
+// @Param status query string false "Filter by status (ok, invalid, error, cached)"
+// @Param offset query int false "Offset (default 0)"
+// @Param limit query int false "Limit (default 50, max 200)"
+// @Success 200 {object} ListResponse "LLM calls"
+// @Failure 400 {object} ListResponse "Invalid query parameter"
+// @Failure 401 {object} ListResponse "Unauthorized"
+// @Failure 403 {object} ListResponse "Forbidden"
+// @Failure 500 {object} ListResponse "Internal server error"
+// @Security Bearer
+// @Router /admin/llm/calls [get]
+func List(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.llm_calls.List"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+		filter := database.LLMCallFilter{
+			TaskAlias: query.Get("taskAlias"),
+			Status:    query.Get("status"),
+			Limit:     defaultLimit,
+		}
+
+		var ok bool
+		if filter.UserID, ok = parseInt(query.Get("userId"), 0); !ok {
+			badRequest(w, r, log, "invalid userId parameter")
+			return
+		}
+		if filter.SubmissionID, ok = parseInt(query.Get("submissionId"), 0); !ok {
+			badRequest(w, r, log, "invalid submissionId parameter")
+			return
+		}
+		offset, ok := parseInt(query.Get("offset"), 0)
+		if !ok {
+			badRequest(w, r, log, "invalid offset parameter")
+			return
+		}
+		limit, ok := parseInt(query.Get("limit"), defaultLimit)
+		if !ok || limit == 0 || limit > maxLimit {
+			badRequest(w, r, log, "invalid limit parameter")
+			return
+		}
+		filter.Offset, filter.Limit = int(offset), int(limit)
+
+		calls, total, err := storage.ListLLMCalls(filter)
+		if err != nil {
+			log.Error("failed to list llm calls", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, ListResponse{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ListResponse{ResponseInfo: response_info.OK(), Calls: calls, Total: total})
+	}
+}
+
+// Get возвращает запись журнала LLM целиком
+// @Summary Get LLM call
+// @Description Returns a single LLM call with the raw system and user prompts and the raw model response. Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Param id path int true "LLM call ID"
+// @Success 200 {object} Response "LLM call"
+// @Failure 400 {object} Response "Invalid ID"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 404 {object} Response "Not found"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/llm/calls/{id} [get]
+func Get(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.llm_calls.Get"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil || id <= 0 {
+			log.Error("invalid llm call id", slog.String("id", chi.URLParam(r, "id")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, Response{ResponseInfo: response_info.Error("invalid id")})
+			return
+		}
+
+		call, err := storage.GetLLMCall(id)
+		if err != nil {
+			if err.Error() == "llm call not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, Response{ResponseInfo: response_info.Error("llm call not found")})
+				return
+			}
+			log.Error("failed to get llm call", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, Response{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK(), Call: &call})
+	}
+}
+
+// parseInt разбирает необязательный неотрицательный параметр запроса
+func parseInt(value string, def int64) (int64, bool) {
+	if value == "" {
+		return def, true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func badRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, msg string) {
+	log.Error(msg)
+	w.WriteHeader(http.StatusBadRequest)
+	render.JSON(w, r, ListResponse{ResponseInfo: response_info.Error(msg)})
+}
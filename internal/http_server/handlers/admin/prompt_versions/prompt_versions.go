@@ -0,0 +1,371 @@
+package prompt_versions
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type CreateRequest struct {
+	SystemTemplate string `json:"systemTemplate" validate:"required"`
+	UserTemplate   string `json:"userTemplate" validate:"required"`
+	Comment        string `json:"comment,omitempty" validate:"max=500"`
+}
+
+// PreviewRequest — если заданы шаблоны, рендерится черновик; иначе версия Version или активная версия
+type PreviewRequest struct {
+	Version        int                    `json:"version,omitempty" validate:"gte=0"`
+	SystemTemplate string                 `json:"systemTemplate,omitempty"`
+	UserTemplate   string                 `json:"userTemplate,omitempty"`
+	Vars           map[string]interface{} `json:"vars"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Version      *database.PromptVersion    `json:"version,omitempty"`
+}
+
+type ListResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Versions     []database.PromptVersion   `json:"versions"`
+}
+
+type PreviewResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Version      int                        `json:"version,omitempty"`
+	SystemPrompt string                     `json:"systemPrompt,omitempty"`
+	UserPrompt   string                     `json:"userPrompt,omitempty"`
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{ResponseInfo: response_info.Error(msg)}
+}
+
+// ListActive возвращает активные версии всех промптов
+// @Summary List active prompt versions
+// @Description Returns the currently active version of every prompt. Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} ListResponse "Active prompt versions"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/prompts [get]
+func ListActive(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.prompt_versions.ListActive"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		versions, err := storage.ListActivePromptVersions()
+		if err != nil {
+			log.Error("failed to list active prompt versions", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ListResponse{ResponseInfo: response_info.OK(), Versions: versions})
+	}
+}
+
+// List возвращает историю версий промпта
+// @Summary List prompt versions
+// @Description Returns all versions of a prompt, newest first. Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Prompt name"
+// @Success 200 {object} ListResponse "Prompt versions"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 404 {object} Response "Unknown prompt"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/prompts/{name}/versions [get]
+func List(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.prompt_versions.List"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		name, ok := promptName(w, r, log)
+		if !ok {
+			return
+		}
+
+		versions, err := storage.ListPromptVersions(name)
+		if err != nil {
+			log.Error("failed to list prompt versions", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ListResponse{ResponseInfo: response_info.OK(), Versions: versions})
+	}
+}
+
+// Create сохраняет новую версию промпта (неактивную)
+// @Summary Create prompt version
+// @Description Saves a new inactive version of a prompt. Templates use Go text/template syntax, e.g. {{.SkipsCount}}, {{.NoiseLevel}}, {{.Language}}, {{.Locale}}, {{.Code}}. Requires admin role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Prompt name"
+// @Param request body CreateRequest true "System and user templates"
+// @Success 200 {object} Response "Version created"
+// @Failure 400 {object} Response "Invalid request or template syntax"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 404 {object} Response "Unknown prompt"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/prompts/{name}/versions [post]
+func Create(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.prompt_versions.Create"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		name, ok := promptName(w, r, log)
+		if !ok {
+			return
+		}
+
+		var req CreateRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		if err := prompts.Validate(req.SystemTemplate, req.UserTemplate); err != nil {
+			log.Error("invalid prompt template", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse(err.Error()))
+			return
+		}
+
+		adminID, _ := r.Context().Value(my_middleware.UserIDKey).(int64)
+		version, err := storage.CreatePromptVersion(name, req.SystemTemplate, req.UserTemplate, req.Comment, adminID)
+		if err != nil {
+			log.Error("failed to create prompt version", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		log.Info("prompt version created", slog.String("prompt", name), slog.Int("version", version.Version), slog.Int64("admin_id", adminID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK(), Version: &version})
+	}
+}
+
+// Preview рендерит промпт с тестовыми переменными без обращения к LLM
+// @Summary Preview prompt
+// @Description Renders a draft (when templates are given), a specific version, or the active version with the supplied variables. Requires admin role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param name path string true "Prompt name"
+// @Param request body PreviewRequest true "Variables and optional draft templates or version"
+// @Success 200 {object} PreviewResponse "Rendered prompt"
+// @Failure 400 {object} PreviewResponse "Invalid request, template or variables"
+// @Failure 401 {object} PreviewResponse "Unauthorized"
+// @Failure 403 {object} PreviewResponse "Forbidden"
+// @Failure 404 {object} PreviewResponse "Unknown prompt or version"
+// @Failure 500 {object} PreviewResponse "Internal server error"
+// @Security Bearer
+// @Router /admin/prompts/{name}/preview [post]
+func Preview(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.prompt_versions.Preview"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		name, ok := promptName(w, r, log)
+		if !ok {
+			return
+		}
+
+		var req PreviewRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		var version database.PromptVersion
+		switch {
+		case req.SystemTemplate != "" || req.UserTemplate != "":
+			version = database.PromptVersion{Name: name, SystemTemplate: req.SystemTemplate, UserTemplate: req.UserTemplate}
+		case req.Version > 0:
+			v, err := storage.GetPromptVersion(name, req.Version)
+			if err != nil {
+				log.Error("failed to get prompt version", sl.Err(err))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, PreviewResponse{ResponseInfo: response_info.Error("prompt version not found")})
+				return
+			}
+			version = v
+		default:
+			versions, err := storage.ListActivePromptVersions()
+			if err != nil {
+				log.Error("failed to list active prompt versions", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, PreviewResponse{ResponseInfo: response_info.Error("internal server error")})
+				return
+			}
+			found := false
+			for _, v := range versions {
+				if v.Name == name {
+					version, found = v, true
+					break
+				}
+			}
+			if !found {
+				log.Error("no active prompt version", slog.String("prompt", name))
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, PreviewResponse{ResponseInfo: response_info.Error("no active prompt version")})
+				return
+			}
+		}
+
+		rendered, err := prompts.RenderVersion(version, req.Vars)
+		if err != nil {
+			log.Error("failed to render prompt", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, PreviewResponse{ResponseInfo: response_info.Error(err.Error())})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, PreviewResponse{
+			ResponseInfo: response_info.OK(),
+			Version:      version.Version,
+			SystemPrompt: rendered.System,
+			UserPrompt:   rendered.User,
+		})
+	}
+}
+
+// Activate делает версию промпта активной; новые задачи сразу начинают использовать её
+// @Summary Activate prompt version
+// @Description Makes the given version the active one and invalidates the in-memory prompt cache. Requires admin role.
+// @Tags Admin
+// @Produce json
+// @Param name path string true "Prompt name"
+// @Param version path int true "Version number"
+// @Success 200 {object} Response "Version activated"
+// @Failure 400 {object} Response "Invalid version"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 404 {object} Response "Unknown prompt or version"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/prompts/{name}/versions/{version}/activate [post]
+func Activate(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.prompt_versions.Activate"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		name, ok := promptName(w, r, log)
+		if !ok {
+			return
+		}
+
+		version, err := strconv.Atoi(chi.URLParam(r, "version"))
+		if err != nil || version <= 0 {
+			log.Error("invalid prompt version", slog.String("version", chi.URLParam(r, "version")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("invalid version"))
+			return
+		}
+
+		if err := storage.ActivatePromptVersion(name, version); err != nil {
+			log.Error("failed to activate prompt version", sl.Err(err))
+			if err.Error() == "prompt version not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, getErrorResponse("prompt version not found"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if prompts.Default != nil {
+			prompts.Default.Invalidate()
+		}
+
+		adminID, _ := r.Context().Value(my_middleware.UserIDKey).(int64)
+		log.Info("prompt version activated", slog.String("prompt", name), slog.Int("version", version), slog.Int64("admin_id", adminID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK()})
+	}
+}
+
+// promptName извлекает имя промпта из URL и проверяет, что такой промпт существует
+func promptName(w http.ResponseWriter, r *http.Request, log *slog.Logger) (string, bool) {
+	name := chi.URLParam(r, "name")
+	if !prompts.Known(name) {
+		log.Error("unknown prompt", slog.String("prompt", name))
+		w.WriteHeader(http.StatusNotFound)
+		render.JSON(w, r, getErrorResponse("unknown prompt: "+name))
+		return "", false
+	}
+	return name, true
+}
+
+// decodeRequest декодирует и валидирует тело запроса, при ошибке отправляет ответ клиенту
+func decodeRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("empty request"))
+			return false
+		}
+		log.Error("failed to decode request body", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, getErrorResponse("invalid request body"))
+		return false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			log.Error("invalid request", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, &Response{ResponseInfo: response_info.ValidationError(validationErrs)})
+			return false
+		}
+	}
+	return true
+}
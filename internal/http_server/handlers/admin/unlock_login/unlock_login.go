@@ -0,0 +1,98 @@
+package unlock_login
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/login_guard"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"errors"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type Request struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+}
+
+// New снимает блокировку входа для email
+// @Summary Unlock login for an account
+// @Description Clears failed login counters and any temporary lockout for the given email, including the two-factor step of the account with this email. Requires admin role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body Request true "Account email"
+// @Success 200 {object} Response "Account unlocked"
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 403 {object} Response "Forbidden"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /admin/login/unlock [post]
+func New(logger *slog.Logger, storage *database.Storage, guard *login_guard.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.admin.unlock_login.New"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, Response{ResponseInfo: response_info.Error("empty request")})
+				return
+			}
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, Response{ResponseInfo: response_info.Error("invalid request body")})
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, Response{ResponseInfo: response_info.ValidationError(validationErrs)})
+				return
+			}
+		}
+
+		subjects := []login_guard.Subject{guard.Account(req.Email)}
+		// Второй шаг входа (2FA) ограничивается по ID пользователя; для несуществующего email сбрасывается только счётчик по email
+		userID, _, err := storage.GetUserByEmail(req.Email)
+		switch {
+		case err == nil:
+			subjects = append(subjects, guard.User(userID))
+		case err.Error() != "user not found":
+			log.Error("failed to get user", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, Response{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		if err := guard.Reset(subjects...); err != nil {
+			log.Error("failed to unlock login", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, Response{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		adminID, _ := r.Context().Value(my_middleware.UserIDKey).(int64)
+		log.Info("login unlocked by admin", slog.Int64("admin_id", adminID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK()})
+	}
+}
@@ -0,0 +1,223 @@
+package api_tokens
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/api_token"
+	"codular-backend/lib/logger/sl"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type CreateRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=tasks:read tasks:write submissions:read submissions:write"`
+	ExpiresInDays *int     `json:"expiresInDays,omitempty" validate:"omitempty,gte=1,lte=3650"`
+}
+
+type CreateResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Token        string                     `json:"token,omitempty"`
+	TokenInfo    *database.APIToken         `json:"tokenInfo,omitempty"`
+}
+
+type ListResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Tokens       []database.APIToken        `json:"tokens"`
+}
+
+type RevokeResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+}
+
+// Create выпускает новый персональный токен доступа
+// @Summary Create personal access token
+// @Description Creates a long-lived, scoped personal access token for scripting. The token value is returned only once; only its hash is stored. Available scopes: tasks:read, tasks:write, submissions:read, submissions:write.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body CreateRequest true "Token name, scopes and optional expiry"
+// @Success 200 {object} CreateResponse "Token created"
+// @Failure 400 {object} CreateResponse "Invalid request"
+// @Failure 401 {object} CreateResponse "Unauthorized"
+// @Failure 403 {object} CreateResponse "Api tokens cannot manage tokens"
+// @Failure 500 {object} CreateResponse "Internal server error"
+// @Security Bearer
+// @Router /user/tokens [post]
+func Create(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.api_tokens.Create"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, CreateResponse{ResponseInfo: response_info.Error("unauthorized")})
+			return
+		}
+
+		var req CreateRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, CreateResponse{ResponseInfo: response_info.Error("empty request")})
+				return
+			}
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, CreateResponse{ResponseInfo: response_info.Error("invalid request body")})
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, CreateResponse{ResponseInfo: response_info.ValidationError(validationErrs)})
+				return
+			}
+		}
+
+		var expiresAt *time.Time
+		if req.ExpiresInDays != nil {
+			expiry := time.Now().UTC().Add(time.Hour * 24 * time.Duration(*req.ExpiresInDays))
+			expiresAt = &expiry
+		}
+
+		token, prefix, err := api_token.Generate()
+		if err != nil {
+			log.Error("failed to generate api token", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, CreateResponse{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		tokenInfo, err := storage.CreateAPIToken(userID, req.Name, api_token.Hash(token), prefix, req.Scopes, expiresAt)
+		if err != nil {
+			log.Error("failed to save api token", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, CreateResponse{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		log.Info("api token created", slog.Int64("user_id", userID), slog.Int64("token_id", tokenInfo.ID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, CreateResponse{
+			ResponseInfo: response_info.OK(),
+			Token:        token,
+			TokenInfo:    &tokenInfo,
+		})
+	}
+}
+
+// List возвращает персональные токены пользователя
+// @Summary List personal access tokens
+// @Description Returns metadata (name, prefix, scopes, expiry, last usage, revocation) of the user's personal access tokens. Token values are never returned.
+// @Tags User
+// @Produce json
+// @Success 200 {object} ListResponse "Tokens retrieved"
+// @Failure 401 {object} ListResponse "Unauthorized"
+// @Failure 500 {object} ListResponse "Internal server error"
+// @Security Bearer
+// @Router /user/tokens [get]
+func List(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.api_tokens.List"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, ListResponse{ResponseInfo: response_info.Error("unauthorized"), Tokens: []database.APIToken{}})
+			return
+		}
+
+		tokens, err := storage.ListAPITokens(userID)
+		if err != nil {
+			log.Error("failed to list api tokens", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, ListResponse{ResponseInfo: response_info.Error("internal server error"), Tokens: []database.APIToken{}})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, ListResponse{ResponseInfo: response_info.OK(), Tokens: tokens})
+	}
+}
+
+// Revoke отзывает персональный токен пользователя
+// @Summary Revoke personal access token
+// @Description Revokes one of the user's personal access tokens by its ID. Revoked tokens are rejected immediately.
+// @Tags User
+// @Produce json
+// @Param token_id path int true "Token ID"
+// @Success 200 {object} RevokeResponse "Token revoked"
+// @Failure 400 {object} RevokeResponse "Invalid token ID"
+// @Failure 401 {object} RevokeResponse "Unauthorized"
+// @Failure 404 {object} RevokeResponse "Token not found"
+// @Failure 500 {object} RevokeResponse "Internal server error"
+// @Security Bearer
+// @Router /user/tokens/{token_id} [delete]
+func Revoke(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.api_tokens.Revoke"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, RevokeResponse{ResponseInfo: response_info.Error("unauthorized")})
+			return
+		}
+
+		tokenID, err := strconv.ParseInt(chi.URLParam(r, "token_id"), 10, 64)
+		if err != nil {
+			log.Error("invalid token_id format", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, RevokeResponse{ResponseInfo: response_info.Error("invalid token_id format")})
+			return
+		}
+
+		if err := storage.RevokeAPIToken(userID, tokenID); err != nil {
+			log.Error("failed to revoke api token", sl.Err(err))
+			if err.Error() == "api token not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, RevokeResponse{ResponseInfo: response_info.Error("token not found")})
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, RevokeResponse{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		log.Info("api token revoked", slog.Int64("user_id", userID), slog.Int64("token_id", tokenID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, RevokeResponse{ResponseInfo: response_info.OK()})
+	}
+}
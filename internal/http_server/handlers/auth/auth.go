@@ -1,19 +1,31 @@
 package auth
 
 import (
+	"codular-backend/internal/http_server/handlers/two_factor"
+	"codular-backend/internal/login_guard"
 	"codular-backend/internal/storage/database"
 	response_info "codular-backend/lib/api/response"
 	"codular-backend/lib/logger/sl"
+	"fmt"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 )
 
+const (
+	// challengePurpose помечает токены второго шага входа, которые нельзя использовать как access-токены
+	challengePurpose = "2fa_challenge"
+	challengeTTL     = 5 * time.Minute
+)
+
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
@@ -24,14 +36,34 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
 type AuthResponse struct {
-	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
-	AccessToken  string                     `json:"access_token"`
+	ResponseInfo      response_info.ResponseInfo `json:"responseInfo"`
+	AccessToken       string                     `json:"access_token"`
+	TwoFactorRequired bool                       `json:"two_factor_required,omitempty"`
+	ChallengeToken    string                     `json:"challenge_token,omitempty"`
+}
+
+// TokenSigner подписывает JWT текущим ключом (см. jwt_keys.Manager)
+type TokenSigner interface {
+	Sign(claims jwt.MapClaims) (string, error)
+}
+
+// TokenKeys подписывает и проверяет JWT (см. jwt_keys.Manager)
+type TokenKeys interface {
+	TokenSigner
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	ValidMethods() []string
 }
 
 type UserStorage interface {
 	CreateUser(email, passwordHash string) (int64, error)
 	GetUserByEmail(email string) (int64, string, error)
+	GetTwoFactorState(userID int64) (database.TwoFactorState, error)
 	SaveToken(userID int64, token, tokenType string, expiresAt time.Time) error
 	ValidateToken(token, tokenType string) (int64, bool, error)
 	DeleteToken(token, tokenType string) error
@@ -99,7 +131,7 @@ func clearRefreshTokenCookie(w http.ResponseWriter) {
 // @Failure 409 {object} AuthResponse "Email already exists"
 // @Failure 500 {object} AuthResponse "Internal server error"
 // @Router /auth/register [post]
-func Register(log *slog.Logger, storage UserStorage, jwtSecret string) http.HandlerFunc {
+func Register(log *slog.Logger, storage UserStorage, signer TokenSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const functionPath = "internal.http_server.handlers.auth.Register"
 
@@ -145,7 +177,7 @@ func Register(log *slog.Logger, storage UserStorage, jwtSecret string) http.Hand
 			return
 		}
 
-		accessToken, err := generateJWT(userID, jwtSecret, time.Hour*24)
+		accessToken, err := generateJWT(userID, signer, time.Hour*24)
 		if err != nil {
 			log.Error("failed to generate access JWT", sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -153,7 +185,7 @@ func Register(log *slog.Logger, storage UserStorage, jwtSecret string) http.Hand
 			return
 		}
 
-		refreshToken, err := generateJWT(userID, jwtSecret, time.Hour*24*30)
+		refreshToken, err := generateJWT(userID, signer, time.Hour*24*30)
 		if err != nil {
 			log.Error("failed to generate refresh JWT", sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -184,7 +216,7 @@ func Register(log *slog.Logger, storage UserStorage, jwtSecret string) http.Hand
 
 // Login аутентифицирует пользователя
 // @Summary Login user
-// @Description Authenticates a user with email and password, returning access token and setting refresh token in a secure cookie.
+// @Description Authenticates a user with email and password, returning access token and setting refresh token in a secure cookie. If two-factor authentication is enabled, no tokens are issued; instead the response contains two_factor_required=true and a short-lived challenge_token for /auth/login/2fa.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -192,9 +224,10 @@ func Register(log *slog.Logger, storage UserStorage, jwtSecret string) http.Hand
 // @Success 200 {object} AuthResponse "User logged in successfully"
 // @Failure 400 {object} AuthResponse "Invalid request or empty body"
 // @Failure 401 {object} AuthResponse "Invalid credentials"
+// @Failure 429 {object} AuthResponse "Too many failed attempts; see Retry-After header"
 // @Failure 500 {object} AuthResponse "Internal server error"
 // @Router /auth/login [post]
-func Login(log *slog.Logger, storage UserStorage, jwtSecret string) http.HandlerFunc {
+func Login(log *slog.Logger, storage UserStorage, signer TokenSigner, guard *login_guard.Guard) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const functionPath = "internal.http_server.handlers.auth.Login"
 
@@ -218,9 +251,18 @@ func Login(log *slog.Logger, storage UserStorage, jwtSecret string) http.Handler
 			return
 		}
 
+		// Защита от перебора: проверяем блокировку по email и по IP
+		subjects := []login_guard.Subject{guard.Account(req.Email), guard.IP(r)}
+		if !checkLoginAttempts(w, r, log, guard, subjects...) {
+			return
+		}
+
 		userID, passwordHash, err := storage.GetUserByEmail(req.Email)
 		if err != nil {
 			log.Error("failed to get user", sl.Err(err))
+			// Сравнение с фиктивным хэшем выравнивает время ответа, чтобы не раскрывать существование email
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(req.Password))
+			registerFailedAttempt(log, guard, subjects...)
 			w.WriteHeader(http.StatusUnauthorized)
 			render.JSON(w, r, getErrorResponse("invalid credentials"))
 			return
@@ -228,12 +270,49 @@ func Login(log *slog.Logger, storage UserStorage, jwtSecret string) http.Handler
 
 		if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
 			log.Error("invalid password")
+			registerFailedAttempt(log, guard, subjects...)
 			w.WriteHeader(http.StatusUnauthorized)
 			render.JSON(w, r, getErrorResponse("invalid credentials"))
 			return
 		}
 
-		accessToken, err := generateJWT(userID, jwtSecret, time.Hour*24)
+		if err := guard.Reset(guard.Account(req.Email)); err != nil {
+			log.Error("failed to reset login attempts", sl.Err(err))
+		}
+
+		twoFactor, err := storage.GetTwoFactorState(userID)
+		if err != nil {
+			log.Error("failed to get two-factor state", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		// При включённой 2FA вместо токенов выдаётся короткоживущий challenge-токен
+		if twoFactor.Enabled {
+			challengeToken, err := signer.Sign(jwt.MapClaims{
+				"user_id": userID,
+				"purpose": challengePurpose,
+				"exp":     time.Now().Add(challengeTTL).Unix(),
+			})
+			if err != nil {
+				log.Error("failed to generate challenge JWT", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, getErrorResponse("internal server error"))
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			render.JSON(w, r, &AuthResponse{
+				ResponseInfo:      response_info.OK(),
+				TwoFactorRequired: true,
+				ChallengeToken:    challengeToken,
+			})
+			log.Info("two-factor challenge issued", slog.Int64("user_id", userID))
+			return
+		}
+
+		accessToken, err := generateJWT(userID, signer, time.Hour*24)
 		if err != nil {
 			log.Error("failed to generate access JWT", sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -241,7 +320,7 @@ func Login(log *slog.Logger, storage UserStorage, jwtSecret string) http.Handler
 			return
 		}
 
-		refreshToken, err := generateJWT(userID, jwtSecret, time.Hour*24*30)
+		refreshToken, err := generateJWT(userID, signer, time.Hour*24*30)
 		if err != nil {
 			log.Error("failed to generate refresh JWT", sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -270,6 +349,104 @@ func Login(log *slog.Logger, storage UserStorage, jwtSecret string) http.Handler
 	}
 }
 
+// LoginTwoFactor завершает вход с включённой 2FA
+// @Summary Complete two-factor login
+// @Description Exchanges the challenge token returned by /auth/login and a TOTP or recovery code for an access token, setting refresh token in a secure cookie.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body LoginTwoFactorRequest true "Challenge token and TOTP or recovery code"
+// @Success 200 {object} AuthResponse "User logged in successfully"
+// @Failure 400 {object} AuthResponse "Invalid request or empty body"
+// @Failure 401 {object} AuthResponse "Invalid or expired challenge, or invalid code"
+// @Failure 429 {object} AuthResponse "Too many failed attempts; see Retry-After header"
+// @Failure 500 {object} AuthResponse "Internal server error"
+// @Router /auth/login/2fa [post]
+func LoginTwoFactor(log *slog.Logger, storage *database.Storage, keys TokenKeys, guard *login_guard.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.auth.LoginTwoFactor"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req LoginTwoFactorRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("invalid request body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getValidationErrorResponse(err.(validator.ValidationErrors)))
+			return
+		}
+
+		userID, err := parseChallengeToken(req.ChallengeToken, keys)
+		if err != nil {
+			log.Error("invalid challenge token", sl.Err(err))
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("invalid or expired challenge"))
+			return
+		}
+
+		subjects := []login_guard.Subject{guard.User(userID), guard.IP(r)}
+		if !checkLoginAttempts(w, r, log, guard, subjects...) {
+			return
+		}
+
+		state, err := storage.GetTwoFactorState(userID)
+		if err != nil {
+			log.Error("failed to get two-factor state", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		if !state.Enabled {
+			log.Error("two-factor authentication is not enabled", slog.Int64("user_id", userID))
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("invalid or expired challenge"))
+			return
+		}
+
+		valid, err := two_factor.VerifyCode(storage, userID, state.Secret, req.Code)
+		if err != nil {
+			log.Error("failed to verify second factor", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		if !valid {
+			log.Error("invalid second factor code", slog.Int64("user_id", userID))
+			registerFailedAttempt(log, guard, subjects...)
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("invalid code"))
+			return
+		}
+
+		if err := guard.Reset(guard.User(userID)); err != nil {
+			log.Error("failed to reset login attempts", sl.Err(err))
+		}
+
+		accessToken, refreshToken, err := issueTokens(storage, keys, userID)
+		if err != nil {
+			log.Error("failed to issue tokens", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		setRefreshTokenCookie(w, refreshToken, time.Now().Add(time.Hour*24*30))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, getOKResponse(accessToken))
+		log.Info("user logged in with two-factor authentication", slog.Int64("user_id", userID))
+	}
+}
+
 // Refresh обновляет access-токен по refresh-токену из cookie
 // @Summary Refresh access token
 // @Description Refreshes the access token using a valid refresh token provided in a secure cookie.
@@ -280,7 +457,7 @@ func Login(log *slog.Logger, storage UserStorage, jwtSecret string) http.Handler
 // @Failure 401 {object} AuthResponse "Invalid or expired refresh token"
 // @Failure 500 {object} AuthResponse "Internal server error"
 // @Router /auth/refresh [post]
-func Refresh(log *slog.Logger, storage *database.Storage, jwtSecret string) http.HandlerFunc {
+func Refresh(log *slog.Logger, storage *database.Storage, signer TokenSigner) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const functionPath = "internal.http_server.handlers.auth.Refresh"
 
@@ -312,7 +489,7 @@ func Refresh(log *slog.Logger, storage *database.Storage, jwtSecret string) http
 		}
 
 		// Генерация нового access-токена
-		newAccessToken, err := generateJWT(userID, jwtSecret, time.Hour*24)
+		newAccessToken, err := generateJWT(userID, signer, time.Hour*24)
 		if err != nil {
 			log.Error("failed to generate access JWT", sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -321,7 +498,7 @@ func Refresh(log *slog.Logger, storage *database.Storage, jwtSecret string) http
 		}
 
 		// Генерация нового refresh-токена
-		newRefreshToken, err := generateJWT(userID, jwtSecret, time.Hour*24*30)
+		newRefreshToken, err := generateJWT(userID, signer, time.Hour*24*30)
 		if err != nil {
 			log.Error("failed to generate refresh JWT", sl.Err(err))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -400,10 +577,88 @@ func Logout(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 }
 
 // generateJWT создаёт JWT-токен для пользователя
-func generateJWT(userID int64, secret string, duration time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func generateJWT(userID int64, signer TokenSigner, duration time.Duration) (string, error) {
+	return signer.Sign(jwt.MapClaims{
 		"user_id": userID,
+		"iat":     time.Now().Unix(),
 		"exp":     time.Now().Add(duration).Unix(),
 	})
-	return token.SignedString([]byte(secret))
+}
+
+// issueTokens создаёт и сохраняет пару access/refresh токенов
+func issueTokens(storage UserStorage, signer TokenSigner, userID int64) (string, string, error) {
+	accessToken, err := generateJWT(userID, signer, time.Hour*24)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate access JWT: %v", err)
+	}
+	refreshToken, err := generateJWT(userID, signer, time.Hour*24*30)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh JWT: %v", err)
+	}
+	if err := storage.SaveToken(userID, accessToken, "access", time.Now().Add(time.Hour*24)); err != nil {
+		return "", "", err
+	}
+	if err := storage.SaveToken(userID, refreshToken, "refresh", time.Now().Add(time.Hour*24*30)); err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// parseChallengeToken проверяет challenge-токен второго шага входа и возвращает user_id
+func parseChallengeToken(tokenString string, keys TokenKeys) (int64, error) {
+	token, err := jwt.Parse(tokenString, keys.Keyfunc, jwt.WithValidMethods(keys.ValidMethods()))
+	if err != nil {
+		return 0, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, fmt.Errorf("invalid token claims")
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != challengePurpose {
+		return 0, fmt.Errorf("token is not a two-factor challenge")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("user_id not found in token")
+	}
+	return int64(userID), nil
+}
+
+// checkLoginAttempts отвечает 429, если для субъектов действует задержка или блокировка
+func checkLoginAttempts(w http.ResponseWriter, r *http.Request, log *slog.Logger, guard *login_guard.Guard, subjects ...login_guard.Subject) bool {
+	wait, err := guard.Check(subjects...)
+	if err != nil {
+		log.Error("failed to check login attempts", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, getErrorResponse("internal server error"))
+		return false
+	}
+	if wait > 0 {
+		log.Warn("login attempt rejected by brute-force protection", slog.Duration("retry_after", wait))
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		render.JSON(w, r, getErrorResponse("too many login attempts, try again later"))
+		return false
+	}
+	return true
+}
+
+// registerFailedAttempt учитывает неудачную попытку входа
+func registerFailedAttempt(log *slog.Logger, guard *login_guard.Guard, subjects ...login_guard.Subject) {
+	if err := guard.Fail(subjects...); err != nil {
+		log.Error("failed to register failed login attempt", sl.Err(err))
+	}
+}
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// dummyPasswordHash возвращает bcrypt-хэш для сравнения, когда пользователь не найден
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("codular-dummy-password"), bcrypt.DefaultCost)
+	})
+	return dummyHash
 }
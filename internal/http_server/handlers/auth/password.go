@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"codular-backend/internal/http_server/handlers/two_factor"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/login_guard"
+	"codular-backend/internal/storage/database"
+	"codular-backend/lib/logger/sl"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+// ChangePassword меняет пароль и завершает все остальные сессии пользователя
+// @Summary Change password
+// @Description Changes the password after re-checking the current one. All existing sessions are revoked; the caller receives a fresh access token and refresh token cookie.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} AuthResponse "Password changed"
+// @Failure 400 {object} AuthResponse "Invalid request or empty body"
+// @Failure 401 {object} AuthResponse "Unauthorized or invalid current password"
+// @Failure 429 {object} AuthResponse "Too many failed attempts; see Retry-After"
+// @Failure 500 {object} AuthResponse "Internal server error"
+// @Security Bearer
+// @Router /user/password [post]
+func ChangePassword(log *slog.Logger, storage *database.Storage, signer TokenSigner, guard *login_guard.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.auth.ChangePassword"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var req ChangePasswordRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("invalid request body"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("invalid request", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getValidationErrorResponse(err.(validator.ValidationErrors)))
+			return
+		}
+
+		if !two_factor.CheckAttempts(w, r, log, guard, userID) {
+			return
+		}
+
+		passwordHash, err := storage.GetPasswordHashByID(userID)
+		if err != nil {
+			log.Error("failed to get password hash", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.CurrentPassword)); err != nil {
+			log.Error("invalid current password", slog.Int64("user_id", userID))
+			two_factor.RegisterFailure(r, log, guard, userID)
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("invalid current password"))
+			return
+		}
+
+		two_factor.ResetAttempts(log, guard, userID)
+
+		newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			log.Error("failed to hash password", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if err := storage.UpdatePasswordHash(userID, string(newHash)); err != nil {
+			log.Error("failed to update password", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		// Отзываем все сессии, текущему клиенту выдаём новую пару токенов
+		if err := storage.DeleteUserTokens(userID); err != nil {
+			log.Error("failed to revoke sessions", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		accessToken, refreshToken, err := issueTokens(storage, signer, userID)
+		if err != nil {
+			log.Error("failed to issue tokens", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		setRefreshTokenCookie(w, refreshToken, time.Now().Add(time.Hour*24*30))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, getOKResponse(accessToken))
+		log.Info("password changed", slog.Int64("user_id", userID))
+	}
+}
@@ -2,11 +2,20 @@ package noises
 
 import (
 	"codular-backend/internal/config"
+	"codular-backend/internal/experiments"
+	"codular-backend/internal/hint_ladder"
 	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
 	database "codular-backend/internal/storage/database"
-	openRouterAPI "codular-backend/lib/api/openrouter"
+	"codular-backend/internal/task_progress"
 	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/hintladder"
+	"codular-backend/lib/llmjson"
 	"codular-backend/lib/logger/sl"
+	noises_lib "codular-backend/lib/noises"
+	"codular-backend/lib/scrub"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
@@ -18,7 +27,6 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -26,6 +34,7 @@ type Request struct {
 	Code                string `json:"sourceCode" validate:"required"`
 	NoiseLevel          int    `json:"noiseLevel" validate:"required,gte=0,lte=100"`
 	ProgrammingLanguage string `json:"programmingLanguage" validate:"required"`
+	Locale              string `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
 }
 
 type Response struct {
@@ -38,6 +47,12 @@ type LLMResponse struct {
 	Description string `json:"description"`
 }
 
+// responseSchema — схема ответа LLM при генерации задачи
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"noiseCode":   llmjson.String(),
+	"description": llmjson.String(),
+})
+
 func getErrorResponse(msg string) *Response {
 	return &Response{
 		ResponseInfo: response_info.Error(msg),
@@ -61,7 +76,7 @@ func getOKResponse(taskAlias string) *Response {
 
 // New generates noise for the provided code and saves it to the database.
 // @Summary Generate and save noised code
-// @Description Processes the provided source code with a specified noise level, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. Returns the task alias for retrieving the task code and description.
+// @Description Processes the provided source code with a specified noise level, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. The edits the model made are recorded as a line diff against the original code: inserted lines are noise, changed or removed lines are semantic changes; submissions are graded against them. A ladder of hints (nudge, pointer, near_answer) is generated for every edit and revealed through POST /task/{alias}/hint. Returns the task alias for retrieving the task code and description.
 // @Tags Noises
 // @Accept json
 // @Produce json
@@ -70,6 +85,7 @@ func getOKResponse(taskAlias string) *Response {
 // @Success 200 {object} noises.Response "Example response" Example({"responseInfo":{"status":"OK"},"taskAlias":"abc123"})
 // @Failure 400 {object} noises.Response "Invalid request, empty body, or invalid programming language"
 // @Failure 401 {object} noises.Response "Unauthorized"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
 // @Failure 500 {object} noises.Response "Internal server error"
 // @Security Bearer
 // @Router /noises/generate [post]
@@ -119,6 +135,13 @@ func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.H
 			}
 		}
 
+		if noises_lib.TooLong(decodedRequest.Code) {
+			log.Error("source code is too long")
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse(fmt.Sprintf("source code must be shorter than %d lines", noises_lib.MaxLines)))
+			return
+		}
+
 		programmingLanguageId, err := storage.GetProgrammingLanguageIDByName(decodedRequest.ProgrammingLanguage)
 		if err != nil {
 			log.Error("invalid programming language: "+decodedRequest.ProgrammingLanguage, sl.Err(err))
@@ -142,7 +165,7 @@ func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.H
 		}
 
 		// Сохранение начального статуса "Processing" в Redis
-		initialStatus := database.TaskStatus{Status: "Processing"}
+		initialStatus := task_progress.Initial(userID)
 		if err := storage.SetTaskStatus(alias, initialStatus); err != nil {
 			log.Error("failed to set initial status in Redis", sl.Err(err))
 			writer.WriteHeader(http.StatusInternalServerError)
@@ -154,19 +177,43 @@ func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.H
 		writer.WriteHeader(http.StatusOK)
 		render.JSON(writer, request, getOKResponse(alias))
 
+		locale := decodedRequest.Locale
+		if locale == "" {
+			locale = prompts.DefaultLocale
+		}
+
 		// Асинхронная обработка
-		go processTaskAsync(log, alias, decodedRequest.Code, decodedRequest.NoiseLevel, programmingLanguageId, userID, storage)
+		go processTaskAsync(log, alias, decodedRequest.Code, decodedRequest.NoiseLevel, decodedRequest.ProgrammingLanguage, locale, programmingLanguageId, userID, storage)
 
 		log.Info("task processing initiated", slog.String("task_alias", alias), slog.Int64("user_id", userID))
 	}
 }
 
 // processTaskAsync асинхронно обрабатывает задачу и сохраняет результат
-func processTaskAsync(log *slog.Logger, alias, code string, noiseLevel int, programmingLanguageId, userID int64, storage *database.Storage) {
+func processTaskAsync(log *slog.Logger, alias, code string, noiseLevel int, language, locale string, programmingLanguageId, userID int64, storage *database.Storage) {
 	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", userID))
 
-	processedCode, description, err := ProcessCode(code, noiseLevel, log)
+	opts := llm.Options{UserID: userID, Endpoint: llm.EndpointNoisesGenerate, TaskAlias: alias}
+	variantID := experiments.Apply(prompts.NoisesGenerate, userID, &opts, log)
+
+	ctx, tracker := task_progress.Start(context.Background(), alias, userID, log)
+	opts.OnProgress = tracker.Update
+
+	result, err := ProcessCode(ctx, code, noiseLevel, language, locale, opts, log)
+	var ladders []hintladder.Ladder
+	if err == nil {
+		ladders = hint_ladder.ForNoises(ctx, result.Code, result.Edits, locale, opts, log)
+	}
+	tracker.Stop()
+	if tracker.Cancelled() {
+		cancelledStatus := database.TaskStatus{Status: task_progress.StatusCancelled, UserID: userID}
+		if err := storage.SetTaskStatus(alias, cancelledStatus); err != nil {
+			log.Error("failed to set cancelled status in Redis", sl.Err(err))
+		}
+		return
+	}
 	if err != nil {
+		experiments.RecordGeneration(variantID, userID, alias, false, log)
 		// Обновление статуса на "Error" в случае ошибки
 		errorStatus := database.TaskStatus{Status: "Error", Error: err.Error()}
 		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
@@ -176,7 +223,7 @@ func processTaskAsync(log *slog.Logger, alias, code string, noiseLevel int, prog
 	}
 
 	// Сохранение в PostgreSQL
-	_, _, err = storage.SaveNoisesCodeWithAlias(processedCode, code, programmingLanguageId, userID, alias, description)
+	taskID, _, err := storage.SaveNoisesCodeWithAlias(result.Code, code, noises_lib.Encode(code, result.Edits), programmingLanguageId, userID, alias, result.Description, result.PromptVersionID)
 	if err != nil {
 		// Обновление статуса на "Error" в случае ошибки сохранения
 		errorStatus := database.TaskStatus{Status: "Error", Error: fmt.Sprintf("failed to save task: %v", err)}
@@ -186,56 +233,106 @@ func processTaskAsync(log *slog.Logger, alias, code string, noiseLevel int, prog
 		return
 	}
 
+	if report := result.Scrub.Report(); report != nil {
+		if err := storage.SetTaskScrubReport(taskID, report); err != nil {
+			log.Error("failed to save scrub report", sl.Err(err))
+		}
+	}
+
+	if err := storage.SetTaskHintLadders(taskID, hintladder.Encode(ladders)); err != nil {
+		log.Error("failed to save hint ladders", sl.Err(err))
+	}
+
+	experiments.RecordGeneration(variantID, userID, alias, true, log)
+
 	// Обновление статуса на "Done" при успехе
-	doneStatus := database.TaskStatus{Status: "Done", Result: processedCode}
+	doneStatus := database.TaskStatus{Status: "Done", Result: result.Code, Model: result.Model}
 	if err := storage.SetTaskStatus(alias, doneStatus); err != nil {
 		log.Error("failed to set done status in Redis", sl.Err(err))
 	}
 }
 
-// Returns noised code
-func ProcessCode(code string, noiseLevel int, logger *slog.Logger) (string, string, error) {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	model := os.Getenv("MODEL")
-	temperature := 0.7
-
-	client := openRouterAPI.NewClient(apiKey, model, temperature)
+// Result — сгенерированная задача с шумами
+type Result struct {
+	Code            string
+	Description     string
+	PromptVersionID int64
+	// Edits — правки, которыми код задачи отличается от исходного
+	Edits []noises_lib.Edit
+	// Model — модель, которая сгенерировала задачу
+	Model string
+	// Scrub — что было скрыто или отмечено в коде перед отправкой модели
+	Scrub *scrub.Result
+}
 
-	prompts, err := openRouterAPI.LoadSystemPrompts("./config/noises_gen_prompt.yaml")
+// ProcessCode генерирует задачу с шумами
+func ProcessCode(ctx context.Context, code string, noiseLevel int, language, locale string, opts llm.Options, logger *slog.Logger) (Result, error) {
+	// Секреты и подозрительный текст не должны попасть к модели
+	scrubbed, err := llm.ScrubCode(code)
 	if err != nil {
-		logger.Error("failed to load system prompts", sl.Err(err))
-		return "", "", fmt.Errorf("failed to load system prompts: %v", err)
+		logger.Warn("code refused before llm request", sl.Err(err))
+		return Result{}, err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("code scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
 	}
 
-	systemPrompt, exists := prompts["system_prompt"]
-	if !exists {
-		logger.Error("noises prompt not found in YAML file")
-		return "", "", fmt.Errorf("noises prompt not found")
+	prompt, err := prompts.RenderAt(prompts.NoisesGenerate, opts.PromptVersion, prompts.Vars{
+		"Code":       scrubbed.Code,
+		"NoiseLevel": noiseLevel,
+		"Language":   language,
+		"Locale":     locale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return Result{}, fmt.Errorf("failed to render prompt: %v", err)
 	}
 
-	response, err := client.SendChat(systemPrompt, "Уровень шума = "+strconv.Itoa(noiseLevel)+"/100\n"+code)
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.7,
+		Kind:            llm.KindNoisesGenerate,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), llm.NormalizeCode(scrubbed.Code), strconv.Itoa(noiseLevel), language, locale},
+		Schema:          responseSchema,
+	}, opts)
 	if err != nil {
 		logger.Error("failed to send request to OpenRouter", sl.Err(err))
-		return "", "", fmt.Errorf("failed to send request: %v", err)
+		return Result{}, fmt.Errorf("failed to send request: %v", err)
 	}
-	fmt.Println("Response from OpenRouter:", response)
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
 
 	var decodedLLMResponse LLMResponse
-	cleanedResponse := openRouterAPI.CleanLLMResponse(response)
-	err = json.Unmarshal([]byte(cleanedResponse), &decodedLLMResponse)
+	err = json.Unmarshal([]byte(response.Content), &decodedLLMResponse)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			logger.Error("request body is empty")
-			return "", "", fmt.Errorf("request body is empty")
+			return Result{}, fmt.Errorf("request body is empty")
 		} else {
 			logger.Error("failed to decode request body", sl.Err(err))
-			return "", "", fmt.Errorf("failed to decode request: %v", err)
+			return Result{}, fmt.Errorf("failed to decode request: %v", err)
 		}
 	}
 
-	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse))
+	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse), slog.String("model", response.Model))
+
+	// Метки в коде задачи заменяются исходными значениями
+	noisedCode := scrubbed.Restore(decodedLLMResponse.NoisedCode)
+	edits := noises_lib.Edits(code, noisedCode)
+	if len(edits) == 0 {
+		logger.Error("llm returned the code without edits")
+		return Result{}, fmt.Errorf("model returned the code without edits")
+	}
 
-	return decodedLLMResponse.NoisedCode, decodedLLMResponse.Description, nil
+	return Result{
+		Code:            noisedCode,
+		Edits:           edits,
+		Description:     decodedLLMResponse.Description,
+		PromptVersionID: prompt.VersionID,
+		Model:           response.Model,
+		Scrub:           scrubbed,
+	}, nil
 }
 
 func generateAlias(length int) string {
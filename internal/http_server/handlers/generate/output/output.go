@@ -0,0 +1,275 @@
+package output
+
+import (
+	"codular-backend/internal/config"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/sandbox"
+	database "codular-backend/internal/storage/database"
+	"codular-backend/internal/task_progress"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/textdiff"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// maxStderr — сколько stderr показывается в ошибке генерации
+const maxStderr = 1000
+
+type Request struct {
+	Code                string `json:"sourceCode" validate:"required"`
+	Stdin               string `json:"stdin,omitempty" validate:"max=65536"`
+	Normalization       string `json:"normalization,omitempty" validate:"omitempty,oneof=exact trailing collapse"`
+	ProgrammingLanguage string `json:"programmingLanguage" validate:"required"`
+	Locale              string `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	TaskAlias    string                     `json:"taskAlias"`
+}
+
+// descriptions — условие задачи на каждом языке интерфейса
+var descriptions = map[string]string{
+	"ru": "Что выведет программа? Введите вывод в точности",
+	"en": "What does the program print? Type the exact output",
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{
+		ResponseInfo: response_info.Error(msg),
+		TaskAlias:    "",
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *Response {
+	return &Response{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		TaskAlias:    "",
+	}
+}
+
+func getOKResponse(taskAlias string) *Response {
+	return &Response{
+		ResponseInfo: response_info.OK(),
+		TaskAlias:    taskAlias,
+	}
+}
+
+// New generates a predict-the-output task for the provided code and saves it to the database.
+// @Summary Generate a predict-the-output task
+// @Description Runs the provided source code with the given stdin in a local sandbox (no network, limited time, memory and output) and saves the output it prints as the expected answer; the LLM is not involved. The program is run twice and must print the same non-empty output both times and exit with code 0, otherwise the task status becomes Error with the reason. Normalization (exact, trailing or collapse) sets how whitespace is compared when grading; the server default is used if omitted. Returns the task alias; poll /task-status/{alias} until it is Done.
+// @Tags Output
+// @Accept json
+// @Produce json
+// @Param request body Request true "Source code, stdin, normalization, and programming language"
+// @Success 200 {object} output.Response "Successfully initiated output task generation"
+// @Success 200 {object} output.Response "Example response" Example({"responseInfo":{"status":"OK"},"taskAlias":"abc123"})
+// @Failure 400 {object} output.Response "Invalid request, invalid programming language, or language not supported by the sandbox"
+// @Failure 401 {object} output.Response "Unauthorized"
+// @Failure 500 {object} output.Response "Internal server error"
+// @Security Bearer
+// @Router /output/generate [post]
+func New(log *slog.Logger, storage *database.Storage, cfg *config.Config, box *sandbox.Sandbox) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.generate.output.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest Request
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		programmingLanguageId, err := storage.GetProgrammingLanguageIDByName(decodedRequest.ProgrammingLanguage)
+		if err != nil {
+			log.Error("invalid programming language: "+decodedRequest.ProgrammingLanguage, sl.Err(err))
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("invalid programming language: "+decodedRequest.ProgrammingLanguage))
+			return
+		}
+		if !box.Supports(decodedRequest.ProgrammingLanguage) {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("programming language is not supported by the sandbox: "+decodedRequest.ProgrammingLanguage))
+			return
+		}
+
+		normalization := decodedRequest.Normalization
+		if normalization == "" {
+			normalization = cfg.OutputTasks.Normalization
+		}
+		locale := decodedRequest.Locale
+		if locale == "" {
+			locale = prompts.DefaultLocale
+		}
+
+		// Генерация уникального алиаса
+		aliasExistsInDb := true
+		var alias string
+		for aliasExistsInDb {
+			alias = generateAlias(cfg.AliasLength)
+			aliasExistsInDb, err = storage.CheckAliasExist(alias)
+			if err != nil {
+				log.Error("failed to check alias "+alias+" existence in db", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to check alias existence in db"))
+				return
+			}
+		}
+
+		// Сохранение начального статуса "Processing" в Redis
+		if err := storage.SetTaskStatus(alias, task_progress.Initial(userID)); err != nil {
+			log.Error("failed to set initial status in Redis", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(alias))
+
+		// Асинхронный запуск в песочнице
+		task := task{
+			code:                  decodedRequest.Code,
+			stdin:                 decodedRequest.Stdin,
+			normalization:         normalization,
+			language:              decodedRequest.ProgrammingLanguage,
+			description:           descriptions[locale],
+			programmingLanguageId: programmingLanguageId,
+		}
+		go processTaskAsync(log, alias, task, userID, storage, box)
+
+		log.Info("task processing initiated", slog.String("task_alias", alias), slog.Int64("user_id", userID))
+	}
+}
+
+type task struct {
+	code, stdin, normalization, language, description string
+	programmingLanguageId                             int64
+}
+
+// processTaskAsync запускает код в песочнице и сохраняет задачу с полученным выводом
+func processTaskAsync(log *slog.Logger, alias string, t task, userID int64, storage *database.Storage, box *sandbox.Sandbox) {
+	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", userID))
+
+	setError := func(message string) {
+		errorStatus := database.TaskStatus{Status: task_progress.StatusError, Error: message}
+		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
+			log.Error("failed to set error status in Redis", sl.Err(err))
+		}
+	}
+
+	ctx, tracker := task_progress.Start(context.Background(), alias, userID, log)
+	expected, err := ExpectedOutput(ctx, box, t.language, t.code, t.stdin)
+	tracker.Stop()
+	if tracker.Cancelled() {
+		cancelledStatus := database.TaskStatus{Status: task_progress.StatusCancelled, UserID: userID}
+		if err := storage.SetTaskStatus(alias, cancelledStatus); err != nil {
+			log.Error("failed to set cancelled status in Redis", sl.Err(err))
+		}
+		return
+	}
+	if err != nil {
+		log.Info("program cannot be used for an output task", sl.Err(err))
+		setError(err.Error())
+		return
+	}
+
+	// Сохранение в PostgreSQL
+	_, _, err = storage.SaveOutputCodeWithAlias(t.code, t.stdin, expected, t.normalization, t.programmingLanguageId, userID, alias, t.description)
+	if err != nil {
+		setError(fmt.Sprintf("failed to save task: %v", err))
+		return
+	}
+
+	// Ожидаемый вывод в статус не попадает: статус доступен по одному алиасу
+	doneStatus := database.TaskStatus{Status: task_progress.StatusDone, Result: t.code}
+	if err := storage.SetTaskStatus(alias, doneStatus); err != nil {
+		log.Error("failed to set done status in Redis", sl.Err(err))
+	}
+}
+
+// ExpectedOutput запускает программу дважды и возвращает её вывод. Программа должна завершиться
+// с кодом 0 и оба раза вывести одно и то же непустое (после нормализации переводов строк) значение
+func ExpectedOutput(ctx context.Context, box *sandbox.Sandbox, language, code, stdin string) (string, error) {
+	var outputs [2]string
+	for i := range outputs {
+		result, err := box.Run(ctx, language, code, stdin)
+		if err != nil {
+			if stderr := strings.TrimSpace(result.Stderr); stderr != "" && (errors.Is(err, sandbox.ErrCompile) || errors.Is(err, sandbox.ErrExitCode)) {
+				return "", fmt.Errorf("%v (exit code %d): %s", err, result.ExitCode, truncate(stderr, maxStderr))
+			}
+			return "", err
+		}
+		outputs[i] = result.Stdout
+	}
+
+	if textdiff.Normalize(outputs[0], textdiff.Exact) != textdiff.Normalize(outputs[1], textdiff.Exact) {
+		return "", fmt.Errorf("program output differs between runs (randomness, time or addresses); it cannot be predicted")
+	}
+	if strings.TrimSpace(outputs[0]) == "" {
+		return "", fmt.Errorf("program prints nothing")
+	}
+	return outputs[0], nil
+}
+
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "…"
+}
+
+func generateAlias(length int) string {
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(b)[:length]
+}
@@ -0,0 +1,200 @@
+package parsons
+
+import (
+	"codular-backend/internal/config"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/prompts"
+	database "codular-backend/internal/storage/database"
+	"codular-backend/internal/task_progress"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/parsons"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type Request struct {
+	Code                string `json:"sourceCode" validate:"required"`
+	Distractors         int    `json:"distractors" validate:"gte=0,lte=5"`
+	ProgrammingLanguage string `json:"programmingLanguage" validate:"required"`
+	Locale              string `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	TaskAlias    string                     `json:"taskAlias"`
+}
+
+// descriptions — условие задачи на каждом языке интерфейса
+var descriptions = map[string]string{
+	"ru": "Расставьте строки кода в правильном порядке. Среди них могут быть лишние",
+	"en": "Put the lines of code in the correct order. Some of them may not belong to the solution",
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{
+		ResponseInfo: response_info.Error(msg),
+		TaskAlias:    "",
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *Response {
+	return &Response{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		TaskAlias:    "",
+	}
+}
+
+func getOKResponse(taskAlias string) *Response {
+	return &Response{
+		ResponseInfo: response_info.OK(),
+		TaskAlias:    taskAlias,
+	}
+}
+
+// New generates a Parsons problem for the provided code and saves it to the database.
+// @Summary Generate a Parsons problem
+// @Description Splits the provided source code into logical blocks (a line with its continuation lines; Python keeps its indentation, other languages have it stripped), optionally adds up to 5 distractor lines with a plausible mistake, shuffles the blocks and saves the task. No LLM is involved, so the task is ready immediately: its status is Done and codeToSolve of GET /task/{alias} is a JSON array of the shuffled blocks.
+// @Tags Parsons
+// @Accept json
+// @Produce json
+// @Param request body Request true "Source code, number of distractors, and programming language"
+// @Success 200 {object} parsons.Response "Successfully generated Parsons problem"
+// @Success 200 {object} parsons.Response "Example response" Example({"responseInfo":{"status":"OK"},"taskAlias":"abc123"})
+// @Failure 400 {object} parsons.Response "Invalid request, invalid programming language, or code with fewer than two lines"
+// @Failure 401 {object} parsons.Response "Unauthorized"
+// @Failure 500 {object} parsons.Response "Internal server error"
+// @Security Bearer
+// @Router /parsons/generate [post]
+func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.generate.parsons.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest Request
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		log.Info("request body was decoded", slog.Any("decodedRequest", decodedRequest))
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		programmingLanguageId, err := storage.GetProgrammingLanguageIDByName(decodedRequest.ProgrammingLanguage)
+		if err != nil {
+			log.Error("invalid programming language: "+decodedRequest.ProgrammingLanguage, sl.Err(err))
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("invalid programming language: "+decodedRequest.ProgrammingLanguage))
+			return
+		}
+
+		task, err := parsons.Build(decodedRequest.Code, decodedRequest.ProgrammingLanguage, decodedRequest.Distractors)
+		if err != nil {
+			log.Error("failed to build parsons problem", sl.Err(err))
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse(err.Error()))
+			return
+		}
+		blocks, err := json.Marshal(task.Blocks)
+		if err != nil {
+			log.Error("failed to encode blocks", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		answers := make([]string, len(task.Order))
+		for i, index := range task.Order {
+			answers[i] = strconv.Itoa(index)
+		}
+
+		locale := decodedRequest.Locale
+		if locale == "" {
+			locale = prompts.DefaultLocale
+		}
+
+		// Генерация уникального алиаса
+		aliasExistsInDb := true
+		var alias string
+		for aliasExistsInDb {
+			alias = generateAlias(cfg.AliasLength)
+			aliasExistsInDb, err = storage.CheckAliasExist(alias)
+			if err != nil {
+				log.Error("failed to check alias "+alias+" existence in db", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to check alias existence in db"))
+				return
+			}
+		}
+
+		// Сохранение в PostgreSQL
+		_, _, err = storage.SaveParsonsCodeWithAlias(string(blocks), decodedRequest.Code, answers, programmingLanguageId, userID, alias, descriptions[locale])
+		if err != nil {
+			log.Error("failed to save task", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("failed to save task"))
+			return
+		}
+
+		// Задача готова сразу; статус "Done" — для клиентов, которые ждут его, как для других типов
+		doneStatus := database.TaskStatus{Status: task_progress.StatusDone, Result: string(blocks), UserID: userID}
+		if err := storage.SetTaskStatus(alias, doneStatus); err != nil {
+			log.Error("failed to set done status in Redis", sl.Err(err))
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(alias))
+
+		log.Info("parsons problem generated", slog.String("task_alias", alias), slog.Int("blocks", len(task.Blocks)), slog.Int64("user_id", userID))
+	}
+}
+
+func generateAlias(length int) string {
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(b)[:length]
+}
@@ -0,0 +1,341 @@
+package quiz
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/experiments"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	database "codular-backend/internal/storage/database"
+	"codular-backend/internal/task_progress"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/quiz"
+	"codular-backend/lib/scrub"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type Request struct {
+	Code                string `json:"sourceCode" validate:"required"`
+	QuestionsCount      int    `json:"questionsCount" validate:"required,gte=1,lte=10"`
+	ProgrammingLanguage string `json:"programmingLanguage" validate:"required"`
+	Locale              string `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	TaskAlias    string                     `json:"taskAlias"`
+}
+
+type LLMResponse struct {
+	Description string          `json:"description"`
+	Questions   []quiz.Question `json:"questions"`
+}
+
+// responseSchema — схема ответа LLM при генерации задачи
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"description": llmjson.String(),
+	"questions": llmjson.Array(llmjson.Object(map[string]*llmjson.Schema{
+		"question":    llmjson.String(),
+		"options":     llmjson.Array(llmjson.String()),
+		"correct":     llmjson.Integer(0, quiz.MaxOptions-1),
+		"explanation": llmjson.String(),
+	})),
+})
+
+func getErrorResponse(msg string) *Response {
+	return &Response{
+		ResponseInfo: response_info.Error(msg),
+		TaskAlias:    "",
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *Response {
+	return &Response{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		TaskAlias:    "",
+	}
+}
+
+func getOKResponse(taskAlias string) *Response {
+	return &Response{
+		ResponseInfo: response_info.OK(),
+		TaskAlias:    taskAlias,
+	}
+}
+
+// New generates a multiple-choice quiz about the provided code and saves it to the database.
+// @Summary Generate and save a code quiz
+// @Description Asks the LLM for a description of the provided source code and the given number of multiple-choice questions about it (what a function returns, complexity, the purpose of a variable), each with one correct option and plausible distractors. The task is saved asynchronously. Questions with fewer than 2 or more than 6 options, duplicate options or an invalid correct option are dropped, so the quiz may contain fewer questions than requested. The questions are returned by /task/{alias} in random order for every attempt and are graded without an LLM. Returns the task alias for retrieving the task code and description.
+// @Tags Quiz
+// @Accept json
+// @Produce json
+// @Param request body Request true "Source code, number of questions, and programming language"
+// @Success 200 {object} quiz.Response "Successfully initiated quiz generation"
+// @Success 200 {object} quiz.Response "Example response" Example({"responseInfo":{"status":"OK"},"taskAlias":"abc123"})
+// @Failure 400 {object} quiz.Response "Invalid request, empty body, or invalid programming language"
+// @Failure 401 {object} quiz.Response "Unauthorized"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
+// @Failure 500 {object} quiz.Response "Internal server error"
+// @Security Bearer
+// @Router /quiz/generate [post]
+func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.generate.quiz.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest Request
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		log.Info("request body was decoded", slog.Any("decodedRequest", decodedRequest))
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		programmingLanguageId, err := storage.GetProgrammingLanguageIDByName(decodedRequest.ProgrammingLanguage)
+		if err != nil {
+			log.Error("invalid programming language: "+decodedRequest.ProgrammingLanguage, sl.Err(err))
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("invalid programming language: "+decodedRequest.ProgrammingLanguage))
+			return
+		}
+
+		// Генерация уникального алиаса
+		aliasExistsInDb := true
+		var alias string
+		for aliasExistsInDb {
+			alias = generateAlias(cfg.AliasLength)
+			aliasExistsInDb, err = storage.CheckAliasExist(alias)
+			if err != nil {
+				log.Error("failed to check alias "+alias+" existence in db", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to check alias existence in db"))
+				return
+			}
+		}
+
+		// Сохранение начального статуса "Processing" в Redis
+		initialStatus := task_progress.Initial(userID)
+		if err := storage.SetTaskStatus(alias, initialStatus); err != nil {
+			log.Error("failed to set initial status in Redis", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		// Отправка "OK" клиенту
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(alias))
+
+		locale := decodedRequest.Locale
+		if locale == "" {
+			locale = prompts.DefaultLocale
+		}
+
+		// Асинхронная обработка
+		go processTaskAsync(log, alias, decodedRequest.Code, decodedRequest.QuestionsCount, decodedRequest.ProgrammingLanguage, locale, programmingLanguageId, userID, storage)
+
+		log.Info("task processing initiated", slog.String("task_alias", alias), slog.Int64("user_id", userID))
+	}
+}
+
+// processTaskAsync асинхронно обрабатывает задачу и сохраняет результат
+func processTaskAsync(log *slog.Logger, alias, code string, questionsCount int, language, locale string, programmingLanguageId, userID int64, storage *database.Storage) {
+	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", userID))
+
+	opts := llm.Options{UserID: userID, Endpoint: llm.EndpointQuizGenerate, TaskAlias: alias}
+	variantID := experiments.Apply(prompts.QuizGenerate, userID, &opts, log)
+
+	ctx, tracker := task_progress.Start(context.Background(), alias, userID, log)
+	opts.OnProgress = tracker.Update
+
+	result, err := ProcessCode(ctx, code, questionsCount, language, locale, opts, log)
+	tracker.Stop()
+	if tracker.Cancelled() {
+		cancelledStatus := database.TaskStatus{Status: task_progress.StatusCancelled, UserID: userID}
+		if err := storage.SetTaskStatus(alias, cancelledStatus); err != nil {
+			log.Error("failed to set cancelled status in Redis", sl.Err(err))
+		}
+		return
+	}
+	if err != nil {
+		experiments.RecordGeneration(variantID, userID, alias, false, log)
+		// Обновление статуса на "Error" в случае ошибки
+		errorStatus := database.TaskStatus{Status: "Error", Error: err.Error()}
+		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
+			log.Error("failed to set error status in Redis", sl.Err(err))
+		}
+		return
+	}
+
+	// Сохранение в PostgreSQL
+	taskID, _, err := storage.SaveQuizCodeWithAlias(code, code, quiz.Encode(result.Questions), programmingLanguageId, userID, alias, result.Description, result.PromptVersionID)
+	if err != nil {
+		// Обновление статуса на "Error" в случае ошибки сохранения
+		errorStatus := database.TaskStatus{Status: "Error", Error: fmt.Sprintf("failed to save task: %v", err)}
+		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
+			log.Error("failed to set error status in Redis", sl.Err(err))
+		}
+		return
+	}
+
+	if report := result.Scrub.Report(); report != nil {
+		if err := storage.SetTaskScrubReport(taskID, report); err != nil {
+			log.Error("failed to save scrub report", sl.Err(err))
+		}
+	}
+
+	experiments.RecordGeneration(variantID, userID, alias, true, log)
+
+	// Обновление статуса на "Done" при успехе
+	doneStatus := database.TaskStatus{Status: "Done", Result: code, Model: result.Model}
+	if err := storage.SetTaskStatus(alias, doneStatus); err != nil {
+		log.Error("failed to set done status in Redis", sl.Err(err))
+	}
+}
+
+// Result — сгенерированные вопросы по коду
+type Result struct {
+	Questions       []quiz.Question
+	Description     string
+	PromptVersionID int64
+	// Model — модель, которая сгенерировала задачу
+	Model string
+	// Scrub — что было скрыто или отмечено в коде перед отправкой модели
+	Scrub *scrub.Result
+}
+
+// ProcessCode генерирует вопросы по коду
+func ProcessCode(ctx context.Context, code string, questionsCount int, language, locale string, opts llm.Options, logger *slog.Logger) (Result, error) {
+	// Секреты и подозрительный текст не должны попасть к модели
+	scrubbed, err := llm.ScrubCode(code)
+	if err != nil {
+		logger.Warn("code refused before llm request", sl.Err(err))
+		return Result{}, err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("code scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
+	}
+
+	prompt, err := prompts.RenderAt(prompts.QuizGenerate, opts.PromptVersion, prompts.Vars{
+		"Code":           scrubbed.Code,
+		"QuestionsCount": questionsCount,
+		"Language":       language,
+		"Locale":         locale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return Result{}, fmt.Errorf("failed to render prompt: %v", err)
+	}
+
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.7,
+		Kind:            llm.KindQuizGenerate,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), llm.NormalizeCode(scrubbed.Code), strconv.Itoa(questionsCount), language, locale},
+		Schema:          responseSchema,
+	}, opts)
+	if err != nil {
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return Result{}, fmt.Errorf("failed to send request: %v", err)
+	}
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
+
+	var decodedLLMResponse LLMResponse
+	err = json.Unmarshal([]byte(response.Content), &decodedLLMResponse)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			logger.Error("request body is empty")
+			return Result{}, fmt.Errorf("request body is empty")
+		} else {
+			logger.Error("failed to decode request body", sl.Err(err))
+			return Result{}, fmt.Errorf("failed to decode request: %v", err)
+		}
+	}
+
+	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse), slog.String("model", response.Model))
+
+	questions := quiz.Prepare(decodedLLMResponse.Questions)
+	if len(questions) == 0 {
+		logger.Error("llm response contains no valid questions", slog.Int("proposed", len(decodedLLMResponse.Questions)))
+		return Result{}, fmt.Errorf("model did not generate any valid questions")
+	}
+	if len(questions) < len(decodedLLMResponse.Questions) {
+		logger.Warn("some proposed questions were dropped", slog.Int("proposed", len(decodedLLMResponse.Questions)), slog.Int("kept", len(questions)))
+	}
+
+	// Вопросы могут цитировать код, который видела модель: метки заменяются исходными значениями
+	for i := range questions {
+		questions[i].Text = scrubbed.Restore(questions[i].Text)
+		questions[i].Explanation = scrubbed.Restore(questions[i].Explanation)
+		for j := range questions[i].Options {
+			questions[i].Options[j] = scrubbed.Restore(questions[i].Options[j])
+		}
+	}
+
+	return Result{
+		Questions:       questions,
+		Description:     decodedLLMResponse.Description,
+		PromptVersionID: prompt.VersionID,
+		Model:           response.Model,
+		Scrub:           scrubbed,
+	}, nil
+}
+
+func generateAlias(length int) string {
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(b)[:length]
+}
@@ -2,11 +2,20 @@ package skips
 
 import (
 	"codular-backend/internal/config"
+	"codular-backend/internal/experiments"
+	"codular-backend/internal/hint_ladder"
 	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
 	database "codular-backend/internal/storage/database"
-	openRouterAPI "codular-backend/lib/api/openrouter"
+	"codular-backend/internal/task_progress"
 	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/hintladder"
+	"codular-backend/lib/llmjson"
 	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/scrub"
+	"codular-backend/lib/skipgen"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
@@ -18,7 +27,6 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -26,6 +34,9 @@ type Request struct {
 	Code                string `json:"sourceCode" validate:"required"`
 	SkipsNumber         int    `json:"skipsNumber" validate:"required,gte=0"`
 	ProgrammingLanguage string `json:"programmingLanguage" validate:"required"`
+	Locale              string `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
+	// Generator — кто расставляет пропуски: модель (llm, по умолчанию) или разбор синтаксиса (ast)
+	Generator string `json:"generator,omitempty" validate:"omitempty,oneof=llm ast"`
 }
 
 type Response struct {
@@ -39,6 +50,28 @@ type LLMResponse struct {
 	Answers     []string `json:"answers"`
 }
 
+// responseSchema — схема ответа LLM при генерации задачи
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"description": llmjson.String(),
+	"skipsCode":   llmjson.String(),
+	"answers":     llmjson.Array(llmjson.String()),
+})
+
+type DescribeLLMResponse struct {
+	Description string `json:"description"`
+}
+
+// describeSchema — схема ответа LLM, когда пропуски расставлены без модели
+var describeSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"description": llmjson.String(),
+})
+
+// Генераторы пропусков
+const (
+	GeneratorLLM = "llm"
+	GeneratorAST = "ast"
+)
+
 func getErrorResponse(msg string) *Response {
 	return &Response{
 		ResponseInfo: response_info.Error(msg),
@@ -62,15 +95,16 @@ func getOKResponse(taskAlias string) *Response {
 
 // New generates skips for the provided code and saves it to the database.
 // @Summary Generate and save skips code
-// @Description Processes the provided source code with a specified number of skips, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. Returns the task alias for retrieving the task code and description.
+// @Description Processes the provided source code with a specified number of skips, generates a unique alias, saves the task with its description to the database, and initiates asynchronous processing. Returns the task alias for retrieving the task code and description. The generator field selects who places the skips: "llm" (default) lets the model choose them; "ast" parses the code (Python, Java or C++) into syntax trees and deterministically blanks exactly skipsNumber fragments — conditions, loop bounds, call arguments and return expressions — so the model only writes the description. A ladder of hints (nudge, pointer, near_answer) is generated for every skip and revealed through POST /task/{alias}/hint. With "ast" the request fails with 400 if the language is not supported or the code has fewer suitable fragments than requested.
 // @Tags Skips
 // @Accept json
 // @Produce json
 // @Param request body Request true "Source code, number of skips, and programming language"
 // @Success 200 {object} skips.Response "Successfully initiated skips generation"
 // @Success 200 {object} skips.Response "Example response" Example({"responseInfo":{"status":"OK"},"taskAlias":"abc123"})
-// @Failure 400 {object} skips.Response "Invalid request, empty body, or invalid programming language"
+// @Failure 400 {object} skips.Response "Invalid request, empty body, invalid programming language, or too few fragments for the ast generator"
 // @Failure 401 {object} skips.Response "Unauthorized"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
 // @Failure 500 {object} skips.Response "Internal server error"
 // @Security Bearer
 // @Router /skips/generate [post]
@@ -128,6 +162,20 @@ func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.H
 			return
 		}
 
+		generator := decodedRequest.Generator
+		if generator == "" {
+			generator = GeneratorLLM
+		}
+		// Разбор кода быстрый, поэтому неподходящий код отклоняется сразу, а не статусом Error
+		if generator == GeneratorAST {
+			if _, err := skipgen.Generate(decodedRequest.Code, decodedRequest.ProgrammingLanguage, decodedRequest.SkipsNumber, Placeholder); err != nil {
+				log.Info("code rejected by syntax generator", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse(err.Error()))
+				return
+			}
+		}
+
 		// Генерация уникального алиаса
 		aliasExistsInDb := true
 		var alias string
@@ -143,7 +191,7 @@ func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.H
 		}
 
 		// Сохранение начального статуса "Processing" в Redis
-		initialStatus := database.TaskStatus{Status: "Processing"}
+		initialStatus := task_progress.Initial(userID)
 		if err := storage.SetTaskStatus(alias, initialStatus); err != nil {
 			log.Error("failed to set initial status in Redis", sl.Err(err))
 			writer.WriteHeader(http.StatusInternalServerError)
@@ -155,19 +203,43 @@ func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.H
 		writer.WriteHeader(http.StatusOK)
 		render.JSON(writer, request, getOKResponse(alias))
 
+		locale := decodedRequest.Locale
+		if locale == "" {
+			locale = prompts.DefaultLocale
+		}
+
 		// Асинхронная обработка
-		go processTaskAsync(log, alias, decodedRequest.Code, decodedRequest.SkipsNumber, programmingLanguageId, userID, storage)
+		go processTaskAsync(log, alias, decodedRequest.Code, decodedRequest.SkipsNumber, decodedRequest.ProgrammingLanguage, locale, generator, programmingLanguageId, userID, storage)
 
 		log.Info("task processing initiated", slog.String("task_alias", alias), slog.Int64("user_id", userID))
 	}
 }
 
 // processTaskAsync асинхронно обрабатывает задачу и сохраняет результат
-func processTaskAsync(log *slog.Logger, alias, code string, skipsNumber int, programmingLanguageId, userID int64, storage *database.Storage) {
-	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", userID))
+func processTaskAsync(log *slog.Logger, alias, code string, skipsNumber int, language, locale, generator string, programmingLanguageId, userID int64, storage *database.Storage) {
+	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", userID), slog.String("generator", generator))
 
-	processedCode, answers, description, err := ProcessCode(code, skipsNumber, log)
+	opts := llm.Options{UserID: userID, Endpoint: llm.EndpointSkipsGenerate, TaskAlias: alias}
+	variantID := experiments.Apply(Flow(generator), userID, &opts, log)
+
+	ctx, tracker := task_progress.Start(context.Background(), alias, userID, log)
+	opts.OnProgress = tracker.Update
+
+	result, err := Generate(ctx, generator, code, skipsNumber, language, locale, opts, log)
+	var ladders []hintladder.Ladder
+	if err == nil {
+		ladders = hint_ladder.ForSkips(ctx, result.Code, result.Answers, Placeholder, locale, opts, log)
+	}
+	tracker.Stop()
+	if tracker.Cancelled() {
+		cancelledStatus := database.TaskStatus{Status: task_progress.StatusCancelled, UserID: userID}
+		if err := storage.SetTaskStatus(alias, cancelledStatus); err != nil {
+			log.Error("failed to set cancelled status in Redis", sl.Err(err))
+		}
+		return
+	}
 	if err != nil {
+		experiments.RecordGeneration(variantID, userID, alias, false, log)
 		// Обновление статуса на "Error" в случае ошибки
 		errorStatus := database.TaskStatus{Status: "Error", Error: err.Error()}
 		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
@@ -177,7 +249,7 @@ func processTaskAsync(log *slog.Logger, alias, code string, skipsNumber int, pro
 	}
 
 	// Сохранение в PostgreSQL
-	_, _, err = storage.SaveSkipsCodeWithAlias(processedCode, code, answers, programmingLanguageId, userID, alias, description)
+	taskID, _, err := storage.SaveSkipsCodeWithAlias(result.Code, code, result.Answers, programmingLanguageId, userID, alias, result.Description, result.PromptVersionID)
 	if err != nil {
 		// Обновление статуса на "Error" в случае ошибки сохранения
 		errorStatus := database.TaskStatus{Status: "Error", Error: fmt.Sprintf("failed to save task: %v", err)}
@@ -187,56 +259,184 @@ func processTaskAsync(log *slog.Logger, alias, code string, skipsNumber int, pro
 		return
 	}
 
+	if report := result.Scrub.Report(); report != nil {
+		if err := storage.SetTaskScrubReport(taskID, report); err != nil {
+			log.Error("failed to save scrub report", sl.Err(err))
+		}
+	}
+
+	if err := storage.SetTaskHintLadders(taskID, hintladder.Encode(ladders)); err != nil {
+		log.Error("failed to save hint ladders", sl.Err(err))
+	}
+
+	experiments.RecordGeneration(variantID, userID, alias, true, log)
+
 	// Обновление статуса на "Done" при успехе
-	doneStatus := database.TaskStatus{Status: "Done", Result: processedCode}
+	doneStatus := database.TaskStatus{Status: "Done", Result: result.Code, Model: result.Model}
 	if err := storage.SetTaskStatus(alias, doneStatus); err != nil {
 		log.Error("failed to set done status in Redis", sl.Err(err))
 	}
 }
 
-func ProcessCode(code string, number int, logger *slog.Logger) (string, []string, string, error) {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	model := os.Getenv("MODEL")
-	temperature := 0.7
+// Placeholder — метка пропуска в коде задачи
+const Placeholder = "🔑"
+
+// Result — сгенерированная задача с пропусками
+type Result struct {
+	Code            string
+	Answers         []string
+	Description     string
+	PromptVersionID int64
+	// Model — модель, которая сгенерировала задачу
+	Model string
+	// Scrub — что было скрыто или отмечено в коде перед отправкой модели
+	Scrub *scrub.Result
+}
 
-	client := openRouterAPI.NewClient(apiKey, model, temperature)
+// Flow возвращает промпт генератора: для него подбирается вариант эксперимента
+func Flow(generator string) string {
+	if generator == GeneratorAST {
+		return prompts.SkipsDescribe
+	}
+	return prompts.SkipsGenerate
+}
+
+// Generate генерирует задачу с пропусками выбранным генератором
+func Generate(ctx context.Context, generator, code string, number int, language, locale string, opts llm.Options, logger *slog.Logger) (Result, error) {
+	if generator == GeneratorAST {
+		return ProcessCodeAST(ctx, code, number, language, locale, opts, logger)
+	}
+	return ProcessCode(ctx, code, number, language, locale, opts, logger)
+}
 
-	prompts, err := openRouterAPI.LoadSystemPrompts("./config/system_prompts.yaml")
+// ProcessCode генерирует задачу с пропусками
+func ProcessCode(ctx context.Context, code string, number int, language, locale string, opts llm.Options, logger *slog.Logger) (Result, error) {
+	// Секреты и подозрительный текст не должны попасть к модели
+	scrubbed, err := llm.ScrubCode(code)
 	if err != nil {
-		logger.Error("failed to load system prompts", sl.Err(err))
-		return "", []string{}, "", fmt.Errorf("failed to load system prompts: %v", err)
+		logger.Warn("code refused before llm request", sl.Err(err))
+		return Result{}, err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("code scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
 	}
 
-	systemPrompt, exists := prompts["system_prompt"]
-	if !exists {
-		logger.Error("system prompt not found in YAML file")
-		return "", []string{}, "", fmt.Errorf("system prompt not found")
+	prompt, err := prompts.RenderAt(prompts.SkipsGenerate, opts.PromptVersion, prompts.Vars{
+		"Code":       scrubbed.Code,
+		"SkipsCount": number,
+		"Language":   language,
+		"Locale":     locale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return Result{}, fmt.Errorf("failed to render prompt: %v", err)
 	}
 
-	response, err := client.SendChat(systemPrompt, "Число пропусков = "+strconv.Itoa(number)+"\n"+code)
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.7,
+		Kind:            llm.KindSkipsGenerate,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), llm.NormalizeCode(scrubbed.Code), strconv.Itoa(number), language, locale},
+		Schema:          responseSchema,
+	}, opts)
 	if err != nil {
 		logger.Error("failed to send request to OpenRouter", sl.Err(err))
-		return "", []string{}, "", fmt.Errorf("failed to send request: %v", err)
+		return Result{}, fmt.Errorf("failed to send request: %v", err)
 	}
-	fmt.Println("Response from OpenRouter:", response)
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
 
 	var decodedLLMResponse LLMResponse
-	cleanedResponse := openRouterAPI.CleanLLMResponse(response)
-	fmt.Println("Cleaned response from OpenRouter:", cleanedResponse)
-	err = json.Unmarshal([]byte(cleanedResponse), &decodedLLMResponse)
+	err = json.Unmarshal([]byte(response.Content), &decodedLLMResponse)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			logger.Error("request body is empty")
-			return "", []string{}, "", fmt.Errorf("request body is empty")
+			return Result{}, fmt.Errorf("request body is empty")
 		} else {
 			logger.Error("failed to decode request body", sl.Err(err))
-			return "", []string{}, "", fmt.Errorf("failed to decode request: %v", err)
+			return Result{}, fmt.Errorf("failed to decode request: %v", err)
 		}
 	}
 
-	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse))
+	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse), slog.String("model", response.Model))
+
+	// Метки в коде задачи и ответах заменяются исходными значениями
+	answers := make([]string, len(decodedLLMResponse.Answers))
+	for i, answer := range decodedLLMResponse.Answers {
+		answers[i] = scrubbed.Restore(answer)
+	}
+
+	return Result{
+		Code:            scrubbed.Restore(decodedLLMResponse.SkipsCode),
+		Answers:         answers,
+		Description:     decodedLLMResponse.Description,
+		PromptVersionID: prompt.VersionID,
+		Model:           response.Model,
+		Scrub:           scrubbed,
+	}, nil
+}
+
+// ProcessCodeAST расставляет пропуски по синтаксическому дереву кода, а модель только описывает код
+func ProcessCodeAST(ctx context.Context, code string, number int, language, locale string, opts llm.Options, logger *slog.Logger) (Result, error) {
+	task, err := skipgen.Generate(code, language, number, Placeholder)
+	if err != nil {
+		logger.Error("failed to generate skips from syntax tree", sl.Err(err))
+		return Result{}, err
+	}
+
+	// Секреты и подозрительный текст не должны попасть к модели
+	scrubbed, err := llm.ScrubCode(code)
+	if err != nil {
+		logger.Warn("code refused before llm request", sl.Err(err))
+		return Result{}, err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("code scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
+	}
+
+	prompt, err := prompts.RenderAt(prompts.SkipsDescribe, opts.PromptVersion, prompts.Vars{
+		"Code":     scrubbed.Code,
+		"Language": language,
+		"Locale":   locale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return Result{}, fmt.Errorf("failed to render prompt: %v", err)
+	}
+
+	// Описание не зависит от числа пропусков, поэтому его число не входит в ключ кэша
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.3,
+		Kind:            llm.KindSkipsDescribe,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), llm.NormalizeCode(scrubbed.Code), language, locale},
+		Schema:          describeSchema,
+	}, opts)
+	if err != nil {
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return Result{}, fmt.Errorf("failed to send request: %v", err)
+	}
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
+
+	var decodedLLMResponse DescribeLLMResponse
+	if err := json.Unmarshal([]byte(response.Content), &decodedLLMResponse); err != nil {
+		logger.Error("failed to decode llm response", sl.Err(err))
+		return Result{}, fmt.Errorf("failed to decode llm response: %v", err)
+	}
+
+	logger.Info("skips generated from syntax tree", slog.Int("skips", len(task.Answers)), slog.Any("kinds", task.Kinds), slog.String("model", response.Model))
 
-	return decodedLLMResponse.SkipsCode, decodedLLMResponse.Answers, decodedLLMResponse.Description, nil
+	return Result{
+		Code:            task.Code,
+		Answers:         task.Answers,
+		Description:     decodedLLMResponse.Description,
+		PromptVersionID: prompt.VersionID,
+		Model:           response.Model,
+		Scrub:           scrubbed,
+	}, nil
 }
 
 func generateAlias(length int) string {
@@ -0,0 +1,267 @@
+package translate
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/http_server/handlers/generate/output"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/sandbox"
+	database "codular-backend/internal/storage/database"
+	"codular-backend/internal/task_progress"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	translate_lib "codular-backend/lib/translate"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type Request struct {
+	Code                string `json:"sourceCode" validate:"required"`
+	ProgrammingLanguage string `json:"programmingLanguage" validate:"required"`
+	TargetLanguage      string `json:"targetLanguage" validate:"required,nefield=ProgrammingLanguage"`
+	// TestInputs — входные данные, на которых сравниваются программы; если не заданы, программа запускается с пустым вводом
+	TestInputs []string `json:"testInputs,omitempty" validate:"max=10,dive,max=65536"`
+	Locale     string   `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	TaskAlias    string                     `json:"taskAlias"`
+}
+
+// descriptions — условие задачи на каждом языке интерфейса; подставляются исходный и целевой языки
+var descriptions = map[string]string{
+	"ru": "Перепишите код с %s на %s",
+	"en": "Translate the code from %s to %s",
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{
+		ResponseInfo: response_info.Error(msg),
+		TaskAlias:    "",
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *Response {
+	return &Response{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		TaskAlias:    "",
+	}
+}
+
+func getOKResponse(taskAlias string) *Response {
+	return &Response{
+		ResponseInfo: response_info.OK(),
+		TaskAlias:    taskAlias,
+	}
+}
+
+// New creates a code translation task and saves it to the database.
+// @Summary Generate a translate task
+// @Description Creates a task where the student receives the provided code and must write the equivalent program in the target language (Java, Python or C++). If the sandbox supports the source language, the code is run on each test input (or once with empty stdin when no inputs are given) and the outputs are saved as tests; submissions are then graded by running them on the same inputs. A program that cannot be run (e.g. a fragment without an entry point) is saved without tests, unless test inputs were given explicitly, and its submissions are graded by an LLM equivalence check. The source and target languages are stored on the task. Returns the task alias; poll /task-status/{alias} until it is Done.
+// @Tags Translate
+// @Accept json
+// @Produce json
+// @Param request body Request true "Source code, source and target programming languages, and optional test inputs"
+// @Success 200 {object} translate.Response "Successfully initiated translate task generation"
+// @Success 200 {object} translate.Response "Example response" Example({"responseInfo":{"status":"OK"},"taskAlias":"abc123"})
+// @Failure 400 {object} translate.Response "Invalid request, invalid programming language, the same source and target language, or test inputs for a language not supported by the sandbox"
+// @Failure 401 {object} translate.Response "Unauthorized"
+// @Failure 500 {object} translate.Response "Internal server error"
+// @Security Bearer
+// @Router /translate/generate [post]
+func New(log *slog.Logger, storage *database.Storage, cfg *config.Config, box *sandbox.Sandbox) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.generate.translate.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest Request
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		programmingLanguageId, err := storage.GetProgrammingLanguageIDByName(decodedRequest.ProgrammingLanguage)
+		if err != nil {
+			log.Error("invalid programming language: "+decodedRequest.ProgrammingLanguage, sl.Err(err))
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("invalid programming language: "+decodedRequest.ProgrammingLanguage))
+			return
+		}
+		targetLanguageId, err := storage.GetProgrammingLanguageIDByName(decodedRequest.TargetLanguage)
+		if err != nil {
+			log.Error("invalid target language: "+decodedRequest.TargetLanguage, sl.Err(err))
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("invalid target language: "+decodedRequest.TargetLanguage))
+			return
+		}
+		if len(decodedRequest.TestInputs) > 0 && !box.Supports(decodedRequest.ProgrammingLanguage) {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("test inputs require a programming language supported by the sandbox: "+decodedRequest.ProgrammingLanguage))
+			return
+		}
+
+		locale := decodedRequest.Locale
+		if locale == "" {
+			locale = prompts.DefaultLocale
+		}
+
+		// Генерация уникального алиаса
+		aliasExistsInDb := true
+		var alias string
+		for aliasExistsInDb {
+			alias = generateAlias(cfg.AliasLength)
+			aliasExistsInDb, err = storage.CheckAliasExist(alias)
+			if err != nil {
+				log.Error("failed to check alias "+alias+" existence in db", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("failed to check alias existence in db"))
+				return
+			}
+		}
+
+		// Сохранение начального статуса "Processing" в Redis
+		if err := storage.SetTaskStatus(alias, task_progress.Initial(userID)); err != nil {
+			log.Error("failed to set initial status in Redis", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(alias))
+
+		// Асинхронный запуск в песочнице
+		task := task{
+			code:             decodedRequest.Code,
+			inputs:           decodedRequest.TestInputs,
+			language:         decodedRequest.ProgrammingLanguage,
+			description:      fmt.Sprintf(descriptions[locale], decodedRequest.ProgrammingLanguage, decodedRequest.TargetLanguage),
+			sourceLanguageId: programmingLanguageId,
+			targetLanguageId: targetLanguageId,
+		}
+		go processTaskAsync(log, alias, task, userID, storage, box)
+
+		log.Info("task processing initiated", slog.String("task_alias", alias), slog.Int64("user_id", userID))
+	}
+}
+
+type task struct {
+	code, language, description        string
+	inputs                             []string
+	sourceLanguageId, targetLanguageId int64
+}
+
+// processTaskAsync запускает исходную программу на тестовых входных данных и сохраняет задачу с тестами
+func processTaskAsync(log *slog.Logger, alias string, t task, userID int64, storage *database.Storage, box *sandbox.Sandbox) {
+	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", userID))
+
+	setError := func(message string) {
+		errorStatus := database.TaskStatus{Status: task_progress.StatusError, Error: message}
+		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
+			log.Error("failed to set error status in Redis", sl.Err(err))
+		}
+	}
+
+	ctx, tracker := task_progress.Start(context.Background(), alias, userID, log)
+	tests, err := Tests(ctx, box, t.language, t.code, t.inputs)
+	tracker.Stop()
+	if tracker.Cancelled() {
+		cancelledStatus := database.TaskStatus{Status: task_progress.StatusCancelled, UserID: userID}
+		if err := storage.SetTaskStatus(alias, cancelledStatus); err != nil {
+			log.Error("failed to set cancelled status in Redis", sl.Err(err))
+		}
+		return
+	}
+	if err != nil {
+		// Входные данные заданы явно — автор рассчитывает на проверку запуском
+		if len(t.inputs) > 0 {
+			log.Info("program cannot be run on test inputs", sl.Err(err))
+			setError(err.Error())
+			return
+		}
+		log.Info("program cannot be run, submissions will be checked by llm", sl.Err(err))
+		tests = nil
+	}
+
+	// Сохранение в PostgreSQL
+	_, _, err = storage.SaveTranslateCodeWithAlias(t.code, translate_lib.Encode(tests), t.sourceLanguageId, t.targetLanguageId, userID, alias, t.description)
+	if err != nil {
+		setError(fmt.Sprintf("failed to save task: %v", err))
+		return
+	}
+
+	doneStatus := database.TaskStatus{Status: task_progress.StatusDone, Result: t.code}
+	if err := storage.SetTaskStatus(alias, doneStatus); err != nil {
+		log.Error("failed to set done status in Redis", sl.Err(err))
+	}
+}
+
+// Tests запускает программу на каждом входе (или один раз с пустым вводом) и возвращает тесты.
+// Вывод на каждом входе должен быть воспроизводимым и непустым, см. output.ExpectedOutput
+func Tests(ctx context.Context, box *sandbox.Sandbox, language, code string, inputs []string) ([]translate_lib.Test, error) {
+	if len(inputs) == 0 {
+		inputs = []string{""}
+	}
+	tests := make([]translate_lib.Test, 0, len(inputs))
+	for i, stdin := range inputs {
+		expected, err := output.ExpectedOutput(ctx, box, language, code, stdin)
+		if err != nil {
+			return nil, fmt.Errorf("test input %d: %v", i+1, err)
+		}
+		tests = append(tests, translate_lib.Test{Stdin: stdin, Output: expected})
+	}
+	return tests, nil
+}
+
+func generateAlias(length int) string {
+	b := make([]byte, length)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.URLEncoding.EncodeToString(b)[:length]
+}
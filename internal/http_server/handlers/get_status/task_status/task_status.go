@@ -1,7 +1,9 @@
 package task_status
 
 import (
+	my_middleware "codular-backend/internal/http_server/middleware"
 	"codular-backend/internal/storage/database"
+	"codular-backend/internal/task_progress"
 	"codular-backend/lib/logger/sl"
 	"errors"
 	"fmt"
@@ -17,16 +19,19 @@ type StatusResponse struct {
 	Result  string `json:"result,omitempty"`
 	Error   string `json:"error,omitempty"`
 	Message string `json:"message,omitempty"`
+	// Progress — ход генерации, пока статус "Processing"
+	Progress *database.TaskProgress `json:"progress,omitempty"`
 }
 
 // GetTaskStatus возвращает статус задачи по алиасу
 // @Summary Get task status
-// @Description Returns the current status of a task by its alias.
+// @Description Returns the current status of a task by its alias: Processing, Done, Error or Cancelled. While processing, progress reports the model, streamed tokens received, elapsed time and the task description once it has been generated; updatedAt stops advancing if the generation stalls.
 // @Tags Skips
 // @Produce json
 // @Param alias path string true "Task alias"
 // @Success 200 {object} StatusResponse "Task status retrieved successfully"
 // @Success 200 {object} StatusResponse "Example response" Example({"status":"Done","result":"processed code"})
+// @Success 200 {object} StatusResponse "Example response while processing" Example({"status":"Processing","progress":{"model":"openai/gpt-4o-mini","tokensReceived":84,"elapsedMs":5200,"description":"Factorial of a number","updatedAt":"2025-05-01T12:00:05Z"}})
 // @Failure 400 {object} StatusResponse "Alias parameter is missing"
 // @Failure 404 {object} StatusResponse "Task not found"
 // @Failure 500 {object} StatusResponse "Internal server error"
@@ -69,9 +74,87 @@ func GetTaskStatus(log *slog.Logger) http.HandlerFunc {
 			Result: status.Result,
 			Error:  status.Error,
 		}
+		if status.Status == task_progress.StatusProcessing {
+			response.Progress = status.Progress
+		}
 		writer.WriteHeader(http.StatusOK)
 		render.JSON(writer, request, response)
 
 		log.Info("task status retrieved", slog.String("alias", alias), slog.String("status", status.Status))
 	}
 }
+
+// CancelTask отменяет генерацию задачи
+// @Summary Cancel task generation
+// @Description Cancels a generation or regeneration started by the current user. The worker stops within about a second and the status becomes Cancelled; a generation whose progress has not been updated for a while (e.g. its server restarted) is marked Cancelled immediately. A cancelled regeneration keeps the previous version of the task.
+// @Tags Skips
+// @Produce json
+// @Param alias path string true "Task alias"
+// @Success 202 {object} StatusResponse "Cancellation requested"
+// @Success 200 {object} StatusResponse "Generation cancelled"
+// @Failure 401 {object} StatusResponse "Unauthorized"
+// @Failure 403 {object} StatusResponse "Generation was started by another user"
+// @Failure 404 {object} StatusResponse "Task not found"
+// @Failure 409 {object} StatusResponse "Task is not being generated"
+// @Failure 500 {object} StatusResponse "Internal server error"
+// @Security Bearer
+// @Router /task/{alias}/cancel [post]
+func CancelTask(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.get_status.task_status.CancelTask"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(request.Context())),
+		)
+
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, StatusResponse{Message: "unauthorized"})
+			return
+		}
+
+		alias := chi.URLParam(request, "alias")
+		status, err := storage.GetTaskStatus(alias)
+		if err != nil {
+			if err.Error() == fmt.Sprintf("task status not found for alias: %s", alias) {
+				writer.WriteHeader(http.StatusNotFound)
+				render.JSON(writer, request, StatusResponse{Message: "task not found"})
+				return
+			}
+			log.Error("failed to get task status", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, StatusResponse{Message: "internal server error"})
+			return
+		}
+		if status.Status != task_progress.StatusProcessing {
+			writer.WriteHeader(http.StatusConflict)
+			render.JSON(writer, request, StatusResponse{Status: status.Status, Message: "task is not being generated"})
+			return
+		}
+		if status.UserID != userID {
+			log.Error("user did not start this generation", slog.Int64("user_id", userID))
+			writer.WriteHeader(http.StatusForbidden)
+			render.JSON(writer, request, StatusResponse{Message: "forbidden: generation was started by another user"})
+			return
+		}
+
+		status, err = task_progress.Cancel(alias, status)
+		if err != nil {
+			log.Error("failed to cancel task generation", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, StatusResponse{Message: "internal server error"})
+			return
+		}
+
+		log.Info("task generation cancel requested", slog.String("alias", alias), slog.Int64("user_id", userID))
+		if status.Status == task_progress.StatusCancelled {
+			writer.WriteHeader(http.StatusOK)
+		} else {
+			writer.WriteHeader(http.StatusAccepted)
+		}
+		render.JSON(writer, request, StatusResponse{Status: status.Status, Progress: status.Progress, Message: "cancellation requested"})
+	}
+}
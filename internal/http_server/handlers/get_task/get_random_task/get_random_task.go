@@ -39,10 +39,10 @@ func getOKResponse(taskAlias string) *Response {
 
 // RandomTask redirects to a random public task.
 // @Summary Get random public task
-// @Description Redirects to a random public task with public = true, filtered by task type (skips, noises, or any), use this syntax: .../random?type=[type]. The redirected endpoint returns the task code and description.
+// @Description Redirects to a random public task with public = true, filtered by task type (skips, noises, parsons, bugs, output, quiz, translate, explain, or any), use this syntax: .../random?type=[type]. The redirected endpoint returns the task code and description.
 // @Tags Task
 // @Produce json
-// @Param type query string false "Task type (skips, noises, or any)" Enums(skips, noises, any) default(any)
+// @Param type query string false "Task type (skips, noises, parsons, bugs, output, quiz, translate, explain, or any)" Enums(skips, noises, parsons, bugs, output, quiz, translate, explain, any) default(any)
 // @Success 302 {string} string "Redirect to /api/v1/task/{alias}"
 // @Failure 400 {object} map[string]string "Invalid task type"
 // @Failure 404 {object} map[string]string "No public tasks found for the specified type"
@@ -64,7 +64,7 @@ func RandomTask(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 		}
 
 		// Валидация типа задачи
-		if taskType != "skips" && taskType != "noises" && taskType != "any" {
+		if taskType != "skips" && taskType != "noises" && taskType != "parsons" && taskType != "bugs" && taskType != "output" && taskType != "quiz" && taskType != "translate" && taskType != "explain" && taskType != "any" {
 			log.Error("invalid task type", slog.String("type", taskType))
 			w.WriteHeader(http.StatusBadRequest)
 			render.JSON(w, r, getErrorResponse("invalid task type"))
@@ -5,6 +5,7 @@ import (
 	"codular-backend/internal/storage/database"
 	response_info "codular-backend/lib/api/response"
 	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/quiz"
 	"github.com/go-chi/chi/v5"
 	chi_middleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -21,6 +22,12 @@ type Response struct {
 	CodeToSolve     string                     `json:"codeToSolve"`
 	CanEdit         bool                       `json:"canEdit"`
 	IsPublic        bool                       `json:"isPublic"`
+	// Stdin — ввод программы (задачи output)
+	Stdin string `json:"stdin,omitempty"`
+	// Questions — вопросы задачи quiz; порядок вопросов и вариантов новый при каждом запросе
+	Questions []quiz.View `json:"questions,omitempty"`
+	// TargetLanguage — язык, на который нужно перевести код (задачи translate)
+	TargetLanguage string `json:"targetLanguage,omitempty"`
 }
 
 func getErrorResponse(msg string) *Response {
@@ -49,7 +56,7 @@ func getOKResponse(codeToSolve string, canEdit bool, description string, taskTyp
 
 // New retrieves a task by alias.
 // @Summary Get task by alias
-// @Description Retrieves a task by its alias, returning the task code, description (title), and edit permissions for the authenticated user. Requires user authorization.
+// @Description Retrieves a task by its alias, returning the task code, description (title), and edit permissions for the authenticated user. Predict-the-output tasks also return the stdin the program reads. Quiz tasks also return the questions without the correct options; questions and options are shuffled on every request, while their ids stay the same. Translate tasks also return the target language. Requires user authorization.
 // @Tags Tasks
 // @Produce json
 // @Param alias path string true "Task alias"
@@ -128,8 +135,39 @@ func New(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
 		// Проверка прав редактирования
 		canEdit := userID == taskDetails.UserID
 
+		response := getOKResponse(codeFromDb, canEdit, description, taskDetails.Type, programmingLanguageName, taskDetails.IsPublic)
+		response.Stdin = taskDetails.Stdin
+		if taskDetails.Type == "quiz" {
+			questions, err := loadQuestions(storage, alias)
+			if err != nil {
+				log.Error("failed to load quiz questions", sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("internal server error"))
+				return
+			}
+			response.Questions = quiz.Shuffle(questions)
+		}
+		if taskDetails.Type == "translate" {
+			response.TargetLanguage, err = storage.GetProgrammingLanguageNameById(taskDetails.TargetLanguageID)
+			if err != nil {
+				log.Error("invalid target language found for id"+strconv.Itoa(int(taskDetails.TargetLanguageID)), sl.Err(err))
+				writer.WriteHeader(http.StatusInternalServerError)
+				render.JSON(writer, request, getErrorResponse("invalid target language found"))
+				return
+			}
+		}
+
 		log.Info("got task by alias from db", slog.String("alias", alias), slog.Bool("canEdit", canEdit))
 		writer.WriteHeader(http.StatusOK)
-		render.JSON(writer, request, getOKResponse(codeFromDb, canEdit, description, taskDetails.Type, programmingLanguageName, taskDetails.IsPublic))
+		render.JSON(writer, request, response)
+	}
+}
+
+// loadQuestions возвращает вопросы задачи quiz
+func loadQuestions(storage *database.Storage, alias string) ([]quiz.Question, error) {
+	answers, err := storage.GetCodeAnswers(alias)
+	if err != nil {
+		return nil, err
 	}
+	return quiz.Decode(answers)
 }
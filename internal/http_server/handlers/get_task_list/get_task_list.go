@@ -38,13 +38,13 @@ func getOKResponse(tasks []database.Task, total int) *Response {
 // @Description Retrieves a paginated list of public tasks filtered by task type, sorted by creation date (descending). Requires query parameters for pagination (offset, limit) and optional task type.
 // @Tags Tasks
 // @Produce json
-// @Param type query string false "Task type (e.g., skips, noises, or any for all types)" default(any)
+// @Param type query string false "Task type (e.g., skips, noises, parsons, bugs, output, quiz, translate, explain, or any for all types)" default(any)
 // @Param offset query int true "Offset for pagination" default(0)
 // @Param limit query int true "Limit for pagination" default(10)
-// @Success 200 {object} task.Response "Successfully retrieved task list"
-// @Success 200 {object} task.Response "Example response" Example({"responseInfo":{"status":"OK"},"tasks":[{"alias":"abc123","task_id":1,"type":"skips","description":"String concatenation task","programming_language":"Python","created_at":"2025-06-16T12:00:00Z"}],"total":1})
-// @Failure 400 {object} task.Response "Invalid query parameters"
-// @Failure 500 {object} task.Response "Internal server error"
+// @Success 200 {object} Response "Successfully retrieved task list"
+// @Success 200 {object} Response "Example response" Example({"responseInfo":{"status":"OK"},"tasks":[{"alias":"abc123","task_id":1,"type":"skips","description":"String concatenation task","programming_language":"Python","created_at":"2025-06-16T12:00:00Z"}],"total":1})
+// @Failure 400 {object} Response "Invalid query parameters"
+// @Failure 500 {object} Response "Internal server error"
 // @Router /tasks [get]
 func ListTasks(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -41,11 +41,11 @@ func getUserTasksOKResponse(tasks []database.Task, total int) *UserTasksResponse
 // @Produce json
 // @Param offset query int true "Offset for pagination" default(0)
 // @Param limit query int true "Limit for pagination" default(10)
-// @Success 200 {object} task.UserTasksResponse "Successfully retrieved user task list"
-// @Success 200 {object} task.UserTasksResponse "Example response" Example({"responseInfo":{"status":"OK"},"tasks":[{"alias":"xyz789","task_id":1,"type":"skips","description":"String concatenation task","programming_language":"Python","created_at":"2025-06-16T12:00:00Z"}],"total":1})
-// @Failure 400 {object} task.UserTasksResponse "Invalid query parameters"
-// @Failure 401 {object} task.UserTasksResponse "Unauthorized"
-// @Failure 500 {object} task.UserTasksResponse "Internal server error"
+// @Success 200 {object} UserTasksResponse "Successfully retrieved user task list"
+// @Success 200 {object} UserTasksResponse "Example response" Example({"responseInfo":{"status":"OK"},"tasks":[{"alias":"xyz789","task_id":1,"type":"skips","description":"String concatenation task","programming_language":"Python","created_at":"2025-06-16T12:00:00Z"}],"total":1})
+// @Failure 400 {object} UserTasksResponse "Invalid query parameters"
+// @Failure 401 {object} UserTasksResponse "Unauthorized"
+// @Failure 500 {object} UserTasksResponse "Internal server error"
 // @Security Bearer
 // @Router /user/tasks [get]
 func UserTasks(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
@@ -0,0 +1,119 @@
+package get_user_usage
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm_quota"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type Period struct {
+	database.LLMUsageTotals
+	ByEndpoint []database.LLMEndpointUsage `json:"byEndpoint"`
+	ResetAt    time.Time                   `json:"resetAt"`
+}
+
+// Limits — лимиты роли пользователя; 0 означает отсутствие лимита
+type Limits struct {
+	DailyRequests int   `json:"dailyRequests"`
+	DailyTokens   int64 `json:"dailyTokens"`
+	MonthlyTokens int64 `json:"monthlyTokens"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Role         string                     `json:"role,omitempty"`
+	Today        *Period                    `json:"today,omitempty"`
+	Month        *Period                    `json:"month,omitempty"`
+	Limits       *Limits                    `json:"limits,omitempty"`
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{ResponseInfo: response_info.Error(msg)}
+}
+
+// GetUserUsage возвращает расход LLM пользователя за текущие сутки и месяц
+// @Summary Get LLM usage
+// @Description Returns the authenticated user's LLM usage (requests, prompt/completion tokens, cost) for the current UTC day and month, broken down by endpoint, together with the quota limits for the user's role and reset times.
+// @Tags User
+// @Produce json
+// @Success 200 {object} get_user_usage.Response "Usage and limits"
+// @Failure 401 {object} get_user_usage.Response "Unauthorized"
+// @Failure 500 {object} get_user_usage.Response "Internal server error"
+// @Security Bearer
+// @Router /user/usage [get]
+func GetUserUsage(logger *slog.Logger, storage *database.Storage, checker *llm_quota.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.get_user_usage.GetUserUsage"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		role, err := storage.GetUserRole(userID)
+		if err != nil {
+			log.Error("failed to get user role", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		now := time.Now().UTC()
+		today, err := getPeriod(storage, userID, llm_quota.DayStart(now), llm_quota.NextDay(now))
+		if err != nil {
+			log.Error("failed to get daily usage", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		month, err := getPeriod(storage, userID, llm_quota.MonthStart(now), llm_quota.NextMonth(now))
+		if err != nil {
+			log.Error("failed to get monthly usage", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		limits := checker.Limits(role)
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{
+			ResponseInfo: response_info.OK(),
+			Role:         role,
+			Today:        today,
+			Month:        month,
+			Limits: &Limits{
+				DailyRequests: limits.DailyRequests,
+				DailyTokens:   limits.DailyTokens,
+				MonthlyTokens: limits.MonthlyTokens,
+			},
+		})
+	}
+}
+
+func getPeriod(storage *database.Storage, userID int64, since, resetAt time.Time) (*Period, error) {
+	totals, err := storage.GetLLMUsageTotals(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	byEndpoint, err := storage.GetLLMUsageByEndpoint(userID, since)
+	if err != nil {
+		return nil, err
+	}
+	return &Period{LLMUsageTotals: totals, ByEndpoint: byEndpoint, ResetAt: resetAt}, nil
+}
@@ -0,0 +1,26 @@
+package jwks
+
+import (
+	"codular-backend/internal/jwt_keys"
+	"github.com/go-chi/render"
+	"net/http"
+)
+
+type KeySetProvider interface {
+	JWKS() jwt_keys.JWKSet
+}
+
+// New возвращает публичные ключи проверки подписи JWT
+// @Summary JSON Web Key Set
+// @Description Returns the public keys (RFC 7517) that currently verify access tokens issued by this service. Keys are identified by the kid header of a token.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} jwt_keys.JWKSet "Active verification keys"
+// @Router /.well-known/jwks.json [get]
+func New(keys KeySetProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, keys.JWKS())
+	}
+}
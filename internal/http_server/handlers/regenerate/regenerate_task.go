@@ -1,12 +1,27 @@
 package regenerate
 
 import (
+	"codular-backend/internal/experiments"
+	"codular-backend/internal/hint_ladder"
+	"codular-backend/internal/http_server/handlers/generate/bugs"
+	"codular-backend/internal/http_server/handlers/generate/explain"
 	"codular-backend/internal/http_server/handlers/generate/noises"
+	"codular-backend/internal/http_server/handlers/generate/quiz"
 	"codular-backend/internal/http_server/handlers/generate/skips"
 	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
 	"codular-backend/internal/storage/database"
+	"codular-backend/internal/task_progress"
 	response_info "codular-backend/lib/api/response"
+	bugs_lib "codular-backend/lib/bugs"
+	explain_lib "codular-backend/lib/explain"
+	"codular-backend/lib/hintladder"
 	"codular-backend/lib/logger/sl"
+	noises_lib "codular-backend/lib/noises"
+	quiz_lib "codular-backend/lib/quiz"
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	chi_middleware "github.com/go-chi/chi/v5/middleware"
@@ -17,8 +32,12 @@ import (
 )
 
 type Request struct {
-	SkipsNumber *int `json:"skipsNumber,omitempty" validate:"omitempty,gte=0"`
-	NoiseLevel  *int `json:"noiseLevel,omitempty" validate:"omitempty,gte=0,lte=10"`
+	SkipsNumber    *int `json:"skipsNumber,omitempty" validate:"omitempty,gte=0"`
+	NoiseLevel     *int `json:"noiseLevel,omitempty" validate:"omitempty,gte=0,lte=10"`
+	BugsCount      *int `json:"bugsCount,omitempty" validate:"omitempty,gte=1,lte=10"`
+	QuestionsCount *int `json:"questionsCount,omitempty" validate:"omitempty,gte=1,lte=10"`
+	// Generator — генератор пропусков для skips (llm или ast), по умолчанию llm
+	Generator string `json:"generator,omitempty" validate:"omitempty,oneof=llm ast"`
 }
 
 type Response struct {
@@ -49,7 +68,7 @@ func getOKResponse(taskAlias string) *Response {
 
 // New regenerates an existing task by alias.
 // @Summary Regenerate task by alias
-// @Description Regenerates an existing task (skips or noises) by its alias with optional new parameters (skips number or noise level). Updates the task code and description in the database. Requires user authorization and edit permissions. Returns the task alias for retrieving the updated task code and description.
+// @Description Regenerates an existing task (skips, noises, bugs, quiz or explain) by its alias with optional new parameters (skips number, noise level, bugs count or questions count; explain tasks take none). Parsons problems, predict-the-output and translate tasks cannot be regenerated. Updates the task code and description in the database. Always requests a fresh variant from the LLM (the response cache is bypassed). Skips tasks accept the same generator field as /skips/generate; the ast generator is deterministic, so it changes the skips only when skipsNumber changes. Skips and noises tasks get new hint ladders, and hints users already revealed are reset. Requires user authorization and edit permissions. Returns the task alias for retrieving the updated task code and description.
 // @Tags Tasks
 // @Accept json
 // @Produce json
@@ -61,6 +80,7 @@ func getOKResponse(taskAlias string) *Response {
 // @Failure 401 {object} regenerate.Response "Unauthorized"
 // @Failure 403 {object} regenerate.Response "Forbidden: user does not have edit permissions"
 // @Failure 404 {object} regenerate.Response "Task not found"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
 // @Failure 500 {object} regenerate.Response "Internal server error"
 // @Security Bearer
 // @Router /task/{alias}/regenerate [patch]
@@ -126,6 +146,13 @@ func New(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
 			return
 		}
 
+		// Задачи Парсонса, «предскажи вывод» и перевод кода строятся без модели: новую проще сгенерировать заново
+		if taskDetails.Type == "parsons" || taskDetails.Type == "output" || taskDetails.Type == "translate" {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("regeneration is not supported for "+taskDetails.Type+" tasks"))
+			return
+		}
+
 		// Проверка параметров в зависимости от типа задачи
 		if taskDetails.Type == "skips" && req.SkipsNumber == nil {
 			log.Error("skipsNumber is required for skips task")
@@ -139,9 +166,21 @@ func New(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
 			render.JSON(writer, request, getErrorResponse("noiseLevel is required for noises task"))
 			return
 		}
+		if taskDetails.Type == "bugs" && req.BugsCount == nil {
+			log.Error("bugsCount is required for bugs task")
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("bugsCount is required for bugs task"))
+			return
+		}
+		if taskDetails.Type == "quiz" && req.QuestionsCount == nil {
+			log.Error("questionsCount is required for quiz task")
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("questionsCount is required for quiz task"))
+			return
+		}
 
 		// Установка начального статуса "Processing" в Redis
-		initialStatus := database.TaskStatus{Status: "Processing"}
+		initialStatus := task_progress.Initial(userID)
 		if err := storage.SetTaskStatus(alias, initialStatus); err != nil {
 			log.Error("failed to set initial status in Redis", sl.Err(err))
 			writer.WriteHeader(http.StatusInternalServerError)
@@ -161,19 +200,93 @@ func New(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
 func processTaskAsync(log *slog.Logger, alias string, taskDetails database.TaskDetails, req Request, storage *database.Storage) {
 	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", taskDetails.UserID))
 
-	var processedCode string
+	var processedCode, description, model string
 	var answers []string
-	var err error
+	var scrubReport json.RawMessage
+	var ladders []hintladder.Ladder
+	var promptVersionID int64
+
+	language, err := storage.GetProgrammingLanguageNameById(taskDetails.ProgrammingLanguageID)
+	if err != nil {
+		errorStatus := database.TaskStatus{Status: "Error", Error: fmt.Sprintf("failed to get programming language: %v", err)}
+		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
+			log.Error("failed to set error status in Redis", sl.Err(err))
+		}
+		return
+	}
+
+	regenerateOptions := llm.Options{NoCache: true, UserID: taskDetails.UserID, Endpoint: llm.EndpointRegenerate, TaskAlias: alias}
+
+	generator := req.Generator
+	if generator == "" {
+		generator = skips.GeneratorLLM
+	}
+
+	// Перегенерация участвует в эксперименте сценария генерации того же типа
+	flow := skips.Flow(generator)
+	if taskDetails.Type == "noises" {
+		flow = prompts.NoisesGenerate
+	} else if taskDetails.Type == "bugs" {
+		flow = prompts.BugsGenerate
+	} else if taskDetails.Type == "quiz" {
+		flow = prompts.QuizGenerate
+	} else if taskDetails.Type == "explain" {
+		flow = prompts.ExplainGenerate
+	}
+	variantID := experiments.Apply(flow, taskDetails.UserID, &regenerateOptions, log)
+
+	ctx, tracker := task_progress.Start(context.Background(), alias, taskDetails.UserID, log)
+	regenerateOptions.OnProgress = tracker.Update
 
-	description := ""
 	if taskDetails.Type == "skips" {
-		processedCode, answers, description, err = skips.ProcessCode(taskDetails.UserOriginalCode, *req.SkipsNumber, log)
+		var result skips.Result
+		result, err = skips.Generate(ctx, generator, taskDetails.UserOriginalCode, *req.SkipsNumber, language, prompts.DefaultLocale, regenerateOptions, log)
+		processedCode, answers, description, promptVersionID, model = result.Code, result.Answers, result.Description, result.PromptVersionID, result.Model
+		scrubReport = result.Scrub.Report()
+		if err == nil {
+			ladders = hint_ladder.ForSkips(ctx, result.Code, result.Answers, skips.Placeholder, prompts.DefaultLocale, regenerateOptions, log)
+		}
 	} else if taskDetails.Type == "noises" {
-		processedCode, description, err = noises.ProcessCode(taskDetails.UserOriginalCode, *req.NoiseLevel, log)
-		answers = []string{taskDetails.UserOriginalCode} // Для noises ответ — оригинальный код
+		var result noises.Result
+		result, err = noises.ProcessCode(ctx, taskDetails.UserOriginalCode, *req.NoiseLevel, language, prompts.DefaultLocale, regenerateOptions, log)
+		processedCode, description, promptVersionID, model = result.Code, result.Description, result.PromptVersionID, result.Model
+		scrubReport = result.Scrub.Report()
+		answers = noises_lib.Encode(taskDetails.UserOriginalCode, result.Edits) // Для noises ответ — оригинальный код и внесённые правки
+		if err == nil {
+			ladders = hint_ladder.ForNoises(ctx, result.Code, result.Edits, prompts.DefaultLocale, regenerateOptions, log)
+		}
+	} else if taskDetails.Type == "bugs" {
+		var result bugs.Result
+		result, err = bugs.ProcessCode(ctx, taskDetails.UserOriginalCode, *req.BugsCount, language, prompts.DefaultLocale, regenerateOptions, log)
+		processedCode, description, promptVersionID, model = result.Code, result.Description, result.PromptVersionID, result.Model
+		scrubReport = result.Scrub.Report()
+		answers = bugs_lib.Encode(result.Bugs) // Для bugs ответ — строки с ошибками и исправления
+	} else if taskDetails.Type == "quiz" {
+		var result quiz.Result
+		result, err = quiz.ProcessCode(ctx, taskDetails.UserOriginalCode, *req.QuestionsCount, language, prompts.DefaultLocale, regenerateOptions, log)
+		description, promptVersionID, model = result.Description, result.PromptVersionID, result.Model
+		scrubReport = result.Scrub.Report()
+		processedCode = taskDetails.UserOriginalCode // Код задачи quiz не меняется
+		answers = quiz_lib.Encode(result.Questions)  // Для quiz ответ — вопросы с правильными вариантами
+	} else if taskDetails.Type == "explain" {
+		var result explain.Result
+		result, err = explain.ProcessCode(ctx, taskDetails.UserOriginalCode, language, prompts.DefaultLocale, regenerateOptions, log)
+		description, promptVersionID, model = result.Description, result.PromptVersionID, result.Model
+		scrubReport = result.Scrub.Report()
+		processedCode = taskDetails.UserOriginalCode // Код задачи explain не меняется
+		answers = explain_lib.Encode(result.Rubric)  // Для explain ответ — критерии проверки объяснения
+	}
+	tracker.Stop()
+	if tracker.Cancelled() {
+		cancelledStatus := database.TaskStatus{Status: task_progress.StatusCancelled, UserID: taskDetails.UserID}
+		if err := storage.SetTaskStatus(alias, cancelledStatus); err != nil {
+			log.Error("failed to set cancelled status in Redis", sl.Err(err))
+		}
+		return
 	}
 
 	if err != nil {
+		experiments.RecordGeneration(variantID, taskDetails.UserID, alias, false, log)
 		// Обновление статуса на "Error" в случае ошибки
 		errorStatus := database.TaskStatus{Status: "Error", Error: err.Error()}
 		if err := storage.SetTaskStatus(alias, errorStatus); err != nil {
@@ -183,7 +296,7 @@ func processTaskAsync(log *slog.Logger, alias string, taskDetails database.TaskD
 	}
 
 	// Обновление задачи в PostgreSQL
-	err = storage.UpdateTaskCodeAndAnswers(taskDetails.TaskID, processedCode, answers, description)
+	err = storage.UpdateTaskCodeAndAnswers(taskDetails.TaskID, processedCode, answers, description, promptVersionID)
 	if err != nil {
 		// Обновление статуса на "Error" в случае ошибки сохранения
 		errorStatus := database.TaskStatus{Status: "Error", Error: fmt.Sprintf("failed to update task: %v", err)}
@@ -193,8 +306,22 @@ func processTaskAsync(log *slog.Logger, alias string, taskDetails database.TaskD
 		return
 	}
 
+	// Отчёт перезаписывается всегда, чтобы не остался отчёт прошлой генерации
+	if err := storage.SetTaskScrubReport(taskDetails.TaskID, scrubReport); err != nil {
+		log.Error("failed to save scrub report", sl.Err(err))
+	}
+
+	// Новые лестницы заменяют прежние вместе с открытыми по ним подсказками
+	if taskDetails.Type == "skips" || taskDetails.Type == "noises" {
+		if err := storage.SetTaskHintLadders(taskDetails.TaskID, hintladder.Encode(ladders)); err != nil {
+			log.Error("failed to save hint ladders", sl.Err(err))
+		}
+	}
+
+	experiments.RecordGeneration(variantID, taskDetails.UserID, alias, true, log)
+
 	// Обновление статуса на "Done" при успехе
-	doneStatus := database.TaskStatus{Status: "Done", Result: processedCode}
+	doneStatus := database.TaskStatus{Status: "Done", Result: processedCode, Model: model}
 	if err := storage.SetTaskStatus(alias, doneStatus); err != nil {
 		log.Error("failed to set done status in Redis", sl.Err(err))
 	}
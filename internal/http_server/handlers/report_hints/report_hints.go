@@ -0,0 +1,105 @@
+package report_hints
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type Request struct {
+	Comment string `json:"comment,omitempty" validate:"max=1000"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{ResponseInfo: response_info.Error(msg)}
+}
+
+// New сохраняет жалобу пользователя на подсказки к своей посылке; учитывается в отчётах экспериментов
+// @Summary Report bad hints
+// @Description Marks the hints of the user's own submission as unhelpful or wrong, with an optional comment. Reporting again replaces the comment. Reports are counted per variant in experiment reports.
+// @Tags Submissions
+// @Accept json
+// @Produce json
+// @Param submission_id path int true "Submission ID"
+// @Param request body Request false "Optional comment"
+// @Success 200 {object} Response "Report saved"
+// @Failure 400 {object} Response "Invalid submission ID or request"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 404 {object} Response "Submission not found"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /submission/{submission_id}/report-hints [post]
+func New(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.report_hints.New"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("user ID not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		submissionID, err := strconv.ParseInt(chi.URLParam(r, "submission_id"), 10, 64)
+		if err != nil || submissionID <= 0 {
+			log.Error("invalid submission_id format", slog.String("submission_id", chi.URLParam(r, "submission_id")))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("invalid submission_id format"))
+			return
+		}
+
+		// Тело необязательно: пустой запрос — жалоба без комментария
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("invalid request body"))
+			return
+		}
+		if err := validator.New().Struct(req); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, &Response{ResponseInfo: response_info.ValidationError(validationErrs)})
+				return
+			}
+		}
+
+		if err := storage.ReportBadHints(submissionID, userID, req.Comment); err != nil {
+			log.Error("failed to report hints", sl.Err(err))
+			if err.Error() == "submission not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, getErrorResponse("submission not found"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		log.Info("hints reported", slog.Int64("submission_id", submissionID), slog.Int64("user_id", userID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK()})
+	}
+}
@@ -0,0 +1,336 @@
+package bugs_check
+
+import (
+	"codular-backend/internal/experiments"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/bugs"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type ClientRequest struct {
+	TaskAlias string `json:"taskAlias" validate:"required"`
+	// Answers — строки с ошибками и необязательные исправления
+	Answers []bugs.Answer `json:"answers" validate:"required,min=1,dive"`
+}
+
+type ServerResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	SubmissionID int64                      `json:"submissionId"`
+}
+
+type LLMResponse struct {
+	Hints []Hint `json:"hints"`
+}
+
+type Hint struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// responseSchema — схема ответа LLM с подсказками
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"hints": llmjson.Array(llmjson.Object(map[string]*llmjson.Schema{
+		"line":    llmjson.Integer(1, 100000),
+		"message": llmjson.String(),
+	})),
+})
+
+func getErrorResponse(msg string) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.Error(msg),
+		SubmissionID: -1,
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		SubmissionID: -1,
+	}
+}
+
+func getOKResponse(submissionID int64) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.OK(),
+		SubmissionID: submissionID,
+	}
+}
+
+// New handles the submission of bug lines for a find-the-bug task.
+// @Summary Submit bug lines for a find-the-bug task
+// @Description Receives the line numbers the user believes contain bugs, each with an optional fixed line, saves the submission and grades it asynchronously. Grading is deterministic per line: a found bug with a correct fix (compared ignoring whitespace) or without a fix earns a full share, a found bug with a wrong fix earns half, and every line without a bug costs half a share. Hints are requested from the LLM only for the lines the user got wrong; hints for missed bugs do not reveal the line. Submissions are rejected while the user's LLM usage quota is exhausted.
+// @Tags Bugs
+// @Accept json
+// @Produce json
+// @Param request body ClientRequest true "Task alias and bug lines"
+// @Success 200 {object} ServerResponse "Successfully initiated submission processing"
+// @Success 200 {object} ServerResponse "Example response" Example({"responseInfo":{"status":"OK"},"submissionId":123})
+// @Failure 400 {object} ServerResponse "Invalid request body, validation error, or not a find-the-bug task"
+// @Failure 401 {object} ServerResponse "Unauthorized"
+// @Failure 404 {object} ServerResponse "Task not found"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
+// @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
+// @Router /bugs/solve [post]
+func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.solve.bugs_check.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest ClientRequest
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		log.Info("request body was decoded", slog.Any("decodedRequest", decodedRequest))
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(decodedRequest.TaskAlias)
+		if err != nil {
+			if err.Error() == "task not found" {
+				writer.WriteHeader(http.StatusNotFound)
+				render.JSON(writer, request, getErrorResponse("task not found"))
+				return
+			}
+			log.Error("failed to get task details", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		if taskDetails.Type != "bugs" {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("task is not a find-the-bug task"))
+			return
+		}
+
+		// Сохранение посылки: по одному JSON-объекту на строку
+		submission := make([]string, len(decodedRequest.Answers))
+		for i, answer := range decodedRequest.Answers {
+			encoded, _ := json.Marshal(answer)
+			submission[i] = string(encoded)
+		}
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, submission)
+		if err != nil {
+			log.Error("failed to save submission", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		// Возвращаем ID посылки (статус пока Pending)
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(submissionID))
+
+		// Асинхронная обработка
+		go processSubmissionAsync(log, storage, decodedRequest.TaskAlias, submissionID, userID, decodedRequest.Answers)
+
+		log.Info("submission processing initiated", slog.Int64("submission_id", submissionID))
+	}
+}
+
+// processSubmissionAsync проверяет ответ и сохраняет результат с подсказками
+func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAlias string, submissionID, userID int64, answers []bugs.Answer) {
+	log = log.With(slog.Int64("submission_id", submissionID))
+
+	setFailed := func() {
+		if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+			log.Error("failed to set failed status", sl.Err(err))
+		}
+	}
+
+	storedAnswers, err := storage.GetCodeAnswers(taskAlias)
+	if err != nil {
+		log.Error("failed to get correct answers", sl.Err(err))
+		setFailed()
+		return
+	}
+	injected, err := bugs.Decode(storedAnswers)
+	if err != nil {
+		log.Error("failed to decode correct answers", sl.Err(err))
+		setFailed()
+		return
+	}
+
+	grade := bugs.Check(injected, answers)
+	log.Info("bugs submission graded", slog.Int("score", grade.Score), slog.Bool("solved", grade.Solved()))
+	if grade.Solved() {
+		if err := storage.UpdateSubmissionStatusToSuccess(submissionID, grade.Score); err != nil {
+			log.Error("failed to set submission to success", sl.Err(err))
+			setFailed()
+		}
+		return
+	}
+
+	// Подсказки нужны только для строк, с которыми пользователь ошибся
+	wrong := grade.Wrong()
+	hints := fallbackHints(wrong)
+	bugsCode, err := storage.GetSavedTaskCode(taskAlias)
+	if err != nil {
+		log.Error("failed to get saved code", sl.Err(err))
+	} else {
+		opts := llm.Options{UserID: userID, Endpoint: llm.EndpointBugsSolve, TaskAlias: taskAlias, SubmissionID: submissionID}
+		if variantID := experiments.Apply(prompts.BugsCheck, userID, &opts, log); variantID != 0 {
+			experiments.RecordSubmission(variantID, submissionID, log)
+		}
+		llmHints, err := ProcessSubmission(context.Background(), taskAlias, bugsCode, wrong, opts, log)
+		if err != nil {
+			// Оценка уже посчитана без модели — при её недоступности остаются простые подсказки
+			log.Error("failed to get hints from llm", sl.Err(err))
+		} else {
+			hints = mergeHints(wrong, llmHints, hints)
+		}
+	}
+
+	if err := storage.UpdateSubmissionStatusToFailedWithHints(submissionID, hints, grade.Score); err != nil {
+		log.Error("failed to set submission to failed", sl.Err(err))
+		setFailed()
+	}
+}
+
+// ProcessSubmission просит модель написать подсказки к строкам, с которыми пользователь ошибся
+func ProcessSubmission(ctx context.Context, taskAlias, bugsCode string, wrong []bugs.LineResult, opts llm.Options, logger *slog.Logger) ([]Hint, error) {
+	submission, err := json.Marshal(wrong)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal submission: %v", err)
+	}
+
+	// Код задачи восстановлен после генерации, а в ответах есть исправления с его строками: секреты скрываются снова
+	scrubbed, parts, err := llm.ScrubParts(bugs.Number(bugsCode), string(submission))
+	if err != nil {
+		logger.Warn("bugs submission refused before llm request", sl.Err(err))
+		return nil, err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("bugs submission scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
+	}
+
+	prompt, err := prompts.RenderAt(prompts.BugsCheck, opts.PromptVersion, prompts.Vars{
+		"Code":       parts[0],
+		"Submission": parts[1],
+		"Locale":     prompts.DefaultLocale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return nil, fmt.Errorf("failed to render prompt: %v", err)
+	}
+
+	// Ключ включает код задачи: после перегенерации задачи старые подсказки не используются
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.7,
+		Kind:            llm.KindBugsCheck,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), taskAlias, parts[0], parts[1]},
+		Schema:          responseSchema,
+	}, opts)
+	if err != nil {
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
+
+	var decodedLLMResponse LLMResponse
+	if err := json.Unmarshal([]byte(response.Content), &decodedLLMResponse); err != nil {
+		logger.Error("failed to decode llm response", sl.Err(err))
+		return nil, fmt.Errorf("failed to decode llm response: %v", err)
+	}
+	for i := range decodedLLMResponse.Hints {
+		decodedLLMResponse.Hints[i].Message = scrubbed.Restore(decodedLLMResponse.Hints[i].Message)
+	}
+	return decodedLLMResponse.Hints, nil
+}
+
+// fallbackHints — подсказки без модели. Номер строки пропущенной ошибки не раскрывается
+func fallbackHints(wrong []bugs.LineResult) []string {
+	hints := make([]string, 0, len(wrong))
+	missed := 0
+	for _, line := range wrong {
+		switch line.Status {
+		case bugs.StatusFalsePositive:
+			hints = append(hints, "line "+strconv.Itoa(line.Line)+": this line has no bug")
+		case bugs.StatusWrongFix:
+			hints = append(hints, "line "+strconv.Itoa(line.Line)+": the bug is here, but the fix is not correct")
+		case bugs.StatusMissed:
+			missed++
+		}
+	}
+	if missed > 0 {
+		hints = append(hints, strconv.Itoa(missed)+" bug(s) not found yet")
+	}
+	return hints
+}
+
+// mergeHints подставляет подсказки модели по строкам; если модель пропустила строку, остаются
+// простые подсказки. Подсказка к пропущенной ошибке выводится без номера строки
+func mergeHints(wrong []bugs.LineResult, llmHints []Hint, fallback []string) []string {
+	byLine := make(map[int]string, len(llmHints))
+	for _, hint := range llmHints {
+		if hint.Message != "" {
+			byLine[hint.Line] = hint.Message
+		}
+	}
+
+	hints := make([]string, 0, len(wrong))
+	for _, line := range wrong {
+		message, ok := byLine[line.Line]
+		if !ok {
+			return fallback
+		}
+		if line.Status == bugs.StatusMissed {
+			hints = append(hints, "not found yet: "+message)
+		} else {
+			hints = append(hints, "line "+strconv.Itoa(line.Line)+": "+message)
+		}
+	}
+	return hints
+}
@@ -0,0 +1,292 @@
+package explain_check
+
+import (
+	"codular-backend/internal/experiments"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/explain"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ClientRequest struct {
+	TaskAlias string `json:"taskAlias" validate:"required"`
+	// Explanation — объяснение кода своими словами
+	Explanation string `json:"explanation" validate:"required,max=5000"`
+}
+
+type ServerResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	SubmissionID int64                      `json:"submissionId"`
+}
+
+type LLMResponse struct {
+	Points []explain.Verdict `json:"points"`
+}
+
+// responseSchema — схема ответа LLM с оценкой по пунктам
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"points": llmjson.Array(llmjson.Object(map[string]*llmjson.Schema{
+		"point":   llmjson.Integer(1, explain.MaxPoints),
+		"covered": llmjson.Boolean(),
+		"hint":    llmjson.String(),
+	})),
+})
+
+func getErrorResponse(msg string) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.Error(msg),
+		SubmissionID: -1,
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		SubmissionID: -1,
+	}
+}
+
+func getOKResponse(submissionID int64) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.OK(),
+		SubmissionID: submissionID,
+	}
+}
+
+// New handles the submission of an explanation for an explain-this-code task.
+// @Summary Submit an explanation of the code
+// @Description Receives the student's free-text explanation of the task code, saves the submission and grades it asynchronously. The LLM decides for every point of the rubric stored with the task whether the explanation covers it; the score is the covered share of the rubric weight and is computed by the server. Hints list each point: covered points are named, missed points get a hint that does not reveal them. Status, score and hints are available from /submission-status/{submission_id}. Submissions are rejected while the user's LLM usage quota is exhausted.
+// @Tags Explain
+// @Accept json
+// @Produce json
+// @Param request body ClientRequest true "Task alias and explanation"
+// @Success 200 {object} ServerResponse "Successfully initiated submission processing"
+// @Success 200 {object} ServerResponse "Example response" Example({"responseInfo":{"status":"OK"},"submissionId":123})
+// @Failure 400 {object} ServerResponse "Invalid request body, validation error, or not an explain task"
+// @Failure 401 {object} ServerResponse "Unauthorized"
+// @Failure 404 {object} ServerResponse "Task not found"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
+// @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
+// @Router /explain/solve [post]
+func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.solve.explain_check.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest ClientRequest
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(decodedRequest.TaskAlias)
+		if err != nil {
+			if err.Error() == "task not found" {
+				writer.WriteHeader(http.StatusNotFound)
+				render.JSON(writer, request, getErrorResponse("task not found"))
+				return
+			}
+			log.Error("failed to get task details", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		if taskDetails.Type != "explain" {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("task is not an explain task"))
+			return
+		}
+
+		// Сохранение посылки
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, []string{decodedRequest.Explanation})
+		if err != nil {
+			log.Error("failed to save submission", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(submissionID))
+
+		// Асинхронная обработка
+		go processSubmissionAsync(log, storage, decodedRequest.TaskAlias, submissionID, userID, decodedRequest.Explanation)
+
+		log.Info("submission processing initiated", slog.Int64("submission_id", submissionID))
+	}
+}
+
+// processSubmissionAsync проверяет объяснение по критериям и сохраняет результат с подсказками
+func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAlias string, submissionID, userID int64, explanation string) {
+	log = log.With(slog.Int64("submission_id", submissionID))
+
+	setFailed := func() {
+		if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+			log.Error("failed to set failed status", sl.Err(err))
+		}
+	}
+
+	storedAnswers, err := storage.GetCodeAnswers(taskAlias)
+	if err != nil {
+		log.Error("failed to get rubric", sl.Err(err))
+		setFailed()
+		return
+	}
+	rubric, err := explain.Decode(storedAnswers)
+	if err != nil {
+		log.Error("failed to decode rubric", sl.Err(err))
+		setFailed()
+		return
+	}
+	code, err := storage.GetSavedTaskCode(taskAlias)
+	if err != nil {
+		log.Error("failed to get saved code", sl.Err(err))
+		setFailed()
+		return
+	}
+
+	opts := llm.Options{UserID: userID, Endpoint: llm.EndpointExplainSolve, TaskAlias: taskAlias, SubmissionID: submissionID}
+	if variantID := experiments.Apply(prompts.ExplainCheck, userID, &opts, log); variantID != 0 {
+		experiments.RecordSubmission(variantID, submissionID, log)
+	}
+	verdicts, err := ProcessSubmission(context.Background(), taskAlias, code, rubric, explanation, opts, log)
+	if err != nil {
+		log.Error("failed to check explanation with llm", sl.Err(err))
+		setFailed()
+		return
+	}
+
+	grade := explain.Check(rubric, verdicts)
+	log.Info("explain submission graded", slog.Int("score", grade.Score), slog.Bool("solved", grade.Solved()))
+	if grade.Solved() {
+		err = storage.UpdateSubmissionStatusToSuccess(submissionID, grade.Score)
+	} else {
+		err = storage.UpdateSubmissionStatusToFailedWithHints(submissionID, hints(grade), grade.Score)
+	}
+	if err != nil {
+		log.Error("failed to save submission result", sl.Err(err))
+		setFailed()
+	}
+}
+
+// ProcessSubmission просит модель отметить, какие пункты критериев раскрыты в объяснении
+func ProcessSubmission(ctx context.Context, taskAlias, code string, rubric []explain.Point, explanation string, opts llm.Options, logger *slog.Logger) ([]explain.Verdict, error) {
+	var numbered strings.Builder
+	for i, point := range rubric {
+		numbered.WriteString(strconv.Itoa(i+1) + ". " + point.Text + "\n")
+	}
+
+	// Код задачи и критерии восстановлены после генерации: секреты скрываются снова
+	scrubbed, parts, err := llm.ScrubParts(code, numbered.String(), explanation)
+	if err != nil {
+		logger.Warn("explain submission refused before llm request", sl.Err(err))
+		return nil, err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("explain submission scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
+	}
+
+	prompt, err := prompts.RenderAt(prompts.ExplainCheck, opts.PromptVersion, prompts.Vars{
+		"Code":       parts[0],
+		"Rubric":     parts[1],
+		"Submission": parts[2],
+		"Locale":     prompts.DefaultLocale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return nil, fmt.Errorf("failed to render prompt: %v", err)
+	}
+
+	// Ключ включает критерии: после перегенерации задачи старые оценки не используются
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.2,
+		Kind:            llm.KindExplainCheck,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), taskAlias, parts[1], strings.Join(strings.Fields(parts[2]), " ")},
+		Schema:          responseSchema,
+	}, opts)
+	if err != nil {
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
+
+	var decodedLLMResponse LLMResponse
+	if err := json.Unmarshal([]byte(response.Content), &decodedLLMResponse); err != nil {
+		logger.Error("failed to decode llm response", sl.Err(err))
+		return nil, fmt.Errorf("failed to decode llm response: %v", err)
+	}
+	for i := range decodedLLMResponse.Points {
+		decodedLLMResponse.Points[i].Hint = scrubbed.Restore(decodedLLMResponse.Points[i].Hint)
+	}
+	return decodedLLMResponse.Points, nil
+}
+
+// hints перечисляет пункты критериев: раскрытые называются, к нераскрытым даётся подсказка модели
+func hints(grade explain.Grade) []string {
+	result := make([]string, 0, len(grade.Points))
+	for _, point := range grade.Points {
+		if point.Covered {
+			result = append(result, "covered: "+point.Text)
+			continue
+		}
+		hint := strings.TrimSpace(point.Hint)
+		if hint == "" {
+			hint = "one of the key points of the code is not explained yet"
+		}
+		result = append(result, "not covered: "+hint)
+	}
+	return result
+}
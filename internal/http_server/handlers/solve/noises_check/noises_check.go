@@ -1,10 +1,17 @@
 package noises_check
 
 import (
+	"codular-backend/internal/experiments"
+	"codular-backend/internal/hint_ladder"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
 	"codular-backend/internal/storage/database"
-	openRouterAPI "codular-backend/lib/api/openrouter"
 	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/llmjson"
 	"codular-backend/lib/logger/sl"
+	noises_lib "codular-backend/lib/noises"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,16 +19,14 @@ import (
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"io"
-	"log"
 	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 )
 
 type ClientRequest struct {
 	TaskAlias string `json:"taskAlias" validate:"required"`
-	Answer    string `json:"answer" validate:"required"`
+	Answer    string `json:"answer" validate:"required,max=65536"`
 }
 
 type ServerResponse struct {
@@ -34,6 +39,12 @@ type LLMResponse struct {
 	Hints []string `json:"hints"`
 }
 
+// responseSchema — схема ответа LLM при проверке решения
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"score": llmjson.Integer(0, 100),
+	"hints": llmjson.Array(llmjson.String()),
+})
+
 func getErrorResponse(msg string) *ServerResponse {
 	return &ServerResponse{
 		ResponseInfo: response_info.Error(msg),
@@ -57,7 +68,7 @@ func getOKResponse(submissionID int64) *ServerResponse {
 
 // New handles the submission of user answers for a noises task.
 // @Summary Submit answer for a noises task
-// @Description Submits a user's answer for a noises task identified by its alias, saves the submission, and processes it asynchronously. Returns a submission ID for status tracking.
+// @Description Submits a user's answer for a noises task identified by its alias, saves the submission, and processes it asynchronously. Returns a submission ID for status tracking. The answer is the cleaned code. It is compared line by line (ignoring blank lines and whitespace) with the original code: every edit recorded at generation is either reverted or missed, and other differences count as unnecessary changes. The score is the share of reverted edits among all edits and unnecessary changes; hints name the line ranges of the task code and of the solution. Tasks created before edits were recorded are graded by the LLM. Hints revealed through POST /task/{alias}/hint before the submission lower the score.
 // @Tags Noises
 // @Accept json
 // @Produce json
@@ -65,8 +76,10 @@ func getOKResponse(submissionID int64) *ServerResponse {
 // @Success 200 {object} ServerResponse "Submission processing initiated successfully"
 // @Success 200 {object} ServerResponse "Example response" Example({"responseInfo":{"status":"OK"},"submissionId":123})
 // @Failure 400 {object} ServerResponse "Invalid request body or validation error"
+// @Failure 401 {object} ServerResponse "Unauthorized"
 // @Failure 404 {object} ServerResponse "Task not found"
 // @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
 // @Router /noises/solve [post]
 func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
@@ -77,6 +90,15 @@ func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(request.Context())),
 		)
 
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
 		var decodedRequest ClientRequest
 		err := render.DecodeJSON(request.Body, &decodedRequest)
 		if err != nil {
@@ -105,6 +127,13 @@ func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 			}
 		}
 
+		if noises_lib.TooLong(decodedRequest.Answer) {
+			log.Error("answer is too long")
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse(fmt.Sprintf("answer must be shorter than %d lines", noises_lib.MaxLines)))
+			return
+		}
+
 		// Проверка существования задачи
 		exists, err := storage.CheckAliasExist(decodedRequest.TaskAlias)
 		if err != nil {
@@ -121,7 +150,7 @@ func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 		}
 
 		// Сохранение посылки
-		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, []string{decodedRequest.Answer})
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, []string{decodedRequest.Answer})
 		if err != nil {
 			log.Error("failed to save submission", sl.Err(err))
 			writer.WriteHeader(http.StatusInternalServerError)
@@ -138,14 +167,14 @@ func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 		render.JSON(writer, request, response)
 
 		// Асинхронная обработка
-		go processSubmissionAsync(log, storage, decodedRequest.TaskAlias, submissionID, decodedRequest.Answer)
+		go processSubmissionAsync(log, storage, decodedRequest.TaskAlias, submissionID, userID, decodedRequest.Answer)
 
 		log.Info("submission processing initiated", slog.Int64("submission_id", submissionID))
 	}
 }
 
 // processTaskAsync асинхронно обрабатывает задачу и сохраняет результат
-func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAlias string, submissionID int64, userAnswer string) {
+func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAlias string, submissionID, userID int64, userAnswer string) {
 	log = log.With(slog.Int64("submission_id", submissionID))
 
 	correctAnswers, err := storage.GetCodeAnswers(taskAlias)
@@ -154,8 +183,23 @@ func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAli
 		err := storage.UpdateSubmissionStatusToFailed(submissionID)
 		if err != nil {
 			log.Error("Error processing submission" + strconv.FormatInt(submissionID, 10) + " while setting Failed status: " + err.Error())
-			return
 		}
+		return
+	}
+
+	// Задачи с сохранёнными правками проверяются по различию строк без модели;
+	// у задач, созданных раньше, правок нет, и решение оценивает модель
+	originalCode, edits, err := noises_lib.Decode(correctAnswers)
+	if err != nil {
+		log.Error("failed to decode noises edits", sl.Err(err))
+		if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+			log.Error("failed to set failed status", sl.Err(err))
+		}
+		return
+	}
+	if len(edits) > 0 {
+		gradeByDiff(log, storage, submissionID, originalCode, edits, userAnswer)
+		return
 	}
 
 	taskCode, err := storage.GetSavedTaskCode(taskAlias)
@@ -168,7 +212,11 @@ func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAli
 		}
 	}
 
-	llmResponse, err := processSubmission(correctAnswers[0], taskCode, userAnswer, log)
+	opts := llm.Options{UserID: userID, Endpoint: llm.EndpointNoisesSolve, TaskAlias: taskAlias, SubmissionID: submissionID}
+	if variantID := experiments.Apply(prompts.NoisesCheck, userID, &opts, log); variantID != 0 {
+		experiments.RecordSubmission(variantID, submissionID, log)
+	}
+	llmResponse, err := ProcessSubmission(context.Background(), taskAlias, correctAnswers[0], taskCode, userAnswer, opts, log)
 	if err != nil {
 		log.Error("Got error while processing submission: " + err.Error())
 		err := storage.UpdateSubmissionStatusToFailed(submissionID)
@@ -189,8 +237,10 @@ func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAli
 	log.Info(fmt.Sprintf("LLM response struct: %+v", *llmResponse))
 	log.Info("Processed LLM.", slog.Any("hints", llmResponse.Hints), slog.Int("score", llmResponse.Score))
 
+	// Открытые до отправки подсказки снижают оценку
+	score := hint_ladder.Penalize(storage, submissionID, llmResponse.Score, log)
 	if llmResponse.Score >= 100 {
-		err = storage.UpdateSubmissionStatusToSuccess(submissionID, llmResponse.Score)
+		err = storage.UpdateSubmissionStatusToSuccess(submissionID, score)
 		if err != nil {
 			log.Error("Got error while setting submission to success: " + err.Error())
 			err := storage.UpdateSubmissionStatusToFailed(submissionID)
@@ -210,7 +260,7 @@ func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAli
 		err := storage.UpdateSubmissionStatusToFailedWithHints(
 			submissionID,
 			hints,
-			llmResponse.Score,
+			score,
 		)
 		if err != nil {
 			log.Error("Got error while setting submission to failed: " + err.Error())
@@ -223,33 +273,86 @@ func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAli
 	}
 }
 
-func processSubmission(originalCode string, noisedCode string, userSolutionCode string, logger *slog.Logger) (*LLMResponse, error) {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	model := os.Getenv("MODEL")
-	temperature := 0.7
+// gradeByDiff сравнивает решение с исходным кодом и отмечает каждую правку как отменённую или пропущенную
+func gradeByDiff(log *slog.Logger, storage *database.Storage, submissionID int64, originalCode string, edits []noises_lib.Edit, userAnswer string) {
+	grade := noises_lib.Check(originalCode, edits, userAnswer)
+	log.Info("noises submission graded by diff", slog.Int("score", grade.Score), slog.Bool("solved", grade.Solved()), slog.Int("extra_changes", len(grade.Extra)))
 
-	client := openRouterAPI.NewClient(apiKey, model, temperature)
-
-	prompts, err := openRouterAPI.LoadSystemPrompts("./config/noises_check_prompt.yaml")
+	// Открытые до отправки подсказки снижают оценку
+	score := hint_ladder.Penalize(storage, submissionID, grade.Score, log)
+	var err error
+	if grade.Solved() {
+		err = storage.UpdateSubmissionStatusToSuccess(submissionID, score)
+	} else {
+		err = storage.UpdateSubmissionStatusToFailedWithHints(submissionID, diffHints(grade), score)
+	}
 	if err != nil {
-		log.Fatalf("Error loading system prompts: %v", err)
+		log.Error("failed to save submission result", sl.Err(err))
+		if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+			log.Error("failed to set failed status", sl.Err(err))
+		}
 	}
+}
 
-	systemPrompt, exists := prompts["system_prompt"]
-	if !exists {
-		log.Fatalf("System prompt not found in the YAML file")
+// diffHints называет строки кода задачи с неотменёнными правками и строки решения с лишними изменениями
+func diffHints(grade noises_lib.Grade) []string {
+	var hints []string
+	for _, result := range grade.Edits {
+		if result.Reverted {
+			continue
+		}
+		edit := result.Edit
+		switch {
+		case edit.Kind == noises_lib.KindNoise:
+			hints = append(hints, noises_lib.LineRange(edit.NoisedLine, edit.NoisedCount)+" of the task code: this code does not affect the result, remove it")
+		case edit.NoisedCount == 0:
+			hints = append(hints, noises_lib.LineRange(edit.NoisedLine, edit.NoisedCount)+" of the task code: a line of the original program is missing here")
+		default:
+			hints = append(hints, noises_lib.LineRange(edit.NoisedLine, edit.NoisedCount)+" of the task code: this code changes the program's behaviour, restore the original logic")
+		}
+	}
+	for _, change := range grade.Extra {
+		if change.Count == 0 {
+			hints = append(hints, noises_lib.LineRange(change.Line, change.Count)+" of your solution: correct code was removed here")
+			continue
+		}
+		hints = append(hints, noises_lib.LineRange(change.Line, change.Count)+" of your solution: this change is not needed, the code here was correct")
+	}
+	return hints
+}
+
+// ProcessSubmission оценивает решение задачи с шумами
+func ProcessSubmission(ctx context.Context, taskAlias string, originalCode string, noisedCode string, userSolutionCode string, opts llm.Options, logger *slog.Logger) (*LLMResponse, error) {
+	prompt, err := prompts.RenderAt(prompts.NoisesCheck, opts.PromptVersion, prompts.Vars{
+		"OriginalCode": originalCode,
+		"NoisedCode":   noisedCode,
+		"Solution":     userSolutionCode,
+		"Locale":       prompts.DefaultLocale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return &LLMResponse{}, fmt.Errorf("failed to render prompt: %v", err)
 	}
 
-	response, err := client.SendChat(systemPrompt, "Исходный код:\n"+originalCode+"\nЗашумленный код:\n"+noisedCode+"\nРешение пользователя:\n"+userSolutionCode)
+	// Ключ включает код задачи: после перегенерации задачи старые оценки не используются
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.7,
+		Kind:            llm.KindNoisesCheck,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), taskAlias, noisedCode, llm.NormalizeCode(userSolutionCode)},
+		Schema:          responseSchema,
+	}, opts)
 	if err != nil {
-		log.Fatalf("Error sending request: %v", err)
+		// Все модели недоступны — проверка завершается ошибкой, а не падением сервера
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return &LLMResponse{}, fmt.Errorf("failed to send request: %v", err)
 	}
-	fmt.Println("ServerResponse from OpenRouter:", response)
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
 
 	var decodedLLMResponse LLMResponse
-	cleanedResponse := openRouterAPI.CleanLLMResponse(response)
-	fmt.Println("Cleaned response from OpenRouter:", cleanedResponse)
-	err = json.Unmarshal([]byte(cleanedResponse), &decodedLLMResponse)
+	err = json.Unmarshal([]byte(response.Content), &decodedLLMResponse)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			logger.Error("request body is empty")
@@ -260,7 +363,7 @@ func processSubmission(originalCode string, noisedCode string, userSolutionCode
 		}
 	}
 
-	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse))
+	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse), slog.String("model", response.Model))
 
 	return &decodedLLMResponse, nil
 }
@@ -0,0 +1,174 @@
+package output_check
+
+import (
+	"codular-backend/internal/config"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/textdiff"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ClientRequest struct {
+	TaskAlias string `json:"taskAlias" validate:"required"`
+	// Output — вывод программы, как его представляет пользователь
+	Output string `json:"output" validate:"max=65536"`
+}
+
+type ServerResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	SubmissionID int64                      `json:"submissionId"`
+}
+
+func getErrorResponse(msg string) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.Error(msg),
+		SubmissionID: -1,
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		SubmissionID: -1,
+	}
+}
+
+func getOKResponse(submissionID int64) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.OK(),
+		SubmissionID: submissionID,
+	}
+}
+
+// New handles the submission of a predicted output.
+// @Summary Submit predicted output
+// @Description Compares the predicted output with the output recorded by running the program at generation time, using the whitespace normalization chosen for the task (exact, trailing or collapse). The submission is graded before the response is sent. On a mismatch the score is the share of matching lines and the hints contain the first mismatching line and a diff: lines starting with "-" were expected, lines starting with "+" were typed, spaces and tabs in changed lines are shown as · and →.
+// @Tags Output
+// @Accept json
+// @Produce json
+// @Param request body ClientRequest true "Task alias and predicted output"
+// @Success 200 {object} ServerResponse "Submission graded"
+// @Success 200 {object} ServerResponse "Example response" Example({"responseInfo":{"status":"OK"},"submissionId":123})
+// @Failure 400 {object} ServerResponse "Invalid request body, validation error, or not a predict-the-output task"
+// @Failure 401 {object} ServerResponse "Unauthorized"
+// @Failure 404 {object} ServerResponse "Task not found"
+// @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
+// @Router /output/solve [post]
+func New(log *slog.Logger, storage *database.Storage, cfg *config.Config) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.solve.output_check.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest ClientRequest
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(decodedRequest.TaskAlias)
+		if err != nil {
+			if err.Error() == "task not found" {
+				writer.WriteHeader(http.StatusNotFound)
+				render.JSON(writer, request, getErrorResponse("task not found"))
+				return
+			}
+			log.Error("failed to get task details", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		if taskDetails.Type != "output" {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("task is not a predict-the-output task"))
+			return
+		}
+
+		answers, err := storage.GetCodeAnswers(decodedRequest.TaskAlias)
+		if err != nil || len(answers) != 1 {
+			log.Error("failed to get expected output", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		// Сохранение посылки
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, []string{decodedRequest.Output})
+		if err != nil {
+			log.Error("failed to save submission", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		log = log.With(slog.Int64("submission_id", submissionID))
+
+		// Сравнение занимает микросекунды, поэтому выполняется до ответа
+		normalization := taskDetails.OutputNormalization
+		if normalization == "" {
+			normalization = cfg.OutputTasks.Normalization
+		}
+		result := textdiff.Compare(answers[0], decodedRequest.Output, normalization, cfg.OutputTasks.MaxDiffLines)
+		if result.Equal {
+			err = storage.UpdateSubmissionStatusToSuccess(submissionID, 100)
+		} else {
+			hints := []string{
+				"output differs from the expected one starting at line " + strconv.Itoa(result.FirstMismatch),
+				strings.Join(result.Diff, "\n"),
+			}
+			err = storage.UpdateSubmissionStatusToFailedWithHints(submissionID, hints, result.Score)
+		}
+		if err != nil {
+			log.Error("failed to save submission result", sl.Err(err))
+			if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+				log.Error("failed to set failed status", sl.Err(err))
+			}
+		}
+
+		log.Info("output submission graded", slog.Bool("equal", result.Equal), slog.Int("score", result.Score))
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(submissionID))
+	}
+}
@@ -0,0 +1,220 @@
+package parsons_check
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/parsons"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type ClientRequest struct {
+	TaskAlias string `json:"taskAlias" validate:"required"`
+	// Order — индексы блоков из codeToSolve в порядке, выбранном пользователем
+	Order []int `json:"order" validate:"required,min=1"`
+}
+
+type ServerResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	SubmissionID int64                      `json:"submissionId"`
+}
+
+func getErrorResponse(msg string) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.Error(msg),
+		SubmissionID: -1,
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		SubmissionID: -1,
+	}
+}
+
+func getOKResponse(submissionID int64) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.OK(),
+		SubmissionID: submissionID,
+	}
+}
+
+// New handles the submission of a block order for a Parsons problem.
+// @Summary Submit block order for a Parsons problem
+// @Description Receives the order of blocks (indexes into the codeToSolve array) chosen by the user and grades it deterministically, without an LLM. Identical blocks are interchangeable. The score is the length of the longest common subsequence with the correct order divided by the length of the longer sequence, so misplaced, missing and distractor blocks all lower it. The submission is graded before the response is sent; its status and hints are available from /submission-status/{submission_id}.
+// @Tags Parsons
+// @Accept json
+// @Produce json
+// @Param request body ClientRequest true "Task alias and block order"
+// @Success 200 {object} ServerResponse "Submission graded"
+// @Success 200 {object} ServerResponse "Example response" Example({"responseInfo":{"status":"OK"},"submissionId":123})
+// @Failure 400 {object} ServerResponse "Invalid request body, validation error, not a Parsons problem, or invalid block index"
+// @Failure 401 {object} ServerResponse "Unauthorized"
+// @Failure 404 {object} ServerResponse "Task not found"
+// @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
+// @Router /parsons/solve [post]
+func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.solve.parsons_check.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest ClientRequest
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(decodedRequest.TaskAlias)
+		if err != nil {
+			if err.Error() == "task not found" {
+				writer.WriteHeader(http.StatusNotFound)
+				render.JSON(writer, request, getErrorResponse("task not found"))
+				return
+			}
+			log.Error("failed to get task details", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		if taskDetails.Type != "parsons" {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("task is not a parsons problem"))
+			return
+		}
+
+		blocks, order, err := loadTask(storage, decodedRequest.TaskAlias)
+		if err != nil {
+			log.Error("failed to load parsons problem", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		// Каждый блок можно использовать один раз
+		seen := make(map[int]bool, len(decodedRequest.Order))
+		submission := make([]string, len(decodedRequest.Order))
+		for i, index := range decodedRequest.Order {
+			if index < 0 || index >= len(blocks) || seen[index] {
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse(fmt.Sprintf("invalid block index at position %d: %d", i+1, index)))
+				return
+			}
+			seen[index] = true
+			submission[i] = strconv.Itoa(index)
+		}
+
+		// Сохранение посылки
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, submission)
+		if err != nil {
+			log.Error("failed to save submission", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		log = log.With(slog.Int64("submission_id", submissionID))
+
+		// Проверка без модели занимает микросекунды, поэтому выполняется до ответа
+		grade := parsons.Check(blocks, order, decodedRequest.Order)
+		if grade.Solved() {
+			err = storage.UpdateSubmissionStatusToSuccess(submissionID, grade.Score)
+		} else {
+			err = storage.UpdateSubmissionStatusToFailedWithHints(submissionID, hints(grade), grade.Score)
+		}
+		if err != nil {
+			log.Error("failed to save submission result", sl.Err(err))
+			if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+				log.Error("failed to set failed status", sl.Err(err))
+			}
+		}
+
+		log.Info("parsons submission graded", slog.Int("score", grade.Score))
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(submissionID))
+	}
+}
+
+// loadTask возвращает блоки задачи и правильный порядок
+func loadTask(storage *database.Storage, alias string) ([]string, []int, error) {
+	code, err := storage.GetSavedTaskCode(alias)
+	if err != nil {
+		return nil, nil, err
+	}
+	var blocks []string
+	if err := json.Unmarshal([]byte(code), &blocks); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode blocks: %v", err)
+	}
+
+	answers, err := storage.GetCodeAnswers(alias)
+	if err != nil {
+		return nil, nil, err
+	}
+	order := make([]int, len(answers))
+	for i, answer := range answers {
+		index, err := strconv.Atoi(answer)
+		if err != nil || index < 0 || index >= len(blocks) {
+			return nil, nil, fmt.Errorf("invalid answer %q", answer)
+		}
+		order[i] = index
+	}
+	return blocks, order, nil
+}
+
+// hints объясняет, что не так с порядком; позиции нумеруются с 1
+func hints(grade parsons.Grade) []string {
+	var result []string
+	for _, position := range grade.Distractors {
+		result = append(result, "block at position "+strconv.Itoa(position+1)+" does not belong to the solution")
+	}
+	for _, position := range grade.Misplaced {
+		result = append(result, "block at position "+strconv.Itoa(position+1)+" is out of order")
+	}
+	if grade.Missing > 0 {
+		result = append(result, strconv.Itoa(grade.Missing)+" block(s) of the solution are missing")
+	}
+	return result
+}
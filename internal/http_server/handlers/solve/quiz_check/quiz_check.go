@@ -0,0 +1,205 @@
+package quiz_check
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/quiz"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type ClientRequest struct {
+	TaskAlias string `json:"taskAlias" validate:"required"`
+	// Answers — выбранные варианты; идентификаторы вопросов и вариантов берутся из /task/{alias}
+	Answers []quiz.Answer `json:"answers" validate:"required,min=1,dive"`
+}
+
+type ServerResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	SubmissionID int64                      `json:"submissionId"`
+}
+
+func getErrorResponse(msg string) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.Error(msg),
+		SubmissionID: -1,
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		SubmissionID: -1,
+	}
+}
+
+func getOKResponse(submissionID int64) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.OK(),
+		SubmissionID: submissionID,
+	}
+}
+
+// New handles the submission of answers to a code quiz.
+// @Summary Submit answers to a code quiz
+// @Description Receives the option chosen for each question (question and option ids as returned by /task/{alias}) and grades the answers deterministically, without an LLM. Ids do not depend on the order in which questions and options were shown, so answers are valid for any attempt. Every question is worth the same share of the score; unanswered questions count as wrong. The submission is graded before the response is sent; its status and hints are available from /submission-status/{submission_id}. Hints name the questions answered incorrectly without revealing the correct options.
+// @Tags Quiz
+// @Accept json
+// @Produce json
+// @Param request body ClientRequest true "Task alias and chosen options"
+// @Success 200 {object} ServerResponse "Submission graded"
+// @Success 200 {object} ServerResponse "Example response" Example({"responseInfo":{"status":"OK"},"submissionId":123})
+// @Failure 400 {object} ServerResponse "Invalid request body, validation error, not a quiz, or invalid question or option id"
+// @Failure 401 {object} ServerResponse "Unauthorized"
+// @Failure 404 {object} ServerResponse "Task not found"
+// @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
+// @Router /quiz/solve [post]
+func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.solve.quiz_check.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest ClientRequest
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(decodedRequest.TaskAlias)
+		if err != nil {
+			if err.Error() == "task not found" {
+				writer.WriteHeader(http.StatusNotFound)
+				render.JSON(writer, request, getErrorResponse("task not found"))
+				return
+			}
+			log.Error("failed to get task details", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		if taskDetails.Type != "quiz" {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("task is not a quiz"))
+			return
+		}
+
+		answers, err := storage.GetCodeAnswers(decodedRequest.TaskAlias)
+		if err != nil {
+			log.Error("failed to get quiz questions", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		questions, err := quiz.Decode(answers)
+		if err != nil {
+			log.Error("failed to decode quiz questions", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		// На каждый вопрос — один ответ
+		seen := make(map[int]bool, len(decodedRequest.Answers))
+		submission := make([]string, len(decodedRequest.Answers))
+		for i, answer := range decodedRequest.Answers {
+			if answer.Question >= len(questions) || seen[answer.Question] {
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse(fmt.Sprintf("invalid question id at position %d: %d", i+1, answer.Question)))
+				return
+			}
+			if answer.Option >= len(questions[answer.Question].Options) {
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse(fmt.Sprintf("invalid option id at position %d: %d", i+1, answer.Option)))
+				return
+			}
+			seen[answer.Question] = true
+			encoded, _ := json.Marshal(answer)
+			submission[i] = string(encoded)
+		}
+
+		// Сохранение посылки
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, submission)
+		if err != nil {
+			log.Error("failed to save submission", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		log = log.With(slog.Int64("submission_id", submissionID))
+
+		// Проверка без модели занимает микросекунды, поэтому выполняется до ответа
+		grade := quiz.Check(questions, decodedRequest.Answers)
+		if grade.Solved() {
+			err = storage.UpdateSubmissionStatusToSuccess(submissionID, grade.Score)
+		} else {
+			err = storage.UpdateSubmissionStatusToFailedWithHints(submissionID, hints(grade), grade.Score)
+		}
+		if err != nil {
+			log.Error("failed to save submission result", sl.Err(err))
+			if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+				log.Error("failed to set failed status", sl.Err(err))
+			}
+		}
+
+		log.Info("quiz submission graded", slog.Int("score", grade.Score))
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(submissionID))
+	}
+}
+
+// hints перечисляет вопросы, на которые дан неверный ответ или не дан ответ; правильные варианты не раскрываются
+func hints(grade quiz.Grade) []string {
+	var result []string
+	for _, q := range grade.Questions {
+		switch q.Status {
+		case quiz.StatusWrong:
+			result = append(result, "wrong answer to the question: "+q.Text)
+		case quiz.StatusUnanswered:
+			result = append(result, "no answer to the question: "+q.Text)
+		}
+	}
+	return result
+}
@@ -1,10 +1,16 @@
 package skips_check
 
 import (
+	"codular-backend/internal/experiments"
+	"codular-backend/internal/hint_ladder"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
 	"codular-backend/internal/storage/database"
-	openRouterAPI "codular-backend/lib/api/openrouter"
 	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/llmjson"
 	"codular-backend/lib/logger/sl"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
@@ -14,10 +20,8 @@ import (
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"io"
-	"log"
 	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 )
 
@@ -41,6 +45,15 @@ type Hints struct {
 	Message string `json:"message"`
 }
 
+// responseSchema — схема ответа LLM при проверке решения
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"status": llmjson.Enum("ok", "error"),
+	"hints": llmjson.Array(llmjson.Object(map[string]*llmjson.Schema{
+		"index":   llmjson.Integer(1, 1000),
+		"message": llmjson.String(),
+	})),
+})
+
 func getErrorResponse(msg string) *ServerResponse {
 	return &ServerResponse{
 		ResponseInfo: response_info.Error(msg),
@@ -64,7 +77,7 @@ func getOKResponse(submissionID int64) *ServerResponse {
 
 // New handles the submission of answers for a skips task.
 // @Summary Submit answers for a skips task
-// @Description Receives user answers for a given task alias, saves the submission, and asynchronously processes it.
+// @Description Receives user answers for a given task alias, saves the submission, and asynchronously processes it. A correct submission scores 100, lowered by hints revealed through POST /task/{alias}/hint before the submission.
 // @Tags Skips
 // @Accept json
 // @Produce json
@@ -72,8 +85,10 @@ func getOKResponse(submissionID int64) *ServerResponse {
 // @Success 200 {object} ServerResponse "Successfully initiated submission processing"
 // @Success 200 {object} ServerResponse "Example response for successful submission" Example({"responseInfo":{"status":"OK"},"submissionId":123})
 // @Failure 400 {object} ServerResponse "Invalid request body or validation error"
+// @Failure 401 {object} ServerResponse "Unauthorized"
 // @Failure 404 {object} ServerResponse "Task not found"
 // @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
 // @Router /skips/solve [post]
 func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
@@ -84,6 +99,15 @@ func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(request.Context())),
 		)
 
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
 		var decodedRequest ClientRequest
 		err := render.DecodeJSON(request.Body, &decodedRequest)
 		if err != nil {
@@ -122,7 +146,7 @@ func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 		}
 
 		// Сохранение посылки
-		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, decodedRequest.Answers)
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, decodedRequest.Answers)
 		if err != nil {
 			log.Error("failed to save submission", sl.Err(err))
 			writer.WriteHeader(http.StatusInternalServerError)
@@ -139,14 +163,14 @@ func New(log *slog.Logger, storage *database.Storage) http.HandlerFunc {
 		render.JSON(writer, request, response)
 
 		// Асинхронная обработка
-		go processSubmissionAsync(log, storage, decodedRequest.TaskAlias, submissionID, decodedRequest.Answers)
+		go processSubmissionAsync(log, storage, decodedRequest.TaskAlias, submissionID, userID, decodedRequest.Answers)
 
 		log.Info("submission processing initiated", slog.Int64("submission_id", submissionID))
 	}
 }
 
 // processTaskAsync асинхронно обрабатывает задачу и сохраняет результат
-func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAlias string, submissionID int64, userAnswers []string) {
+func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAlias string, submissionID, userID int64, userAnswers []string) {
 	log = log.With(slog.Int64("submission_id", submissionID))
 
 	correctAnswers, err := storage.GetCodeAnswers(taskAlias)
@@ -169,7 +193,11 @@ func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAli
 		}
 	}
 
-	llmResponse, err := processSubmission(correctAnswers, userAnswers, skipsCode, log)
+	opts := llm.Options{UserID: userID, Endpoint: llm.EndpointSkipsSolve, TaskAlias: taskAlias, SubmissionID: submissionID}
+	if variantID := experiments.Apply(prompts.SkipsCheck, userID, &opts, log); variantID != 0 {
+		experiments.RecordSubmission(variantID, submissionID, log)
+	}
+	llmResponse, err := ProcessSubmission(context.Background(), taskAlias, correctAnswers, userAnswers, skipsCode, opts, log)
 	if err != nil {
 		log.Error("Got error while processing submission: " + err.Error())
 		err := storage.UpdateSubmissionStatusToFailed(submissionID)
@@ -191,7 +219,8 @@ func processSubmissionAsync(log *slog.Logger, storage *database.Storage, taskAli
 	log.Info("Processed LLM.", slog.Any("hints", llmResponse.Hints), slog.String("status", llmResponse.Status))
 
 	if llmResponse.Status == "ok" {
-		err = storage.UpdateSubmissionStatusToSuccess(submissionID, 100)
+		// Открытые до отправки подсказки снижают оценку верного решения
+		err = storage.UpdateSubmissionStatusToSuccess(submissionID, hint_ladder.Penalize(storage, submissionID, 100, log))
 		if err != nil {
 			log.Error("Got error while setting submission to success: " + err.Error())
 			err := storage.UpdateSubmissionStatusToFailed(submissionID)
@@ -256,38 +285,41 @@ func encodePrompt(skipsCode string, correctAnswers, userAnswers []string) (strin
 	return string(jsonData), nil
 }
 
-func processSubmission(correctAnswers []string, userAnswers []string, skipsCode string, logger *slog.Logger) (*LLMResponse, error) {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	model := os.Getenv("MODEL")
-	temperature := 0.7
-
-	client := openRouterAPI.NewClient(apiKey, model, temperature)
-
-	prompts, err := openRouterAPI.LoadSystemPrompts("./config/skips_check_prompt.yaml")
+// ProcessSubmission оценивает ответы на задачу с пропусками
+func ProcessSubmission(ctx context.Context, taskAlias string, correctAnswers []string, userAnswers []string, skipsCode string, opts llm.Options, logger *slog.Logger) (*LLMResponse, error) {
+	submission, err := encodePrompt(skipsCode, correctAnswers, userAnswers)
 	if err != nil {
-		log.Fatalf("Error loading system prompts: %v", err)
-	}
-
-	systemPrompt, exists := prompts["system_prompt"]
-	if !exists {
-		log.Fatalf("System prompt not found in the YAML file")
+		return &LLMResponse{}, err
 	}
 
-	userPrompt, err := encodePrompt(skipsCode, correctAnswers, userAnswers)
+	prompt, err := prompts.RenderAt(prompts.SkipsCheck, opts.PromptVersion, prompts.Vars{
+		"Submission": submission,
+		"Locale":     prompts.DefaultLocale,
+	})
 	if err != nil {
-		return &LLMResponse{}, err
+		logger.Error("failed to render prompt", sl.Err(err))
+		return &LLMResponse{}, fmt.Errorf("failed to render prompt: %v", err)
 	}
 
-	response, err := client.SendChat(systemPrompt, userPrompt)
+	// Ключ включает код задачи: после перегенерации задачи старые оценки не используются
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.7,
+		Kind:            llm.KindSkipsCheck,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), taskAlias, submission},
+		Schema:          responseSchema,
+	}, opts)
 	if err != nil {
-		log.Fatalf("Error sending request: %v", err)
+		// Все модели недоступны — проверка завершается ошибкой, а не падением сервера
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return &LLMResponse{}, fmt.Errorf("failed to send request: %v", err)
 	}
-	fmt.Println("ServerResponse from OpenRouter:", response)
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
 
 	var decodedLLMResponse LLMResponse
-	cleanedResponse := openRouterAPI.CleanLLMResponse(response)
-	fmt.Println("Cleaned response from OpenRouter:", cleanedResponse)
-	err = json.Unmarshal([]byte(cleanedResponse), &decodedLLMResponse)
+	err = json.Unmarshal([]byte(response.Content), &decodedLLMResponse)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			logger.Error("request body is empty")
@@ -298,7 +330,7 @@ func processSubmission(correctAnswers []string, userAnswers []string, skipsCode
 		}
 	}
 
-	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse))
+	logger.Info("LLM response body was decoded", slog.Any("decodedLLMResponse", decodedLLMResponse), slog.String("model", response.Model))
 
 	return &decodedLLMResponse, nil
 }
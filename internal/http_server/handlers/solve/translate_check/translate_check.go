@@ -0,0 +1,344 @@
+package translate_check
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/experiments"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/sandbox"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/textdiff"
+	"codular-backend/lib/translate"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxStderr — сколько stderr показывается в подсказке
+const maxStderr = 1000
+
+type ClientRequest struct {
+	TaskAlias string `json:"taskAlias" validate:"required"`
+	// Code — программа на целевом языке
+	Code string `json:"code" validate:"required"`
+}
+
+type ServerResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	SubmissionID int64                      `json:"submissionId"`
+}
+
+type LLMResponse struct {
+	Score int      `json:"score"`
+	Hints []string `json:"hints"`
+}
+
+// responseSchema — схема ответа LLM при проверке эквивалентности
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"score": llmjson.Integer(0, 100),
+	"hints": llmjson.Array(llmjson.String()),
+})
+
+func getErrorResponse(msg string) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.Error(msg),
+		SubmissionID: -1,
+	}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.ValidationError(validationErrors),
+		SubmissionID: -1,
+	}
+}
+
+func getOKResponse(submissionID int64) *ServerResponse {
+	return &ServerResponse{
+		ResponseInfo: response_info.OK(),
+		SubmissionID: submissionID,
+	}
+}
+
+// New handles the submission of a translated program.
+// @Summary Submit a translation
+// @Description Receives the program written in the task's target language, saves the submission and grades it asynchronously. If the task has tests and the sandbox supports the target language, the program is run on every test input and its output is compared with the output of the original program (trailing whitespace ignored by default); the score is the share of passed tests, and hints name the failed tests with a diff of the first one. A program that does not compile gets score 0 and the compiler output as a hint. Tasks without tests, and submissions the sandbox cannot run, are graded by an LLM equivalence check with hints. Status, score and hints are available from /submission-status/{submission_id}. Submissions are rejected while the user's LLM usage quota is exhausted.
+// @Tags Translate
+// @Accept json
+// @Produce json
+// @Param request body ClientRequest true "Task alias and translated program"
+// @Success 200 {object} ServerResponse "Successfully initiated submission processing"
+// @Success 200 {object} ServerResponse "Example response" Example({"responseInfo":{"status":"OK"},"submissionId":123})
+// @Failure 400 {object} ServerResponse "Invalid request body, validation error, or not a translate task"
+// @Failure 401 {object} ServerResponse "Unauthorized"
+// @Failure 404 {object} ServerResponse "Task not found"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
+// @Failure 500 {object} ServerResponse "Internal server error"
+// @Security Bearer
+// @Router /translate/solve [post]
+func New(log *slog.Logger, storage *database.Storage, cfg *config.Config, box *sandbox.Sandbox) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		const functionPath = "internal.http_server.handlers.solve.translate_check.New"
+
+		log := log.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", middleware.GetReqID(request.Context())),
+		)
+
+		// Извлечение user_id из контекста
+		userID, ok := request.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			writer.WriteHeader(http.StatusUnauthorized)
+			render.JSON(writer, request, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var decodedRequest ClientRequest
+		err := render.DecodeJSON(request.Body, &decodedRequest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("empty request"))
+				return
+			} else {
+				log.Error("failed to decode request body", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getErrorResponse("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(decodedRequest); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				writer.WriteHeader(http.StatusBadRequest)
+				render.JSON(writer, request, getValidationErrorResponse(validationErrs))
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(decodedRequest.TaskAlias)
+		if err != nil {
+			if err.Error() == "task not found" {
+				writer.WriteHeader(http.StatusNotFound)
+				render.JSON(writer, request, getErrorResponse("task not found"))
+				return
+			}
+			log.Error("failed to get task details", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+		if taskDetails.Type != "translate" {
+			writer.WriteHeader(http.StatusBadRequest)
+			render.JSON(writer, request, getErrorResponse("task is not a translate task"))
+			return
+		}
+
+		// Сохранение посылки
+		submissionID, err := storage.SavePendingSubmission(decodedRequest.TaskAlias, userID, []string{decodedRequest.Code})
+		if err != nil {
+			log.Error("failed to save submission", sl.Err(err))
+			writer.WriteHeader(http.StatusInternalServerError)
+			render.JSON(writer, request, getErrorResponse("internal server error"))
+			return
+		}
+
+		writer.WriteHeader(http.StatusOK)
+		render.JSON(writer, request, getOKResponse(submissionID))
+
+		// Асинхронная проверка: запуск в песочнице занимает секунды
+		go processSubmissionAsync(log, storage, cfg, box, decodedRequest.TaskAlias, taskDetails, submissionID, userID, decodedRequest.Code)
+
+		log.Info("submission processing initiated", slog.Int64("submission_id", submissionID))
+	}
+}
+
+// processSubmissionAsync проверяет решение запуском на тестах, а если это невозможно — моделью
+func processSubmissionAsync(log *slog.Logger, storage *database.Storage, cfg *config.Config, box *sandbox.Sandbox, taskAlias string, taskDetails database.TaskDetails, submissionID, userID int64, code string) {
+	log = log.With(slog.Int64("submission_id", submissionID))
+
+	setFailed := func() {
+		if err := storage.UpdateSubmissionStatusToFailed(submissionID); err != nil {
+			log.Error("failed to set failed status", sl.Err(err))
+		}
+	}
+
+	sourceLanguage, err := storage.GetProgrammingLanguageNameById(taskDetails.ProgrammingLanguageID)
+	if err != nil {
+		log.Error("failed to get source language", sl.Err(err))
+		setFailed()
+		return
+	}
+	targetLanguage, err := storage.GetProgrammingLanguageNameById(taskDetails.TargetLanguageID)
+	if err != nil {
+		log.Error("failed to get target language", sl.Err(err))
+		setFailed()
+		return
+	}
+
+	storedAnswers, err := storage.GetCodeAnswers(taskAlias)
+	if err != nil {
+		log.Error("failed to get tests", sl.Err(err))
+		setFailed()
+		return
+	}
+	tests, err := translate.Decode(storedAnswers)
+	if err != nil {
+		log.Error("failed to decode tests", sl.Err(err))
+		setFailed()
+		return
+	}
+
+	var score int
+	var hints []string
+	graded := false
+	if len(tests) > 0 && box.Supports(targetLanguage) {
+		score, hints, err = RunTests(context.Background(), box, cfg.OutputTasks, targetLanguage, code, tests)
+		if err != nil {
+			// Песочница недоступна — решение проверит модель
+			log.Error("failed to run tests in sandbox", sl.Err(err))
+		} else {
+			graded = true
+			log.Info("translate submission graded by tests", slog.Int("score", score), slog.Int("tests", len(tests)))
+		}
+	}
+
+	if !graded {
+		opts := llm.Options{UserID: userID, Endpoint: llm.EndpointTranslateSolve, TaskAlias: taskAlias, SubmissionID: submissionID}
+		if variantID := experiments.Apply(prompts.TranslateCheck, userID, &opts, log); variantID != 0 {
+			experiments.RecordSubmission(variantID, submissionID, log)
+		}
+		llmResponse, err := ProcessSubmission(context.Background(), taskAlias, taskDetails.UserOriginalCode, sourceLanguage, targetLanguage, code, opts, log)
+		if err != nil {
+			log.Error("failed to check submission with llm", sl.Err(err))
+			setFailed()
+			return
+		}
+		score, hints = llmResponse.Score, llmResponse.Hints
+		log.Info("translate submission graded by llm", slog.Int("score", score))
+	}
+
+	if score >= 100 {
+		err = storage.UpdateSubmissionStatusToSuccess(submissionID, score)
+	} else {
+		err = storage.UpdateSubmissionStatusToFailedWithHints(submissionID, hints, score)
+	}
+	if err != nil {
+		log.Error("failed to save submission result", sl.Err(err))
+		setFailed()
+	}
+}
+
+// RunTests запускает решение на входных данных каждого теста и сравнивает вывод с выводом исходной программы.
+// Возвращает долю пройденных тестов и подсказки; ошибка означает, что песочница не смогла запустить код
+func RunTests(ctx context.Context, box *sandbox.Sandbox, cfg config.OutputTasks, language, code string, tests []translate.Test) (int, []string, error) {
+	var hints []string
+	passed := 0
+	diffShown := false
+	for i, test := range tests {
+		name := "test " + strconv.Itoa(i+1)
+		result, err := box.Run(ctx, language, code, test.Stdin)
+		switch {
+		case errors.Is(err, sandbox.ErrCompile):
+			// Остальные тесты не имеют смысла
+			return 0, []string{"program does not compile: " + truncate(strings.TrimSpace(result.Stderr), maxStderr)}, nil
+		case errors.Is(err, sandbox.ErrTimeout), errors.Is(err, sandbox.ErrOutputLimit):
+			hints = append(hints, name+": "+err.Error())
+			continue
+		case errors.Is(err, sandbox.ErrExitCode):
+			hint := fmt.Sprintf("%s: program exited with code %d", name, result.ExitCode)
+			if stderr := strings.TrimSpace(result.Stderr); stderr != "" {
+				hint += ": " + truncate(stderr, maxStderr)
+			}
+			hints = append(hints, hint)
+			continue
+		case err != nil:
+			return 0, nil, err
+		}
+
+		comparison := textdiff.Compare(test.Output, result.Stdout, cfg.Normalization, cfg.MaxDiffLines)
+		if comparison.Equal {
+			passed++
+			continue
+		}
+		hints = append(hints, name+": output differs from the original program starting at line "+strconv.Itoa(comparison.FirstMismatch))
+		if !diffShown {
+			hints = append(hints, strings.Join(comparison.Diff, "\n"))
+			diffShown = true
+		}
+	}
+	return 100 * passed / len(tests), hints, nil
+}
+
+// ProcessSubmission просит модель оценить, эквивалентно ли решение исходному коду
+func ProcessSubmission(ctx context.Context, taskAlias, originalCode, sourceLanguage, targetLanguage, solution string, opts llm.Options, logger *slog.Logger) (*LLMResponse, error) {
+	scrubbed, parts, err := llm.ScrubParts(originalCode, solution)
+	if err != nil {
+		logger.Warn("translate submission refused before llm request", sl.Err(err))
+		return nil, err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("translate submission scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
+	}
+
+	prompt, err := prompts.RenderAt(prompts.TranslateCheck, opts.PromptVersion, prompts.Vars{
+		"OriginalCode":   parts[0],
+		"Language":       sourceLanguage,
+		"TargetLanguage": targetLanguage,
+		"Solution":       parts[1],
+		"Locale":         prompts.DefaultLocale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return nil, fmt.Errorf("failed to render prompt: %v", err)
+	}
+
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.7,
+		Kind:            llm.KindTranslateCheck,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), taskAlias, targetLanguage, llm.NormalizeCode(parts[1])},
+		Schema:          responseSchema,
+	}, opts)
+	if err != nil {
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
+
+	var decodedLLMResponse LLMResponse
+	if err := json.Unmarshal([]byte(response.Content), &decodedLLMResponse); err != nil {
+		logger.Error("failed to decode llm response", sl.Err(err))
+		return nil, fmt.Errorf("failed to decode llm response: %v", err)
+	}
+	for i, hint := range decodedLLMResponse.Hints {
+		decodedLLMResponse.Hints[i] = scrubbed.Restore(hint)
+	}
+	return &decodedLLMResponse, nil
+}
+
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return text[:limit] + "…"
+}
@@ -0,0 +1,196 @@
+package task_hint
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/hintladder"
+	"codular-backend/lib/logger/sl"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+type Request struct {
+	// Item — номер пропуска или правки (с 1); без него берётся первый, у которого остались подсказки
+	Item int `json:"item,omitempty" validate:"omitempty,gte=1"`
+}
+
+type Response struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Item         int                        `json:"item,omitempty"`
+	Level        int                        `json:"level,omitempty"`
+	LevelName    string                     `json:"levelName,omitempty"`
+	Hint         string                     `json:"hint,omitempty"`
+	// MaxScore — наибольшая оценка следующих посылок после этой подсказки
+	MaxScore int `json:"maxScore,omitempty"`
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{ResponseInfo: response_info.Error(msg)}
+}
+
+// New открывает пользователю следующую ступень подсказки к пропуску или правке задачи
+// @Summary Reveal the next hint
+// @Description Reveals the next hint for one skip of a skips task or one edit of a noises task. Every skip and edit has a ladder of three hints generated with the task: nudge, pointer and near_answer; each call reveals the next level for the requested item (or, without item, for the first item that still has hidden hints). Revealed hints are recorded per user and lower the maximum score of the user's subsequent submissions: revealing up to nudge, pointer or near_answer costs 20%, 50% or 80% of that item's share of the score. Regenerating the task replaces its hints and resets revealed ones.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param alias path string true "Task alias"
+// @Param request body Request false "Optional item number, starting from 1"
+// @Success 200 {object} Response "Revealed hint and the new maximum score"
+// @Success 200 {object} Response "Example response" Example({"responseInfo":{"status":"OK"},"item":1,"level":2,"levelName":"pointer","hint":"Skip 1: the answer is a function call, 8 characters long.","maxScore":84})
+// @Failure 400 {object} Response "Invalid request, the task type has no hints, unknown item, or all hints already revealed"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 404 {object} Response "Task not found or the task has no hints"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /task/{alias}/hint [post]
+func New(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.task_hint.New"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("user ID not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("alias is required"))
+			return
+		}
+
+		// Тело необязательно: пустой запрос — следующая подсказка к первому элементу, где они остались
+		var req Request
+		if err := render.DecodeJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("invalid request body"))
+			return
+		}
+		if err := validator.New().Struct(req); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, &Response{ResponseInfo: response_info.ValidationError(validationErrs)})
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(alias)
+		if err != nil {
+			log.Error("failed to get task details", sl.Err(err))
+			if err.Error() == "task not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, getErrorResponse("task not found"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if taskDetails.Type != "skips" && taskDetails.Type != "noises" {
+			log.Error("hints requested for unsupported task type", slog.String("type", taskDetails.Type))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("hints are available only for skips and noises tasks"))
+			return
+		}
+
+		encoded, err := storage.GetTaskHintLadders(taskDetails.TaskID)
+		if err != nil {
+			log.Error("failed to get hint ladders", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		ladders, err := hintladder.Decode(encoded)
+		if err != nil {
+			log.Error("failed to decode hint ladders", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		// Задачи, созданные до лестниц подсказок, их не имеют
+		if len(ladders) == 0 {
+			log.Info("task has no hint ladders", slog.String("task_alias", alias))
+			w.WriteHeader(http.StatusNotFound)
+			render.JSON(w, r, getErrorResponse("task has no hints"))
+			return
+		}
+
+		levels, err := storage.GetUsedHintLevels(taskDetails.TaskID, userID)
+		if err != nil {
+			log.Error("failed to get used hints", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		item := req.Item
+		if item == 0 {
+			for i := 1; i <= len(ladders); i++ {
+				if levels[i] < hintladder.MaxLevel {
+					item = i
+					break
+				}
+			}
+			if item == 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, getErrorResponse("all hints are already revealed"))
+				return
+			}
+		}
+		if item > len(ladders) {
+			log.Error("hint item out of range", slog.Int("item", item), slog.Int("items", len(ladders)))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("item not found"))
+			return
+		}
+		if levels[item] >= hintladder.MaxLevel {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("all hints for this item are already revealed"))
+			return
+		}
+
+		level := levels[item] + 1
+		levels[item] = level
+		maxScore := hintladder.MaxScore(len(ladders), levels)
+
+		// Повторный запрос той же ступени (например, двойной клик) возвращает её ещё раз без новой записи
+		if _, err := storage.RecordHintUsage(taskDetails.TaskID, userID, item, level, maxScore); err != nil {
+			log.Error("failed to record hint usage", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		log.Info("hint revealed", slog.String("task_alias", alias), slog.Int64("user_id", userID), slog.Int("item", item), slog.Int("level", level), slog.Int("max_score", maxScore))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{
+			ResponseInfo: response_info.OK(),
+			Item:         item,
+			Level:        level,
+			LevelName:    hintladder.LevelName(level),
+			Hint:         ladders[item-1].Hint(level),
+			MaxScore:     maxScore,
+		})
+	}
+}
@@ -0,0 +1,423 @@
+package tutor_chat
+
+import (
+	"codular-backend/internal/config"
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/prompts"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/hintladder"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyLimit — сколько последних сообщений чата возвращается пользователю
+const historyLimit = 200
+
+type AskRequest struct {
+	Message string `json:"message" validate:"required,max=2000"`
+	Locale  string `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
+}
+
+type AskResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	// ReplyID — сообщение наставника, которое появится в истории, когда модель ответит
+	ReplyID int64 `json:"replyId,omitempty"`
+}
+
+type HistoryResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Messages     []database.TutorMessage    `json:"messages"`
+}
+
+type LLMResponse struct {
+	Reply string `json:"reply"`
+}
+
+// responseSchema — схема ответа LLM в чате с наставником
+var responseSchema = llmjson.Object(map[string]*llmjson.Schema{
+	"reply": llmjson.String(),
+})
+
+func getErrorResponse(msg string) *AskResponse {
+	return &AskResponse{ResponseInfo: response_info.Error(msg)}
+}
+
+// Ask принимает вопрос пользователя наставнику по задаче; ответ модели сохраняется в историю асинхронно
+// @Summary Ask the task tutor
+// @Description Sends a follow-up question to the AI tutor of a task. The tutor sees the task code, the user's latest submission with its result and hints, and the hint-ladder hints the user has revealed, and is instructed never to reveal answers verbatim. The question and a pending tutor reply are saved to the conversation history at once; the reply is generated asynchronously and appears in GET /task/{alias}/tutor with status Done (or Failed). Only one reply per task can be pending. Questions are limited per user (tutor.max_messages per tutor.window; 429 with Retry-After when exceeded) and count towards the LLM usage quota.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Param alias path string true "Task alias"
+// @Param request body AskRequest true "Question and optional reply locale"
+// @Success 200 {object} AskResponse "Question saved, reply is being generated"
+// @Success 200 {object} AskResponse "Example response" Example({"responseInfo":{"status":"OK"},"replyId":42})
+// @Failure 400 {object} AskResponse "Invalid request"
+// @Failure 401 {object} AskResponse "Unauthorized"
+// @Failure 404 {object} AskResponse "Task not found"
+// @Failure 409 {object} AskResponse "Previous reply is still being generated"
+// @Failure 429 {object} AskResponse "Too many questions to the tutor; see Retry-After"
+// @Failure 429 {object} my_middleware.QuotaResponse "LLM usage quota exceeded; see resetAt and Retry-After"
+// @Failure 500 {object} AskResponse "Internal server error"
+// @Security Bearer
+// @Router /task/{alias}/tutor [post]
+func Ask(logger *slog.Logger, storage *database.Storage, cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.tutor_chat.Ask"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("user ID not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("alias is required"))
+			return
+		}
+
+		var req AskRequest
+		if err := render.DecodeJSON(r.Body, &req); err != nil {
+			if errors.Is(err, io.EOF) {
+				log.Error("request body is empty")
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, getErrorResponse("empty request"))
+				return
+			}
+			log.Error("failed to decode request body", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("invalid request body"))
+			return
+		}
+		req.Message = strings.TrimSpace(req.Message)
+		if err := validator.New().Struct(req); err != nil {
+			var validationErrs validator.ValidationErrors
+			if errors.As(err, &validationErrs) {
+				log.Error("invalid request", sl.Err(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, &AskResponse{ResponseInfo: response_info.ValidationError(validationErrs)})
+				return
+			}
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(alias)
+		if err != nil {
+			log.Error("failed to get task details", sl.Err(err))
+			if err.Error() == "task not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, getErrorResponse("task not found"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if cfg.Tutor.MaxMessages > 0 {
+			count, resetIn, err := storage.IncrTutorMessages(userID, cfg.Tutor.Window)
+			if err != nil {
+				log.Error("failed to count tutor messages", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, getErrorResponse("internal server error"))
+				return
+			}
+			if count > int64(cfg.Tutor.MaxMessages) {
+				log.Warn("tutor rate limit exceeded", slog.Int64("user_id", userID), slog.Int64("count", count))
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(resetIn.Seconds()))))
+				w.WriteHeader(http.StatusTooManyRequests)
+				render.JSON(w, r, getErrorResponse("too many questions to the tutor, try again later"))
+				return
+			}
+		}
+
+		// Ответ, который ждут дольше ReplyTimeout, брошен (например, инстанс упал) и не мешает новому вопросу
+		questionID, replyID, err := storage.SaveTutorQuestion(taskDetails.TaskID, userID, req.Message, time.Now().UTC().Add(-cfg.Tutor.ReplyTimeout))
+		if errors.Is(err, database.ErrTutorReplyPending) {
+			w.WriteHeader(http.StatusConflict)
+			render.JSON(w, r, getErrorResponse("previous reply is still being generated"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to save tutor question", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, AskResponse{ResponseInfo: response_info.OK(), ReplyID: replyID})
+
+		locale := req.Locale
+		if locale == "" {
+			locale = prompts.DefaultLocale
+		}
+
+		// Асинхронная обработка
+		go processReplyAsync(log, storage, cfg.Tutor, alias, taskDetails, userID, questionID, replyID, req.Message, locale)
+
+		log.Info("tutor question accepted", slog.String("task_alias", alias), slog.Int64("user_id", userID), slog.Int64("reply_id", replyID))
+	}
+}
+
+// History возвращает историю чата пользователя с наставником по задаче
+// @Summary Get the tutor conversation
+// @Description Returns the user's conversation with the AI tutor of a task in chronological order (up to the last 200 messages). Student messages have role "student", tutor replies role "tutor"; a reply is Pending while it is being generated, then Done or Failed.
+// @Tags Tasks
+// @Produce json
+// @Param alias path string true "Task alias"
+// @Success 200 {object} HistoryResponse "Conversation history"
+// @Failure 400 {object} HistoryResponse "Invalid alias"
+// @Failure 401 {object} HistoryResponse "Unauthorized"
+// @Failure 404 {object} HistoryResponse "Task not found"
+// @Failure 500 {object} HistoryResponse "Internal server error"
+// @Security Bearer
+// @Router /task/{alias}/tutor [get]
+func History(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.tutor_chat.History"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("user ID not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, HistoryResponse{ResponseInfo: response_info.Error("unauthorized")})
+			return
+		}
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Error("alias is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, HistoryResponse{ResponseInfo: response_info.Error("alias is required")})
+			return
+		}
+
+		taskDetails, err := storage.GetTaskDetailsByAlias(alias)
+		if err != nil {
+			log.Error("failed to get task details", sl.Err(err))
+			if err.Error() == "task not found" {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, HistoryResponse{ResponseInfo: response_info.Error("task not found")})
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, HistoryResponse{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		messages, err := storage.GetTutorMessages(taskDetails.TaskID, userID, historyLimit)
+		if err != nil {
+			log.Error("failed to get tutor messages", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, HistoryResponse{ResponseInfo: response_info.Error("internal server error")})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, HistoryResponse{ResponseInfo: response_info.OK(), Messages: messages})
+	}
+}
+
+// processReplyAsync запрашивает ответ наставника и сохраняет его вместо ожидающего сообщения
+func processReplyAsync(log *slog.Logger, storage *database.Storage, cfg config.Tutor, alias string, taskDetails database.TaskDetails, userID, questionID, replyID int64, question, locale string) {
+	log = log.With(slog.String("task_alias", alias), slog.Int64("user_id", userID), slog.Int64("reply_id", replyID))
+
+	fail := func() {
+		if err := storage.FailTutorReply(replyID); err != nil {
+			log.Error("failed to set failed status of tutor reply", sl.Err(err))
+		}
+	}
+
+	taskContext, err := buildContext(storage, alias, taskDetails, userID)
+	if err != nil {
+		log.Error("failed to build tutor context", sl.Err(err))
+		fail()
+		return
+	}
+
+	// В историю для модели попадают только завершённые сообщения до текущего вопроса
+	messages, err := storage.GetTutorMessages(taskDetails.TaskID, userID, cfg.HistoryMessages+2)
+	if err != nil {
+		log.Error("failed to get tutor messages", sl.Err(err))
+		fail()
+		return
+	}
+	var history []database.TutorMessage
+	for _, message := range messages {
+		if message.ID < questionID && message.Status == "Done" {
+			history = append(history, message)
+		}
+	}
+	if len(history) > cfg.HistoryMessages {
+		history = history[len(history)-cfg.HistoryMessages:]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ReplyTimeout)
+	defer cancel()
+
+	opts := llm.Options{UserID: userID, Endpoint: llm.EndpointTutor, TaskAlias: alias}
+	reply, err := ProcessQuestion(ctx, taskContext, history, question, locale, opts, log)
+	if err != nil {
+		log.Error("failed to get tutor reply", sl.Err(err))
+		fail()
+		return
+	}
+
+	if err := storage.CompleteTutorReply(replyID, reply); err != nil {
+		log.Error("failed to save tutor reply", sl.Err(err))
+		fail()
+		return
+	}
+	log.Info("tutor reply saved")
+}
+
+// buildContext собирает то, что наставник знает о задаче: код, последнюю посылку и выданные подсказки.
+// Правильные ответы наставнику не передаются
+func buildContext(storage *database.Storage, alias string, taskDetails database.TaskDetails, userID int64) (string, error) {
+	taskCode, err := storage.GetSavedTaskCode(alias)
+	if err != nil {
+		return "", err
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Task type: %s\nDescription: %s\nTask code:\n%s\n", taskDetails.Type, taskDetails.Description, taskCode)
+
+	var hints []string
+	submission, err := storage.GetLatestSubmission(alias, userID)
+	switch {
+	case err != nil && err.Error() == "submission not found":
+		builder.WriteString("Latest submission: none yet\n")
+	case err != nil:
+		return "", err
+	default:
+		fmt.Fprintf(&builder, "Latest submission (%s, score %d):\n", submission.Status, max(submission.Score, 0))
+		for i, part := range submission.Code {
+			if len(submission.Code) > 1 {
+				fmt.Fprintf(&builder, "#%d ", i+1)
+			}
+			builder.WriteString(part)
+			builder.WriteString("\n")
+		}
+		hints = append(hints, submission.Hints...)
+	}
+
+	// Подсказки из лестницы, которые пользователь уже открыл
+	if taskDetails.Type == "skips" || taskDetails.Type == "noises" {
+		encoded, err := storage.GetTaskHintLadders(taskDetails.TaskID)
+		if err != nil {
+			return "", err
+		}
+		ladders, err := hintladder.Decode(encoded)
+		if err != nil {
+			return "", err
+		}
+		if len(ladders) > 0 {
+			levels, err := storage.GetUsedHintLevels(taskDetails.TaskID, userID)
+			if err != nil {
+				return "", err
+			}
+			for item := 1; item <= len(ladders); item++ {
+				for level := 1; level <= levels[item]; level++ {
+					hints = append(hints, ladders[item-1].Hint(level))
+				}
+			}
+		}
+	}
+
+	builder.WriteString("Hints already given:\n")
+	if len(hints) == 0 {
+		builder.WriteString("none\n")
+	}
+	for _, hint := range hints {
+		builder.WriteString("- " + hint + "\n")
+	}
+	return builder.String(), nil
+}
+
+// ProcessQuestion получает ответ наставника на вопрос с учётом контекста задачи и истории беседы
+func ProcessQuestion(ctx context.Context, taskContext string, history []database.TutorMessage, question, locale string, opts llm.Options, logger *slog.Logger) (string, error) {
+	var transcript strings.Builder
+	for _, message := range history {
+		fmt.Fprintf(&transcript, "%s: %s\n", message.Role, message.Content)
+	}
+	if transcript.Len() == 0 {
+		transcript.WriteString("(empty)\n")
+	}
+
+	// Секреты и подозрительный текст из кода задачи, посылки, вопроса и истории не должны попасть к модели
+	scrubbed, parts, err := llm.ScrubParts(taskContext, transcript.String(), question)
+	if err != nil {
+		logger.Warn("tutor context refused before llm request", sl.Err(err))
+		return "", err
+	}
+	if scrubbed.Flagged() {
+		logger.Info("tutor context scrubbed before llm request", slog.Int("redactions", len(scrubbed.Redactions)), slog.Int("injections", len(scrubbed.Injections)))
+	}
+	scrubbedContext, scrubbedHistory, scrubbedQuestion := parts[0], parts[1], parts[2]
+
+	prompt, err := prompts.Render(prompts.TutorChat, prompts.Vars{
+		"Code":     scrubbedContext,
+		"History":  scrubbedHistory,
+		"Question": scrubbedQuestion,
+		"Locale":   locale,
+	})
+	if err != nil {
+		logger.Error("failed to render prompt", sl.Err(err))
+		return "", fmt.Errorf("failed to render prompt: %v", err)
+	}
+
+	response, err := llm.Chat(ctx, llm.Request{
+		System:          prompt.System,
+		User:            prompt.User,
+		Temperature:     0.5,
+		Kind:            llm.KindTutorChat,
+		PromptVersionID: prompt.VersionID,
+		CacheKey:        []string{strconv.FormatInt(prompt.VersionID, 10), scrubbedContext, scrubbedHistory, scrubbedQuestion, locale},
+		Schema:          responseSchema,
+	}, opts)
+	if err != nil {
+		logger.Error("failed to send request to OpenRouter", sl.Err(err))
+		return "", fmt.Errorf("failed to send request: %v", err)
+	}
+	logger.Debug("llm response received", slog.String("model", response.Model), slog.Bool("cached", response.Cached))
+
+	var decodedLLMResponse LLMResponse
+	if err := json.Unmarshal([]byte(response.Content), &decodedLLMResponse); err != nil {
+		logger.Error("failed to decode llm response", sl.Err(err))
+		return "", fmt.Errorf("failed to decode llm response: %v", err)
+	}
+
+	return scrubbed.Restore(decodedLLMResponse.Reply), nil
+}
@@ -0,0 +1,411 @@
+package two_factor
+
+import (
+	my_middleware "codular-backend/internal/http_server/middleware"
+	"codular-backend/internal/login_guard"
+	"codular-backend/internal/storage/database"
+	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/logger/sl"
+	"codular-backend/lib/totp"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	issuer             = "Codular"
+	recoveryCodesCount = 10
+	recoveryCodeLength = 10
+	recoveryAlphabet   = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+type ConfirmRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type Response struct {
+	ResponseInfo    response_info.ResponseInfo `json:"responseInfo"`
+	Secret          string                     `json:"secret,omitempty"`
+	ProvisioningURI string                     `json:"provisioningUri,omitempty"`
+	RecoveryCodes   []string                   `json:"recoveryCodes,omitempty"`
+}
+
+// CodeStorage — методы хранилища, необходимые для проверки второго фактора
+type CodeStorage interface {
+	ConsumeTOTPStep(userID int64, step int64) (bool, error)
+	UseRecoveryCode(userID int64, codeHash string) (bool, error)
+}
+
+func getErrorResponse(msg string) *Response {
+	return &Response{ResponseInfo: response_info.Error(msg)}
+}
+
+func getValidationErrorResponse(validationErrors validator.ValidationErrors) *Response {
+	return &Response{ResponseInfo: response_info.ValidationError(validationErrors)}
+}
+
+// VerifyCode проверяет TOTP-код или одноразовый код восстановления
+func VerifyCode(storage CodeStorage, userID int64, secret, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		// Один и тот же код нельзя использовать повторно
+		return storage.ConsumeTOTPStep(userID, step)
+	}
+	return storage.UseRecoveryCode(userID, hashRecoveryCode(code))
+}
+
+// CheckAttempts отвечает 429, если после неудачных попыток ввода пароля или кода для пользователя
+// действует задержка или блокировка. Попытки считаются вместе со вторым шагом входа, как при входе
+func CheckAttempts(w http.ResponseWriter, r *http.Request, log *slog.Logger, guard *login_guard.Guard, userID int64) bool {
+	wait, err := guard.Check(guard.User(userID), guard.IP(r))
+	if err != nil {
+		log.Error("failed to check login attempts", sl.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		render.JSON(w, r, getErrorResponse("internal server error"))
+		return false
+	}
+	if wait > 0 {
+		log.Warn("re-authentication rejected by brute-force protection", slog.Int64("user_id", userID), slog.Duration("retry_after", wait))
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+		render.JSON(w, r, getErrorResponse("too many attempts, try again later"))
+		return false
+	}
+	return true
+}
+
+// RegisterFailure учитывает неверный пароль или код при повторной аутентификации
+func RegisterFailure(r *http.Request, log *slog.Logger, guard *login_guard.Guard, userID int64) {
+	if err := guard.Fail(guard.User(userID), guard.IP(r)); err != nil {
+		log.Error("failed to register failed attempt", sl.Err(err))
+	}
+}
+
+// ResetAttempts обнуляет счётчик попыток пользователя после успешной повторной аутентификации
+func ResetAttempts(log *slog.Logger, guard *login_guard.Guard, userID int64) {
+	if err := guard.Reset(guard.User(userID)); err != nil {
+		log.Error("failed to reset login attempts", sl.Err(err))
+	}
+}
+
+// Enroll начинает подключение TOTP
+// @Summary Start TOTP enrollment
+// @Description Generates a new TOTP secret and returns it together with an otpauth:// provisioning URI for authenticator apps. Two-factor authentication is enabled only after confirmation.
+// @Tags User
+// @Produce json
+// @Success 200 {object} Response "Enrollment started"
+// @Failure 401 {object} Response "Unauthorized"
+// @Failure 409 {object} Response "Two-factor authentication is already enabled"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /user/2fa/enroll [post]
+func Enroll(logger *slog.Logger, storage *database.Storage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.two_factor.Enroll"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		email, err := storage.GetUserEmailByID(userID)
+		if err != nil {
+			log.Error("failed to get user email", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			log.Error("failed to generate totp secret", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if err := storage.SetPendingTOTPSecret(userID, secret); err != nil {
+			log.Error("failed to save totp secret", sl.Err(err))
+			if errors.Is(err, database.ErrTOTPAlreadyEnabled) {
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, getErrorResponse("two-factor authentication is already enabled"))
+				return
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		log.Info("totp enrollment started", slog.Int64("user_id", userID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{
+			ResponseInfo:    response_info.OK(),
+			Secret:          secret,
+			ProvisioningURI: totp.ProvisioningURI(issuer, email, secret),
+		})
+	}
+}
+
+// Confirm подтверждает подключение TOTP кодом из приложения
+// @Summary Confirm TOTP enrollment
+// @Description Verifies a code from the authenticator app, enables two-factor authentication and returns one-time recovery codes. Recovery codes are shown only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body ConfirmRequest true "TOTP code"
+// @Success 200 {object} Response "Two-factor authentication enabled"
+// @Failure 400 {object} Response "Invalid request or enrollment not started"
+// @Failure 401 {object} Response "Unauthorized or invalid code"
+// @Failure 429 {object} Response "Too many failed attempts; see Retry-After"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /user/2fa/confirm [post]
+func Confirm(logger *slog.Logger, storage *database.Storage, guard *login_guard.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.two_factor.Confirm"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var req ConfirmRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		if !CheckAttempts(w, r, log, guard, userID) {
+			return
+		}
+
+		state, err := storage.GetTwoFactorState(userID)
+		if err != nil {
+			log.Error("failed to get two-factor state", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		if state.Enabled || state.Secret == "" {
+			log.Error("two-factor enrollment not started", slog.Bool("enabled", state.Enabled))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("two-factor enrollment not started"))
+			return
+		}
+
+		step, valid := totp.Validate(state.Secret, req.Code, time.Now())
+		if !valid {
+			log.Error("invalid totp code")
+			RegisterFailure(r, log, guard, userID)
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("invalid code"))
+			return
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			log.Error("failed to generate recovery codes", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		if err := storage.EnableTOTP(userID, step, hashes); err != nil {
+			log.Error("failed to enable totp", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		ResetAttempts(log, guard, userID)
+		log.Info("two-factor authentication enabled", slog.Int64("user_id", userID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK(), RecoveryCodes: codes})
+	}
+}
+
+// Disable выключает двухфакторную аутентификацию после повторной аутентификации
+// @Summary Disable two-factor authentication
+// @Description Disables TOTP after re-authentication with the current password and a valid TOTP or recovery code. Removes the secret and all recovery codes.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param request body DisableRequest true "Current password and TOTP or recovery code"
+// @Success 200 {object} Response "Two-factor authentication disabled"
+// @Failure 400 {object} Response "Invalid request or two-factor authentication is not enabled"
+// @Failure 401 {object} Response "Invalid credentials"
+// @Failure 429 {object} Response "Too many failed attempts; see Retry-After"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /user/2fa/disable [post]
+func Disable(logger *slog.Logger, storage *database.Storage, guard *login_guard.Guard) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const functionPath = "internal.http_server.handlers.two_factor.Disable"
+
+		log := logger.With(
+			slog.String("function_path", functionPath),
+			slog.String("request_id", chi_middleware.GetReqID(r.Context())),
+		)
+
+		userID, ok := r.Context().Value(my_middleware.UserIDKey).(int64)
+		if !ok {
+			log.Error("failed to get user_id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("unauthorized"))
+			return
+		}
+
+		var req DisableRequest
+		if !decodeRequest(w, r, log, &req) {
+			return
+		}
+
+		if !CheckAttempts(w, r, log, guard, userID) {
+			return
+		}
+
+		passwordHash, err := storage.GetPasswordHashByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+			log.Error("invalid password")
+			RegisterFailure(r, log, guard, userID)
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("invalid credentials"))
+			return
+		}
+
+		state, err := storage.GetTwoFactorState(userID)
+		if err != nil {
+			log.Error("failed to get two-factor state", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		if !state.Enabled {
+			log.Error("two-factor authentication is not enabled")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("two-factor authentication is not enabled"))
+			return
+		}
+
+		valid, err := VerifyCode(storage, userID, state.Secret, req.Code)
+		if err != nil {
+			log.Error("failed to verify second factor", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+		if !valid {
+			log.Error("invalid second factor code")
+			RegisterFailure(r, log, guard, userID)
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, getErrorResponse("invalid credentials"))
+			return
+		}
+
+		if err := storage.DisableTOTP(userID); err != nil {
+			log.Error("failed to disable totp", sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, getErrorResponse("internal server error"))
+			return
+		}
+
+		ResetAttempts(log, guard, userID)
+		log.Info("two-factor authentication disabled", slog.Int64("user_id", userID))
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, Response{ResponseInfo: response_info.OK()})
+	}
+}
+
+// decodeRequest декодирует и валидирует тело запроса, при ошибке отправляет ответ клиенту
+func decodeRequest(w http.ResponseWriter, r *http.Request, log *slog.Logger, req interface{}) bool {
+	if err := render.DecodeJSON(r.Body, req); err != nil {
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getErrorResponse("empty request"))
+			return false
+		}
+		log.Error("failed to decode request body", sl.Err(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, getErrorResponse("invalid request body"))
+		return false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			log.Error("invalid request", sl.Err(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, getValidationErrorResponse(validationErrs))
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCodes возвращает коды восстановления для пользователя и их хэши для базы
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = recoveryAlphabet[int(b[j])%len(recoveryAlphabet)]
+		}
+		code := string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
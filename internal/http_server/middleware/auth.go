@@ -1,31 +1,51 @@
 package middleware
 
 import (
+	"codular-backend/internal/llm_quota"
+	"codular-backend/internal/storage/database"
 	response_info "codular-backend/lib/api/response"
+	"codular-backend/lib/api_token"
 	"codular-backend/lib/logger/sl"
 	"context"
-	"fmt"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/golang-jwt/jwt/v5"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type UserIDKeyType string
 
 const UserIDKey UserIDKeyType = "user_id"
 
-// AuthMiddleware проверяет JWT access-токен
-func AuthMiddleware(jwtSecret string, log *slog.Logger) func(next http.Handler) http.Handler {
+// ScopesKey хранит области действия персонального токена; для JWT-сессий значение отсутствует
+const ScopesKey UserIDKeyType = "scopes"
+
+type TokenStorage interface {
+	ValidateToken(token, tokenType string) (int64, bool, error)
+	GetActiveAPIToken(tokenHash string) (database.APIToken, error)
+	TouchAPIToken(tokenID int64) error
+}
+
+// TokenVerifier предоставляет ключи проверки подписи JWT (см. jwt_keys.Manager)
+type TokenVerifier interface {
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	ValidMethods() []string
+}
+
+// AuthMiddleware проверяет JWT access-токен или персональный токен доступа
+func AuthMiddleware(verifier TokenVerifier, storage TokenStorage, log *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			const functionPath = "internal.http_server.middleware.AuthMiddleware"
 
-			log = log.With(
+			log := log.With(
 				slog.String("function_path", functionPath),
-				slog.String("request_id", r.Context().Value(chiMiddleware.RequestIDKey).(string)),
+				slog.String("request_id", chiMiddleware.GetReqID(r.Context())),
 			)
 
 			authHeader := r.Header.Get("Authorization")
@@ -45,12 +65,27 @@ func AuthMiddleware(jwtSecret string, log *slog.Logger) func(next http.Handler)
 
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			// Персональные токены доступа проверяются по хэшу в базе
+			if api_token.IsAPIToken(tokenString) {
+				apiToken, err := storage.GetActiveAPIToken(api_token.Hash(tokenString))
+				if err != nil {
+					log.Error("failed to validate api token", sl.Err(err))
+					w.WriteHeader(http.StatusUnauthorized)
+					render.JSON(w, r, response_info.Error("invalid or expired token"))
+					return
 				}
-				return []byte(jwtSecret), nil
-			})
+
+				if err := storage.TouchAPIToken(apiToken.ID); err != nil {
+					log.Warn("failed to update api token usage", sl.Err(err))
+				}
+
+				ctx := context.WithValue(r.Context(), UserIDKey, apiToken.UserID)
+				ctx = context.WithValue(ctx, ScopesKey, apiToken.Scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			token, err := jwt.Parse(tokenString, verifier.Keyfunc, jwt.WithValidMethods(verifier.ValidMethods()))
 			if err != nil {
 				log.Error("failed to parse token", sl.Err(err))
 				w.WriteHeader(http.StatusUnauthorized)
@@ -67,6 +102,22 @@ func AuthMiddleware(jwtSecret string, log *slog.Logger) func(next http.Handler)
 					return
 				}
 
+				// Служебные токены (например, challenge второго шага входа) не дают доступа к API
+				if _, hasPurpose := claims["purpose"]; hasPurpose {
+					log.Error("token is not an access token")
+					w.WriteHeader(http.StatusUnauthorized)
+					render.JSON(w, r, response_info.Error("invalid token"))
+					return
+				}
+
+				// Access-токен должен быть выдан и не отозван (смена пароля, удаление аккаунта)
+				if _, valid, err := storage.ValidateToken(tokenString, "access"); err != nil || !valid {
+					log.Error("access token is revoked", sl.Err(err))
+					w.WriteHeader(http.StatusUnauthorized)
+					render.JSON(w, r, response_info.Error("invalid or expired token"))
+					return
+				}
+
 				// Добавление user_id в контекст
 				ctx := context.WithValue(r.Context(), UserIDKey, int64(userID))
 				next.ServeHTTP(w, r.WithContext(ctx))
@@ -78,3 +129,125 @@ func AuthMiddleware(jwtSecret string, log *slog.Logger) func(next http.Handler)
 		})
 	}
 }
+
+// RequireScope пропускает JWT-сессии и персональные токены с нужной областью действия
+func RequireScope(scope string, log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, isAPIToken := r.Context().Value(ScopesKey).([]string)
+			if isAPIToken && !api_token.HasScope(scopes, scope) {
+				log.Error("api token is missing required scope",
+					slog.String("request_id", chiMiddleware.GetReqID(r.Context())),
+					slog.String("scope", scope),
+				)
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response_info.Error("token is missing required scope: "+scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession запрещает доступ по персональным токенам (например, к управлению самими токенами)
+func RequireSession(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, isAPIToken := r.Context().Value(ScopesKey).([]string); isAPIToken {
+				log.Error("endpoint requires an interactive session",
+					slog.String("request_id", chiMiddleware.GetReqID(r.Context())),
+				)
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response_info.Error("this endpoint is not available for api tokens"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type RoleStorage interface {
+	GetUserRole(userID int64) (string, error)
+}
+
+// RequireAdmin пропускает только пользователей с ролью admin
+func RequireAdmin(storage RoleStorage, log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(
+				slog.String("function_path", "internal.http_server.middleware.RequireAdmin"),
+				slog.String("request_id", chiMiddleware.GetReqID(r.Context())),
+			)
+
+			userID, ok := r.Context().Value(UserIDKey).(int64)
+			if !ok {
+				log.Error("failed to get user_id from context")
+				w.WriteHeader(http.StatusUnauthorized)
+				render.JSON(w, r, response_info.Error("unauthorized"))
+				return
+			}
+
+			role, err := storage.GetUserRole(userID)
+			if err != nil {
+				log.Error("failed to get user role", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response_info.Error("internal server error"))
+				return
+			}
+			if role != database.RoleAdmin {
+				log.Error("user is not an admin", slog.Int64("user_id", userID))
+				w.WriteHeader(http.StatusForbidden)
+				render.JSON(w, r, response_info.Error("forbidden"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type QuotaResponse struct {
+	ResponseInfo response_info.ResponseInfo `json:"responseInfo"`
+	Limit        string                     `json:"limit"`
+	ResetAt      time.Time                  `json:"resetAt"`
+}
+
+// RequireLLMQuota отклоняет запуск генерации, если пользователь исчерпал лимит использования LLM
+func RequireLLMQuota(checker *llm_quota.Checker, log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(
+				slog.String("function_path", "internal.http_server.middleware.RequireLLMQuota"),
+				slog.String("request_id", chiMiddleware.GetReqID(r.Context())),
+			)
+
+			userID, ok := r.Context().Value(UserIDKey).(int64)
+			if !ok {
+				log.Error("failed to get user_id from context")
+				w.WriteHeader(http.StatusUnauthorized)
+				render.JSON(w, r, response_info.Error("unauthorized"))
+				return
+			}
+
+			exceeded, err := checker.Check(userID)
+			if err != nil {
+				log.Error("failed to check llm quota", sl.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response_info.Error("internal server error"))
+				return
+			}
+			if exceeded != nil {
+				log.Warn("llm quota exceeded", slog.Int64("user_id", userID), slog.String("limit", exceeded.Limit))
+				retryAfter := int(math.Ceil(time.Until(exceeded.ResetAt).Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				w.WriteHeader(http.StatusTooManyRequests)
+				render.JSON(w, r, QuotaResponse{
+					ResponseInfo: response_info.Error("llm usage quota exceeded: " + exceeded.Limit),
+					Limit:        exceeded.Limit,
+					ResetAt:      exceeded.ResetAt,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package jwt_keys
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/storage/database"
+	"codular-backend/lib/logger/sl"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+// refreshPeriod — как часто менеджер перечитывает ключи из базы и проверяет необходимость ротации
+const refreshPeriod = 10 * time.Minute
+
+type KeyStorage interface {
+	SaveSigningKey(key database.SigningKey) error
+	ListActiveSigningKeys() ([]database.SigningKey, error)
+	DeleteRetiredSigningKeys() error
+}
+
+type signingKey struct {
+	kid       string
+	algorithm string
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// Manager подписывает JWT текущим ключом и проверяет подписи всеми действующими ключами
+type Manager struct {
+	mu      sync.RWMutex
+	keys    []signingKey // новые первыми
+	storage KeyStorage
+	cfg     config.JWT
+	secret  []byte
+}
+
+// JWK — публичный ключ в формате RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// New создаёт менеджер ключей и, если нужно, выпускает первый ключ подписи
+func New(cfg config.JWT, hmacSecret string, storage KeyStorage) (*Manager, error) {
+	switch cfg.Algorithm {
+	case AlgRS256, AlgEdDSA:
+	case AlgHS256:
+		if hmacSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET is required for HS256 signing")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %s", cfg.Algorithm)
+	}
+	if cfg.AcceptHS256 && hmacSecret == "" {
+		return nil, fmt.Errorf("JWT_SECRET is required when accept_hs256 is enabled")
+	}
+
+	m := &Manager{storage: storage, cfg: cfg, secret: []byte(hmacSecret)}
+	if err := m.refresh(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StartRotation периодически перечитывает ключи и выполняет плановую ротацию
+func (m *Manager) StartRotation(log *slog.Logger) {
+	go func() {
+		ticker := time.NewTicker(refreshPeriod)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := m.refresh(); err != nil {
+				log.Error("failed to refresh jwt signing keys", sl.Err(err))
+			}
+		}
+	}()
+}
+
+// Rotate принудительно выпускает новый ключ подписи
+func (m *Manager) Rotate() error {
+	if !m.asymmetric() {
+		return fmt.Errorf("key rotation is not supported for %s", m.cfg.Algorithm)
+	}
+	if err := m.generateKey(); err != nil {
+		return err
+	}
+	return m.load()
+}
+
+// Sign подписывает claims текущим ключом
+func (m *Manager) Sign(claims jwt.MapClaims) (string, error) {
+	if !m.asymmetric() {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.keys) == 0 {
+		return "", fmt.Errorf("no active signing key")
+	}
+	key := m.keys[0]
+	for _, k := range m.keys {
+		if k.algorithm == m.cfg.Algorithm {
+			key = k
+			break
+		}
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.algorithm), claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// Keyfunc возвращает ключ проверки подписи по заголовку kid (для jwt.Parse)
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && (m.cfg.AcceptHS256 || !m.asymmetric()) {
+			return m.secret, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.keys {
+		if key.kid != kid {
+			continue
+		}
+		if token.Method.Alg() != key.algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.private.Public(), nil
+	}
+	return nil, fmt.Errorf("unknown key id: %s", kid)
+}
+
+// ValidMethods перечисляет алгоритмы, которые принимаются при проверке
+func (m *Manager) ValidMethods() []string {
+	methods := []string{AlgRS256, AlgEdDSA}
+	if m.cfg.AcceptHS256 || !m.asymmetric() {
+		methods = append(methods, AlgHS256)
+	}
+	return methods
+}
+
+// JWKS возвращает публичные части всех действующих ключей
+func (m *Manager) JWKS() JWKSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range m.keys {
+		jwk := JWK{KeyID: key.kid, Use: "sig", Algorithm: key.algorithm}
+		switch pub := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func (m *Manager) asymmetric() bool {
+	return m.cfg.Algorithm != AlgHS256
+}
+
+// refresh удаляет устаревшие ключи, выпускает новый при необходимости и перечитывает набор ключей
+func (m *Manager) refresh() error {
+	if err := m.storage.DeleteRetiredSigningKeys(); err != nil {
+		return err
+	}
+	if err := m.load(); err != nil {
+		return err
+	}
+	if !m.asymmetric() {
+		return nil
+	}
+
+	m.mu.RLock()
+	needsRotation := true
+	for _, key := range m.keys {
+		if key.algorithm == m.cfg.Algorithm && time.Since(key.createdAt) < m.cfg.RotationInterval {
+			needsRotation = false
+			break
+		}
+	}
+	m.mu.RUnlock()
+
+	if needsRotation {
+		return m.Rotate()
+	}
+	return nil
+}
+
+func (m *Manager) load() error {
+	stored, err := m.storage.ListActiveSigningKeys()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]signingKey, 0, len(stored))
+	for _, s := range stored {
+		private, err := decodePrivateKey(s.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to decode signing key %s: %v", s.KID, err)
+		}
+		keys = append(keys, signingKey{kid: s.KID, algorithm: s.Algorithm, private: private, createdAt: s.CreatedAt})
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Manager) generateKey() error {
+	var private crypto.Signer
+	var err error
+	switch m.cfg.Algorithm {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("unsupported jwt algorithm: %s", m.cfg.Algorithm)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %v", err)
+	}
+
+	kidBytes := make([]byte, 12)
+	if _, err := rand.Read(kidBytes); err != nil {
+		return fmt.Errorf("failed to generate key id: %v", err)
+	}
+
+	createdAt := time.Now().UTC()
+	return m.storage.SaveSigningKey(database.SigningKey{
+		KID:        base64.RawURLEncoding.EncodeToString(kidBytes),
+		Algorithm:  m.cfg.Algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:  createdAt,
+		RetireAt:   createdAt.Add(m.cfg.RotationInterval + m.cfg.VerificationGracePeriod),
+	})
+}
+
+func decodePrivateKey(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("invalid PEM data")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func signingMethod(algorithm string) jwt.SigningMethod {
+	if algorithm == AlgEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
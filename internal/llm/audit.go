@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"codular-backend/internal/storage/database"
+	"codular-backend/lib/logger/sl"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+)
+
+// auditCleanupPeriod — как часто удаляются устаревшие записи журнала
+const auditCleanupPeriod = time.Hour
+
+// audit записывает обращение к LLM в журнал
+func (s *Service) audit(req Request, opts Options, call database.LLMCall) {
+	if !s.auditCfg.Enabled || s.replay() {
+		return
+	}
+
+	call.Endpoint = opts.Endpoint
+	call.Kind = req.Kind
+	call.TaskAlias = opts.TaskAlias
+	call.SubmissionID = opts.SubmissionID
+	call.PromptVersionID = req.PromptVersionID
+	call.RequestHash = requestHash(req.System, req.User)
+	call.SystemPrompt = s.truncate(req.System)
+	call.UserPrompt = s.truncate(req.User)
+	call.Response = s.truncate(call.Response)
+	if opts.UserID > 0 {
+		userID := opts.UserID
+		call.UserID = &userID
+	}
+
+	if err := s.storage.RecordLLMCall(call); err != nil {
+		s.log.Error("failed to record llm call", sl.Err(err))
+	}
+}
+
+func (s *Service) truncate(text string) string {
+	limit := s.auditCfg.MaxPayloadBytes
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+	// Не разрезаем многобайтовый символ
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
+// StartAuditCleanup периодически удаляет записи журнала старше срока хранения
+func (s *Service) StartAuditCleanup() {
+	if !s.auditCfg.Enabled || s.auditCfg.Retention <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(auditCleanupPeriod)
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			deleted, err := s.storage.DeleteLLMCallsBefore(time.Now().UTC().Add(-s.auditCfg.Retention))
+			if err != nil {
+				s.log.Error("failed to clean up llm calls", sl.Err(err))
+				continue
+			}
+			if deleted > 0 {
+				s.log.Info("old llm calls deleted", slog.Int64("count", deleted))
+			}
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker — предохранитель по моделям: после threshold подряд неудачных вызовов
+// модель пропускается на cooldown, затем получает одну пробную попытку
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	models    map[string]*breakerState
+}
+
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, models: make(map[string]*breakerState)}
+}
+
+// Allow сообщает, можно ли сейчас обращаться к модели
+func (b *breaker) Allow(model string) bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.models[model]
+	if !ok || state.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(state.openUntil) {
+		return false
+	}
+	// Пробная попытка: до её результата остальные запросы модель пропускают
+	state.openUntil = time.Now().Add(b.cooldown)
+	return true
+}
+
+func (b *breaker) Success(model string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.models, model)
+}
+
+func (b *breaker) Failure(model string) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state, ok := b.models[model]
+	if !ok {
+		state = &breakerState{}
+		b.models[model] = state
+	}
+	state.failures++
+	if state.failures >= b.threshold {
+		state.openUntil = time.Now().Add(b.cooldown)
+	}
+}
@@ -0,0 +1,436 @@
+package llm
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/storage/database"
+	openRouterAPI "codular-backend/lib/api/openrouter"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// Виды запросов (используются в ключе кэша и в метриках)
+const (
+	KindSkipsGenerate   = "skips_generate"
+	KindNoisesGenerate  = "noises_generate"
+	KindSkipsCheck      = "skips_check"
+	KindNoisesCheck     = "noises_check"
+	KindBugsGenerate    = "bugs_generate"
+	KindBugsCheck       = "bugs_check"
+	KindQuizGenerate    = "quiz_generate"
+	KindTranslateCheck  = "translate_check"
+	KindExplainGenerate = "explain_generate"
+	KindExplainCheck    = "explain_check"
+	KindSkipsDescribe   = "skips_describe"
+	KindHintsGenerate   = "hints_generate"
+	KindTutorChat       = "tutor_chat"
+)
+
+// Эндпоинты, к которым относится расход токенов
+const (
+	EndpointSkipsGenerate   = "/skips/generate"
+	EndpointNoisesGenerate  = "/noises/generate"
+	EndpointRegenerate      = "/task/{alias}/regenerate"
+	EndpointSkipsSolve      = "/skips/solve"
+	EndpointNoisesSolve     = "/noises/solve"
+	EndpointBugsGenerate    = "/bugs/generate"
+	EndpointBugsSolve       = "/bugs/solve"
+	EndpointQuizGenerate    = "/quiz/generate"
+	EndpointTranslateSolve  = "/translate/solve"
+	EndpointExplainGenerate = "/explain/generate"
+	EndpointExplainSolve    = "/explain/solve"
+	EndpointTutor           = "/task/{alias}/tutor"
+	EndpointEval            = "eval"
+)
+
+type Storage interface {
+	GetLLMCache(key string) (string, bool, error)
+	SetLLMCache(key, value string, ttl time.Duration) error
+	IncrLLMCacheStat(kind string, hit bool) error
+	RecordLLMUsage(usage database.LLMUsage) error
+	RecordLLMCall(call database.LLMCall) error
+	FindRecordedLLMResponse(requestHash, model string) (string, bool, error)
+	DeleteLLMCallsBefore(before time.Time) (int64, error)
+}
+
+// Request — запрос к LLM
+type Request struct {
+	System      string
+	User        string
+	Temperature float64
+	// Kind — вид запроса; пустой Kind отключает кэш
+	Kind string
+	// PromptVersionID — версия промпта, сохраняется в журнал
+	PromptVersionID int64
+	// CacheKey — значимые параметры запроса (версия промпта, код, число пропусков и т.п.);
+	// список моделей добавляется автоматически
+	CacheKey []string
+	// Schema — схема JSON-ответа. Если задана, у провайдера запрашивается структурированный ответ,
+	// а Content содержит извлечённый и проверенный JSON; ответ, не подходящий под схему,
+	// считается ошибкой модели и не кэшируется
+	Schema *llmjson.Schema
+}
+
+// Options — параметры вызова, задаваемые вызывающим обработчиком
+type Options struct {
+	// NoCache — не читать ответ из кэша (например, при перегенерации задачи нужен новый вариант)
+	NoCache bool
+	// UserID и Endpoint — кому и какому эндпоинту засчитывается расход токенов
+	UserID   int64
+	Endpoint string
+	// TaskAlias и SubmissionID связывают запись журнала с задачей или посылкой
+	TaskAlias    string
+	SubmissionID int64
+	// PromptVersion — номер версии промпта вместо активной (0 — активная)
+	PromptVersion int
+	// Models — цепочка моделей вместо настроенной (для сравнения моделей)
+	Models []string
+	// OnProgress получает прогресс потокового ответа; если задан, ответ запрашивается потоком
+	// (при включённом LLM.Streaming). Вызывается из горутины запроса
+	OnProgress func(Progress)
+}
+
+// Progress — состояние потокового ответа. При повторе или переходе к другой модели начинается заново
+type Progress struct {
+	Model string
+	// Chunks — число полученных фрагментов ответа (обычно по одному токену на фрагмент)
+	Chunks int
+	// Content — полученная часть ответа
+	Content string
+}
+
+// Response — ответ LLM
+type Response struct {
+	Content string
+	// Model — модель, которая фактически ответила (для ответа из кэша — модель, ответившая изначально)
+	Model  string
+	Cached bool
+}
+
+// cachedResponse — формат ответа в кэше
+type cachedResponse struct {
+	Model   string `json:"model"`
+	Content string `json:"content"`
+}
+
+// Service отправляет запросы в OpenRouter, перебирая модели по порядку, и кэширует ответы
+type Service struct {
+	storage  Storage
+	provider Provider
+	cfg      config.LLM
+	cacheCfg config.LLMCache
+	auditCfg config.LLMAudit
+	breaker  *breaker
+	log      *slog.Logger
+}
+
+// Default используется обработчиками; инициализируется в main через Init
+var Default *Service
+
+func Init(storage Storage, cfg config.LLM, cacheCfg config.LLMCache, auditCfg config.LLMAudit, log *slog.Logger) {
+	Default = New(storage, cfg, cacheCfg, auditCfg, log)
+}
+
+func New(storage Storage, cfg config.LLM, cacheCfg config.LLMCache, auditCfg config.LLMAudit, log *slog.Logger) *Service {
+	var provider Provider = openRouterProvider{}
+	if cfg.Provider == ProviderReplay {
+		log.Warn("llm replay mode: responses are served from the llm_calls journal")
+		provider = replayProvider{storage: storage}
+	}
+	return &Service{
+		storage:  storage,
+		provider: provider,
+		cfg:      cfg,
+		cacheCfg: cacheCfg,
+		auditCfg: auditCfg,
+		breaker:  newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		log:      log,
+	}
+}
+
+func (s *Service) replay() bool {
+	return s.cfg.Provider == ProviderReplay
+}
+
+// Chat отправляет запрос через Default
+func Chat(ctx context.Context, req Request, opts Options) (Response, error) {
+	if Default == nil {
+		return Response{}, fmt.Errorf("llm service is not initialized")
+	}
+	return Default.Chat(ctx, req, opts)
+}
+
+// Models возвращает список моделей в порядке приоритета
+func (s *Service) Models() []string {
+	if len(s.cfg.Models) > 0 {
+		return s.cfg.Models
+	}
+	if model := os.Getenv("MODEL"); model != "" {
+		return []string{model}
+	}
+	return []string{openRouterAPI.DefaultModel}
+}
+
+// Chat возвращает ответ из кэша или отправляет запрос в OpenRouter
+func (s *Service) Chat(ctx context.Context, req Request, opts Options) (Response, error) {
+	models := s.Models()
+	if len(opts.Models) > 0 {
+		models = opts.Models
+	}
+
+	// В режиме replay кэш не используется: ответ должен пройти весь путь разбора заново
+	cacheable := s.cacheCfg.Enabled && req.Kind != "" && !s.replay()
+	key := ""
+	if cacheable {
+		key = cacheKey(req.Kind, strings.Join(models, ","), req.CacheKey)
+		if !opts.NoCache {
+			if response, found := s.readCache(key, req.Schema); found {
+				s.recordStat(req.Kind, true)
+				s.audit(req, opts, database.LLMCall{Model: response.Model, Status: database.LLMCallCached, Response: response.Content})
+				return response, nil
+			}
+			s.recordStat(req.Kind, false)
+		}
+	}
+
+	response, err := s.send(ctx, models, req, opts)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if cacheable {
+		value, err := json.Marshal(cachedResponse{Model: response.Model, Content: response.Content})
+		if err == nil {
+			err = s.storage.SetLLMCache(key, string(value), s.cacheCfg.TTL)
+		}
+		if err != nil {
+			s.log.Warn("failed to write llm cache", sl.Err(err))
+		}
+	}
+	return response, nil
+}
+
+func (s *Service) readCache(key string, schema *llmjson.Schema) (Response, bool) {
+	value, found, err := s.storage.GetLLMCache(key)
+	if err != nil {
+		s.log.Warn("failed to read llm cache", sl.Err(err))
+		return Response{}, false
+	}
+	if !found {
+		return Response{}, false
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal([]byte(value), &cached); err != nil {
+		// Записи старого формата считаются промахом и перезаписываются
+		return Response{}, false
+	}
+	if schema != nil {
+		content, err := llmjson.Extract(cached.Content, schema)
+		if err != nil {
+			return Response{}, false
+		}
+		cached.Content = string(content)
+	}
+	return Response{Content: cached.Content, Model: cached.Model, Cached: true}, true
+}
+
+// send перебирает модели по порядку; модели с сработавшим предохранителем пропускаются
+func (s *Service) send(ctx context.Context, models []string, req Request, opts Options) (Response, error) {
+	var lastErr error
+	for _, model := range models {
+		if !s.breaker.Allow(model) {
+			s.log.Warn("llm model skipped by circuit breaker", slog.String("model", model))
+			continue
+		}
+
+		content, err := s.sendWithRetries(ctx, model, req, opts)
+		if err == nil {
+			s.breaker.Success(model)
+			return Response{Content: content, Model: model}, nil
+		}
+		if ctx.Err() != nil {
+			// Запрос отменён вызывающим — модель в этом не виновата
+			return Response{}, ctx.Err()
+		}
+
+		s.breaker.Failure(model)
+		s.log.Warn("llm model failed, falling back to the next one", slog.String("model", model), sl.Err(err))
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		return Response{}, fmt.Errorf("all llm models are temporarily unavailable")
+	}
+	return Response{}, fmt.Errorf("all llm models failed, last error: %v", lastErr)
+}
+
+// sendWithRetries отправляет запрос одной модели, повторяя его при 429, 5xx и сетевых ошибках.
+// Если у запроса есть схема, возвращается извлечённый из ответа JSON
+func (s *Service) sendWithRetries(ctx context.Context, model string, req Request, opts Options) (string, error) {
+	call := Call{Model: model, System: req.System, User: req.User, Temperature: req.Temperature, Timeout: s.cfg.RequestTimeout}
+	if req.Schema != nil && s.cfg.StructuredOutput {
+		call.ResponseFormat = openRouterAPI.JSONSchemaFormat(schemaName(req.Kind), req.Schema)
+	}
+	streaming := opts.OnProgress != nil && s.cfg.Streaming
+	if streaming {
+		call.IdleTimeout = s.cfg.StreamIdleTimeout
+	}
+
+	for attempt := 0; ; attempt++ {
+		if streaming {
+			call.OnDelta = progressReporter(model, opts.OnProgress)
+		}
+		started := time.Now()
+		content, usage, err := s.provider.Send(ctx, call)
+		record := database.LLMCall{
+			Model:            model,
+			Status:           database.LLMCallOK,
+			LatencyMs:        time.Since(started).Milliseconds(),
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			Cost:             usage.Cost,
+			Response:         content,
+		}
+
+		if err == nil {
+			// Ответы из кэша бесплатны, учитываются только реальные запросы
+			s.recordUsage(opts, model, usage)
+
+			if req.Schema != nil {
+				extracted, extractErr := llmjson.Extract(content, req.Schema)
+				if extractErr != nil {
+					record.Status, record.Error = database.LLMCallInvalid, extractErr.Error()
+					s.audit(req, opts, record)
+					return "", fmt.Errorf("invalid response from %s: %v", model, extractErr)
+				}
+				content = string(extracted)
+			}
+			s.audit(req, opts, record)
+			return content, nil
+		}
+
+		record.Status, record.Error = database.LLMCallError, err.Error()
+		s.audit(req, opts, record)
+
+		var statusErr *openRouterAPI.StatusError
+		if call.ResponseFormat != nil && errors.As(err, &statusErr) && statusErr.UnsupportedResponseFormat() {
+			// Провайдер не поддерживает структурированный ответ — повторяем без него, ответ разберёт llmjson
+			s.log.Info("structured output is not supported, retrying without it", slog.String("model", model))
+			call.ResponseFormat = nil
+			attempt--
+			continue
+		}
+
+		if attempt >= s.cfg.MaxRetries || ctx.Err() != nil {
+			return "", err
+		}
+		delay, retry := s.retryDelay(attempt, err)
+		if !retry {
+			return "", err
+		}
+
+		s.log.Warn("llm request failed, retrying",
+			slog.String("model", model), slog.Int("attempt", attempt+1), slog.Duration("delay", delay), sl.Err(err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// progressReporter накапливает фрагменты ответа и передаёт прогресс в onProgress
+func progressReporter(model string, onProgress func(Progress)) func(string) {
+	var content strings.Builder
+	chunks := 0
+	onProgress(Progress{Model: model})
+	return func(delta string) {
+		content.WriteString(delta)
+		chunks++
+		onProgress(Progress{Model: model, Chunks: chunks, Content: content.String()})
+	}
+}
+
+func (s *Service) recordUsage(opts Options, model string, usage openRouterAPI.Usage) {
+	if s.replay() {
+		return
+	}
+	err := s.storage.RecordLLMUsage(database.LLMUsage{
+		UserID:           opts.UserID,
+		Endpoint:         opts.Endpoint,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             usage.Cost,
+	})
+	if err != nil {
+		s.log.Error("failed to record llm usage", sl.Err(err))
+	}
+}
+
+// retryDelay возвращает задержку перед повтором или false, если повторять не нужно.
+// Retry-After длиннее MaxRetryDelay не ждём — быстрее перейти к следующей модели
+func (s *Service) retryDelay(attempt int, err error) (time.Duration, bool) {
+	if errors.Is(err, ErrNotRecorded) {
+		return 0, false
+	}
+	var statusErr *openRouterAPI.StatusError
+	if errors.As(err, &statusErr) {
+		if !statusErr.Retryable() {
+			return 0, false
+		}
+		if statusErr.RetryAfter > 0 {
+			return statusErr.RetryAfter, statusErr.RetryAfter <= s.cfg.MaxRetryDelay
+		}
+	}
+
+	delay := s.cfg.RetryBaseDelay << attempt
+	if delay <= 0 || delay > s.cfg.MaxRetryDelay {
+		delay = s.cfg.MaxRetryDelay
+	}
+	return delay, true
+}
+
+func (s *Service) recordStat(kind string, hit bool) {
+	if err := s.storage.IncrLLMCacheStat(kind, hit); err != nil {
+		s.log.Warn("failed to record llm cache stat", sl.Err(err))
+	}
+}
+
+// NormalizeCode приводит код к каноническому виду для ключа кэша:
+// единые переводы строк, без хвостовых пробелов и пустых строк по краям
+func NormalizeCode(code string) string {
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func schemaName(kind string) string {
+	if kind == "" {
+		return "response"
+	}
+	return kind
+}
+
+func cacheKey(kind, models string, parts []string) string {
+	hash := sha256.New()
+	for _, part := range append([]string{kind, models}, parts...) {
+		// Длина перед каждой частью исключает коллизии при склейке
+		fmt.Fprintf(hash, "%d:%s;", len(part), part)
+	}
+	return kind + ":" + hex.EncodeToString(hash.Sum(nil))
+}
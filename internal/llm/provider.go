@@ -0,0 +1,83 @@
+package llm
+
+import (
+	openRouterAPI "codular-backend/lib/api/openrouter"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Источники ответов (config.LLM.Provider)
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderReplay     = "replay"
+)
+
+// ErrNotRecorded — в журнале нет ответа модели на такой запрос (режим replay)
+var ErrNotRecorded = errors.New("no recorded response for this request")
+
+// Call — один запрос к конкретной модели
+type Call struct {
+	Model          string
+	System         string
+	User           string
+	Temperature    float64
+	Timeout        time.Duration
+	ResponseFormat *openRouterAPI.ResponseFormat
+	// OnDelta, если задан, включает потоковый ответ и получает каждый его фрагмент
+	OnDelta func(string)
+	// IdleTimeout — сколько потоковый ответ может молчать
+	IdleTimeout time.Duration
+}
+
+// Provider отправляет запрос модели
+type Provider interface {
+	Send(ctx context.Context, call Call) (string, openRouterAPI.Usage, error)
+}
+
+type openRouterProvider struct{}
+
+func (openRouterProvider) Send(ctx context.Context, call Call) (string, openRouterAPI.Usage, error) {
+	client := openRouterAPI.NewClient(os.Getenv("OPENROUTER_API_KEY"), call.Model, call.Temperature)
+	client.Timeout = call.Timeout
+	client.ResponseFormat = call.ResponseFormat
+	if call.OnDelta != nil {
+		client.IdleTimeout = call.IdleTimeout
+		return client.StreamChatContext(ctx, call.System, call.User, call.OnDelta)
+	}
+	return client.SendChatContext(ctx, call.System, call.User)
+}
+
+// replayProvider отвечает записанными в журнал ответами: тот же промпт и та же модель дают тот же ответ,
+// что позволяет детерминированно воспроизвести ошибку разбора или оценки
+type replayProvider struct {
+	storage Storage
+}
+
+func (p replayProvider) Send(ctx context.Context, call Call) (string, openRouterAPI.Usage, error) {
+	response, found, err := p.storage.FindRecordedLLMResponse(requestHash(call.System, call.User), call.Model)
+	if err != nil {
+		return "", openRouterAPI.Usage{}, err
+	}
+	if !found {
+		return "", openRouterAPI.Usage{}, fmt.Errorf("%w (model %s)", ErrNotRecorded, call.Model)
+	}
+	if call.OnDelta != nil {
+		// Записанный ответ отдаётся одним фрагментом
+		call.OnDelta(response)
+	}
+	return response, openRouterAPI.Usage{}, nil
+}
+
+// requestHash идентифицирует запрос по тексту промптов независимо от модели
+func requestHash(system, user string) string {
+	hash := sha256.New()
+	for _, part := range []string{system, user} {
+		fmt.Fprintf(hash, "%d:%s;", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
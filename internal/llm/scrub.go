@@ -0,0 +1,53 @@
+package llm
+
+import (
+	"codular-backend/lib/scrub"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Политики обработки подозрения на prompt injection (config.LLM.InjectionPolicy)
+const (
+	InjectionRefuse   = "refuse"
+	InjectionSanitize = "sanitize"
+	InjectionFlag     = "flag"
+)
+
+// ErrPromptInjection — код не отправлен модели из-за подозрения на prompt injection
+var ErrPromptInjection = errors.New("code contains suspected prompt injection")
+
+// partSeparator разделяет части в ScrubParts. PostgreSQL не хранит NUL в тексте, поэтому в частях
+// он не встречается, а переводы строк не дают шаблонам секретов его захватить
+const partSeparator = "\n\x00\n"
+
+// ScrubCode готовит пользовательский код к отправке модели по настройкам Default:
+// секреты заменяются метками, подозрительный текст заменяется или только отмечается.
+// При политике refuse и найденном подозрении возвращается ErrPromptInjection.
+// Ответ модели восстанавливается через Result.Restore
+func ScrubCode(code string) (*scrub.Result, error) {
+	secrets, policy := true, InjectionSanitize
+	if Default != nil {
+		secrets, policy = Default.cfg.ScrubSecrets, Default.cfg.InjectionPolicy
+	}
+
+	result := scrub.Scrub(code, scrub.Options{Secrets: secrets, SanitizeInjections: policy == InjectionSanitize})
+	if policy == InjectionRefuse && len(result.Injections) > 0 {
+		return result, fmt.Errorf("%w (line %d)", ErrPromptInjection, result.Injections[0].Line)
+	}
+	return result, nil
+}
+
+// ScrubParts скрывает секреты в нескольких частях запроса сразу (код задачи, решение, ответы),
+// чтобы одинаковые значения во всех частях получили одинаковые метки. Возвращает части в том же порядке
+func ScrubParts(parts ...string) (*scrub.Result, []string, error) {
+	result, err := ScrubCode(strings.Join(parts, partSeparator))
+	if err != nil {
+		return result, nil, err
+	}
+	scrubbed := strings.Split(result.Code, partSeparator)
+	if len(scrubbed) != len(parts) {
+		return result, nil, fmt.Errorf("failed to split scrubbed parts: got %d of %d", len(scrubbed), len(parts))
+	}
+	return result, scrubbed, nil
+}
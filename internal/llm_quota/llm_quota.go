@@ -0,0 +1,96 @@
+package llm_quota
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/storage/database"
+	"time"
+)
+
+// Названия лимитов
+const (
+	LimitDailyRequests = "daily_requests"
+	LimitDailyTokens   = "daily_tokens"
+	LimitMonthlyTokens = "monthly_tokens"
+)
+
+type Storage interface {
+	GetUserRole(userID int64) (string, error)
+	GetLLMUsageTotals(userID int64, since time.Time) (database.LLMUsageTotals, error)
+}
+
+// Exceeded описывает превышенный лимит и момент его сброса
+type Exceeded struct {
+	Limit   string
+	ResetAt time.Time
+}
+
+// Checker проверяет лимиты использования LLM по роли пользователя.
+// Учитывается весь расход пользователя (генерация и проверка решений), календарные сутки и месяц — по UTC.
+type Checker struct {
+	storage Storage
+	cfg     config.LLMQuotas
+}
+
+func New(storage Storage, cfg config.LLMQuotas) *Checker {
+	return &Checker{storage: storage, cfg: cfg}
+}
+
+// Limits возвращает лимиты для роли
+func (c *Checker) Limits(role string) config.LLMQuota {
+	if role == database.RoleAdmin {
+		return c.cfg.Admin
+	}
+	return c.cfg.User
+}
+
+// Check возвращает превышенный лимит или nil, если пользователь может запускать генерацию
+func (c *Checker) Check(userID int64) (*Exceeded, error) {
+	role, err := c.storage.GetUserRole(userID)
+	if err != nil {
+		return nil, err
+	}
+	limits := c.Limits(role)
+	now := time.Now().UTC()
+
+	if limits.DailyRequests > 0 || limits.DailyTokens > 0 {
+		daily, err := c.storage.GetLLMUsageTotals(userID, DayStart(now))
+		if err != nil {
+			return nil, err
+		}
+		if limits.DailyRequests > 0 && daily.Requests >= int64(limits.DailyRequests) {
+			return &Exceeded{Limit: LimitDailyRequests, ResetAt: NextDay(now)}, nil
+		}
+		if limits.DailyTokens > 0 && daily.Tokens() >= limits.DailyTokens {
+			return &Exceeded{Limit: LimitDailyTokens, ResetAt: NextDay(now)}, nil
+		}
+	}
+
+	if limits.MonthlyTokens > 0 {
+		monthly, err := c.storage.GetLLMUsageTotals(userID, MonthStart(now))
+		if err != nil {
+			return nil, err
+		}
+		if monthly.Tokens() >= limits.MonthlyTokens {
+			return &Exceeded{Limit: LimitMonthlyTokens, ResetAt: NextMonth(now)}, nil
+		}
+	}
+	return nil, nil
+}
+
+func DayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func NextDay(t time.Time) time.Time {
+	return DayStart(t).AddDate(0, 0, 1)
+}
+
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func NextMonth(t time.Time) time.Time {
+	return MonthStart(t).AddDate(0, 1, 0)
+}
@@ -0,0 +1,125 @@
+package login_guard
+
+import (
+	"codular-backend/internal/config"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type AttemptStorage interface {
+	IncrLoginFailures(subject string, window time.Duration) (int64, error)
+	BlockLogin(subject string, duration time.Duration) error
+	GetLoginBlock(subject string) (time.Duration, error)
+	ResetLoginFailures(subject string) error
+}
+
+// Subject — то, по чему считаются неудачные попытки (email, IP, пользователь)
+type Subject struct {
+	key         string
+	maxAttempts int
+}
+
+// Guard ограничивает перебор паролей: экспоненциальная задержка и временная блокировка
+type Guard struct {
+	storage AttemptStorage
+	cfg     config.LoginProtection
+}
+
+func New(storage AttemptStorage, cfg config.LoginProtection) *Guard {
+	return &Guard{storage: storage, cfg: cfg}
+}
+
+// Account возвращает субъект для email (регистр не учитывается)
+func (g *Guard) Account(email string) Subject {
+	return Subject{key: "account:" + strings.ToLower(strings.TrimSpace(email)), maxAttempts: g.cfg.MaxAttemptsPerAccount}
+}
+
+// User возвращает субъект для пользователя (второй шаг входа)
+func (g *Guard) User(userID int64) Subject {
+	return Subject{key: fmt.Sprintf("user:%d", userID), maxAttempts: g.cfg.MaxAttemptsPerAccount}
+}
+
+// IP возвращает субъект для адреса клиента
+func (g *Guard) IP(r *http.Request) Subject {
+	return Subject{key: "ip:" + clientIP(r, g.cfg.TrustProxyHeaders), maxAttempts: g.cfg.MaxAttemptsPerIP}
+}
+
+// Check возвращает, сколько ещё нужно ждать до следующей попытки (0 — можно пробовать)
+func (g *Guard) Check(subjects ...Subject) (time.Duration, error) {
+	var wait time.Duration
+	for _, subject := range subjects {
+		ttl, err := g.storage.GetLoginBlock(subject.key)
+		if err != nil {
+			return 0, err
+		}
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait, nil
+}
+
+// Fail учитывает неудачную попытку и назначает задержку или блокировку
+func (g *Guard) Fail(subjects ...Subject) error {
+	for _, subject := range subjects {
+		failures, err := g.storage.IncrLoginFailures(subject.key, g.cfg.AttemptWindow)
+		if err != nil {
+			return err
+		}
+
+		block := g.backoff(failures)
+		if subject.maxAttempts > 0 && failures >= int64(subject.maxAttempts) {
+			block = g.cfg.LockoutDuration
+		}
+		if block > 0 {
+			if err := g.storage.BlockLogin(subject.key, block); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reset обнуляет счётчики после успешного входа или разблокировки
+func (g *Guard) Reset(subjects ...Subject) error {
+	for _, subject := range subjects {
+		if err := g.storage.ResetLoginFailures(subject.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backoff возвращает задержку BaseDelay * 2^(n-1), но не больше MaxDelay
+func (g *Guard) backoff(failures int64) time.Duration {
+	if failures <= 0 || g.cfg.BaseDelay <= 0 {
+		return 0
+	}
+	delay := g.cfg.BaseDelay
+	for i := int64(1); i < failures && i < 30; i++ {
+		delay *= 2
+	}
+	if g.cfg.MaxDelay > 0 && delay > g.cfg.MaxDelay {
+		return g.cfg.MaxDelay
+	}
+	return delay
+}
+
+func clientIP(r *http.Request, trustProxyHeaders bool) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
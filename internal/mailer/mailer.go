@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"codular-backend/internal/config"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// Mailer отправляет служебные письма через SMTP.
+// Если SMTP не настроен, письма пишутся в лог (удобно для локальной разработки).
+type Mailer struct {
+	cfg config.Mail
+	log *slog.Logger
+}
+
+func New(cfg config.Mail, log *slog.Logger) *Mailer {
+	return &Mailer{cfg: cfg, log: log}
+}
+
+// Send отправляет текстовое письмо
+func (m *Mailer) Send(to, subject, body string) error {
+	if m.cfg.SMTPHost == "" {
+		m.log.Info("smtp is not configured, email not sent",
+			slog.String("to", to),
+			slog.String("subject", subject),
+			slog.String("body", body),
+		)
+		return nil
+	}
+
+	headers := []string{
+		"From: " + m.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	message := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.SMTPHost)
+	}
+
+	addr := m.cfg.SMTPHost + ":" + strconv.Itoa(m.cfg.SMTPPort)
+	if err := smtp.SendMail(addr, auth, m.cfg.From, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package prompts
+
+import (
+	"bytes"
+	"codular-backend/internal/storage/database"
+	openRouterAPI "codular-backend/lib/api/openrouter"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Имена промптов
+const (
+	SkipsGenerate   = "skips_generate"
+	NoisesGenerate  = "noises_generate"
+	SkipsCheck      = "skips_check"
+	NoisesCheck     = "noises_check"
+	BugsGenerate    = "bugs_generate"
+	BugsCheck       = "bugs_check"
+	QuizGenerate    = "quiz_generate"
+	TranslateCheck  = "translate_check"
+	ExplainGenerate = "explain_generate"
+	ExplainCheck    = "explain_check"
+	SkipsDescribe   = "skips_describe"
+	HintsGenerate   = "hints_generate"
+	TutorChat       = "tutor_chat"
+)
+
+// DefaultLocale — язык, на котором сформулированы промпты из конфигурации
+const DefaultLocale = "ru"
+
+// cacheTTL — как долго активные версии берутся из памяти без обращения к базе
+// (изменения через админку сбрасывают кэш сразу, TTL нужен для остальных инстансов)
+const cacheTTL = time.Minute
+
+// Vars — переменные шаблона. Всегда передаётся Locale; генерация: Code, Language,
+// SkipsCount, NoiseLevel, BugsCount или QuestionsCount; проверка skips: Submission; проверка noises: OriginalCode, NoisedCode, Solution;
+// подсказки bugs: Code, Submission; проверка translate: OriginalCode, Language, TargetLanguage, Solution;
+// проверка explain: Code, Rubric, Submission; описание skips: Code, Language; лестницы подсказок: Code;
+// чат наставника: Code, History, Question.
+type Vars map[string]interface{}
+
+type Rendered struct {
+	VersionID int64
+	Version   int
+	System    string
+	User      string
+}
+
+type Storage interface {
+	SeedPromptVersion(name, systemTemplate, userTemplate string) error
+	ListActivePromptVersions() ([]database.PromptVersion, error)
+	GetPromptVersion(name string, version int) (database.PromptVersion, error)
+}
+
+// seed описывает начальную версию промпта, импортируемую из YAML-файла
+type seed struct {
+	name         string
+	file         string
+	userTemplate string
+}
+
+var seeds = []seed{
+	{SkipsGenerate, "./config/system_prompts.yaml", "Число пропусков = {{.SkipsCount}}\nЯзык описания = {{.Locale}}\n{{.Code}}"},
+	{NoisesGenerate, "./config/noises_gen_prompt.yaml", "Уровень шума = {{.NoiseLevel}}/100\nЯзык описания = {{.Locale}}\n{{.Code}}"},
+	{SkipsCheck, "./config/skips_check_prompt.yaml", "{{.Submission}}"},
+	{NoisesCheck, "./config/noises_check_prompt.yaml", "Исходный код:\n{{.OriginalCode}}\nЗашумленный код:\n{{.NoisedCode}}\nРешение пользователя:\n{{.Solution}}"},
+	{BugsGenerate, "./config/bugs_gen_prompt.yaml", "Число ошибок = {{.BugsCount}}\n{{.Code}}"},
+	{BugsCheck, "./config/bugs_check_prompt.yaml", "Код с ошибками:\n{{.Code}}\nОшибки пользователя:\n{{.Submission}}"},
+	{QuizGenerate, "./config/quiz_gen_prompt.yaml", "Число вопросов = {{.QuestionsCount}}\nЯзык вопросов = {{.Locale}}\n{{.Code}}"},
+	{TranslateCheck, "./config/translate_check_prompt.yaml", "Исходный код ({{.Language}}):\n{{.OriginalCode}}\nРешение пользователя ({{.TargetLanguage}}):\n{{.Solution}}"},
+	{ExplainGenerate, "./config/explain_gen_prompt.yaml", "Язык критериев = {{.Locale}}\n{{.Code}}"},
+	{ExplainCheck, "./config/explain_check_prompt.yaml", "Код:\n{{.Code}}\nКритерии:\n{{.Rubric}}\nОбъяснение студента:\n{{.Submission}}"},
+	{SkipsDescribe, "./config/skips_describe_prompt.yaml", "Язык описания = {{.Locale}}\n{{.Code}}"},
+	{HintsGenerate, "./config/hints_gen_prompt.yaml", "Язык подсказок = {{.Locale}}\n{{.Code}}"},
+	{TutorChat, "./config/tutor_chat_prompt.yaml", "Язык ответа = {{.Locale}}\n{{.Code}}\n=== conversation ===\n{{.History}}\n=== question ===\n{{.Question}}"},
+}
+
+// Names возвращает имена всех известных промптов
+func Names() []string {
+	names := make([]string, 0, len(seeds))
+	for _, s := range seeds {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// Known сообщает, существует ли промпт с таким именем
+func Known(name string) bool {
+	for _, s := range seeds {
+		if s.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type compiled struct {
+	version database.PromptVersion
+	system  *template.Template
+	user    *template.Template
+}
+
+// Manager хранит активные версии промптов в памяти
+type Manager struct {
+	mu       sync.RWMutex
+	storage  Storage
+	active   map[string]compiled
+	loadedAt time.Time
+}
+
+// Default используется обработчиками; инициализируется в main через Init
+var Default *Manager
+
+// Init импортирует промпты из конфигурации (если их ещё нет в базе) и создаёт Default
+func Init(storage Storage) error {
+	for _, s := range seeds {
+		prompts, err := openRouterAPI.LoadSystemPrompts(s.file)
+		if err != nil {
+			return err
+		}
+		systemPrompt, exists := prompts["system_prompt"]
+		if !exists {
+			return fmt.Errorf("system prompt not found in %s", s.file)
+		}
+		if err := storage.SeedPromptVersion(s.name, systemPrompt, s.userTemplate); err != nil {
+			return err
+		}
+	}
+
+	m := &Manager{storage: storage}
+	if err := m.load(); err != nil {
+		return err
+	}
+	Default = m
+	return nil
+}
+
+// Render подставляет переменные в активную версию промпта
+func Render(name string, vars Vars) (Rendered, error) {
+	if Default == nil {
+		return Rendered{}, fmt.Errorf("prompts are not initialized")
+	}
+	return Default.Render(name, vars)
+}
+
+// Render подставляет переменные в активную версию промпта
+func (m *Manager) Render(name string, vars Vars) (Rendered, error) {
+	m.mu.RLock()
+	stale := time.Since(m.loadedAt) > cacheTTL
+	m.mu.RUnlock()
+	if stale {
+		if err := m.load(); err != nil {
+			return Rendered{}, err
+		}
+	}
+
+	m.mu.RLock()
+	c, ok := m.active[name]
+	m.mu.RUnlock()
+	if !ok {
+		return Rendered{}, fmt.Errorf("no active version of prompt %s", name)
+	}
+	return c.render(vars)
+}
+
+// Invalidate сбрасывает кэш; следующий Render перечитает активные версии из базы
+func (m *Manager) Invalidate() {
+	m.mu.Lock()
+	m.loadedAt = time.Time{}
+	m.mu.Unlock()
+}
+
+// RenderAt подставляет переменные в версию version промпта; 0 — активная версия
+func RenderAt(name string, version int, vars Vars) (Rendered, error) {
+	if version == 0 {
+		return Render(name, vars)
+	}
+	if Default == nil {
+		return Rendered{}, fmt.Errorf("prompts are not initialized")
+	}
+	v, err := Default.storage.GetPromptVersion(name, version)
+	if err != nil {
+		return Rendered{}, err
+	}
+	return RenderVersion(v, vars)
+}
+
+// RenderVersion подставляет переменные в произвольную версию (для предпросмотра)
+func RenderVersion(version database.PromptVersion, vars Vars) (Rendered, error) {
+	c, err := compile(version)
+	if err != nil {
+		return Rendered{}, err
+	}
+	return c.render(vars)
+}
+
+// Validate проверяет синтаксис шаблонов
+func Validate(systemTemplate, userTemplate string) error {
+	_, err := compile(database.PromptVersion{SystemTemplate: systemTemplate, UserTemplate: userTemplate})
+	return err
+}
+
+func (m *Manager) load() error {
+	versions, err := m.storage.ListActivePromptVersions()
+	if err != nil {
+		return err
+	}
+
+	active := make(map[string]compiled, len(versions))
+	for _, v := range versions {
+		c, err := compile(v)
+		if err != nil {
+			return fmt.Errorf("prompt %s v%d: %v", v.Name, v.Version, err)
+		}
+		active[v.Name] = c
+	}
+
+	m.mu.Lock()
+	m.active = active
+	m.loadedAt = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+func compile(v database.PromptVersion) (compiled, error) {
+	system, err := template.New("system").Option("missingkey=error").Parse(v.SystemTemplate)
+	if err != nil {
+		return compiled{}, fmt.Errorf("invalid system template: %v", err)
+	}
+	user, err := template.New("user").Option("missingkey=error").Parse(v.UserTemplate)
+	if err != nil {
+		return compiled{}, fmt.Errorf("invalid user template: %v", err)
+	}
+	return compiled{version: v, system: system, user: user}, nil
+}
+
+func (c compiled) render(vars Vars) (Rendered, error) {
+	var system, user bytes.Buffer
+	if err := c.system.Execute(&system, vars); err != nil {
+		return Rendered{}, fmt.Errorf("failed to render system prompt: %v", err)
+	}
+	if err := c.user.Execute(&user, vars); err != nil {
+		return Rendered{}, fmt.Errorf("failed to render user prompt: %v", err)
+	}
+	return Rendered{
+		VersionID: c.version.ID,
+		Version:   c.version.Version,
+		System:    system.String(),
+		User:      user.String(),
+	}, nil
+}
@@ -0,0 +1,266 @@
+package sandbox
+
+import (
+	"bytes"
+	"codular-backend/internal/config"
+	"codular-backend/lib/logger/sl"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Способы запуска
+const (
+	RunnerDocker = "docker"
+	RunnerLocal  = "local"
+)
+
+var (
+	ErrUnsupportedLanguage = errors.New("language is not supported by the sandbox")
+	ErrCompile             = errors.New("compilation failed")
+	ErrTimeout             = errors.New("program did not finish in time")
+	ErrOutputLimit         = errors.New("program output exceeds the limit")
+	ErrExitCode            = errors.New("program exited with an error")
+	// ErrImageMissing — образа языка нет на хосте с docker; во время запуска он не скачивается
+	ErrImageMissing = errors.New("sandbox image is not pulled")
+	// ErrBusy — программа не дождалась очереди на запуск
+	ErrBusy = errors.New("sandbox is busy")
+)
+
+// defaultLanguages используются, если в конфигурации языки не заданы
+var defaultLanguages = map[string]config.SandboxLanguage{
+	"Python": {Image: "python:3.12-alpine", File: "main.py", Run: []string{"python3", "main.py"}},
+	"C++":    {Image: "gcc:14", File: "main.cpp", Compile: []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"}, Run: []string{"./main"}},
+	"Java":   {Image: "eclipse-temurin:21-jdk-alpine", File: "{class}.java", Compile: []string{"javac", "{class}.java"}, Run: []string{"java", "-Xmx128m", "{class}"}},
+}
+
+var publicClassRe = regexp.MustCompile(`public\s+(?:final\s+)?class\s+([A-Za-z_]\w*)`)
+
+// Result — результат запуска программы
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+}
+
+// Sandbox собирает и запускает пользовательский код с ограничениями по времени, выводу и ресурсам
+type Sandbox struct {
+	cfg config.Sandbox
+	// images — образы, которые уже есть на хосте с docker
+	images sync.Map
+	// slots ограничивает число одновременных запусков (MaxConcurrent)
+	slots chan struct{}
+}
+
+func New(cfg config.Sandbox) *Sandbox {
+	if len(cfg.Languages) == 0 {
+		cfg.Languages = defaultLanguages
+	}
+	return &Sandbox{cfg: cfg, slots: make(chan struct{}, max(cfg.MaxConcurrent, 1))}
+}
+
+// StartImagePull в фоне скачивает образы языков, которых ещё нет на хосте с docker.
+// Запуски не скачивают образы сами: иначе загрузка съела бы таймаут программы
+func (s *Sandbox) StartImagePull(logger *slog.Logger) {
+	if s.cfg.Runner != RunnerDocker {
+		return
+	}
+	go func() {
+		for language, lang := range s.cfg.Languages {
+			log := logger.With(slog.String("language", language), slog.String("image", lang.Image))
+			if s.hasImage(context.Background(), lang.Image) {
+				continue
+			}
+			log.Info("pulling sandbox image")
+			if output, err := exec.Command("docker", "pull", "--quiet", lang.Image).CombinedOutput(); err != nil {
+				log.Error("failed to pull sandbox image", sl.Err(err), slog.String("output", strings.TrimSpace(string(output))))
+				continue
+			}
+			s.images.Store(lang.Image, true)
+			log.Info("sandbox image pulled")
+		}
+	}()
+}
+
+// hasImage сообщает, есть ли образ на хосте с docker; найденные образы запоминаются
+func (s *Sandbox) hasImage(ctx context.Context, image string) bool {
+	if _, ok := s.images.Load(image); ok {
+		return true
+	}
+	if err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", image).Run(); err != nil {
+		return false
+	}
+	s.images.Store(image, true)
+	return true
+}
+
+// Supports сообщает, умеет ли песочница запускать код на языке
+func (s *Sandbox) Supports(language string) bool {
+	_, ok := s.cfg.Languages[language]
+	return ok
+}
+
+// Run собирает и запускает код, передавая stdin. Ошибки компиляции, таймаут, превышение вывода
+// и ненулевой код возврата возвращаются как ErrCompile, ErrTimeout, ErrOutputLimit и ErrExitCode
+// вместе с результатом, в котором есть stderr
+func (s *Sandbox) Run(ctx context.Context, language, code, stdin string) (Result, error) {
+	lang, ok := s.cfg.Languages[language]
+	if !ok {
+		return Result{}, ErrUnsupportedLanguage
+	}
+	// Очередь ждёт не дольше ctx; время ожидания не входит в таймауты программы
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return Result{}, fmt.Errorf("%w: %v", ErrBusy, ctx.Err())
+	}
+	class := "Main"
+	if m := publicClassRe.FindStringSubmatch(code); m != nil {
+		class = m[1]
+	}
+	expand := func(args []string) []string {
+		expanded := make([]string, len(args))
+		for i, arg := range args {
+			expanded[i] = strings.ReplaceAll(arg, "{class}", class)
+		}
+		return expanded
+	}
+
+	dir, err := os.MkdirTemp(s.cfg.WorkDir, "codular-sandbox-")
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to create sandbox dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	// В контейнере код выполняется от непривилегированного пользователя
+	if err := os.Chmod(dir, 0o777); err != nil {
+		return Result{}, fmt.Errorf("failed to prepare sandbox dir: %v", err)
+	}
+	file := strings.ReplaceAll(lang.File, "{class}", class)
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(code), 0o644); err != nil {
+		return Result{}, fmt.Errorf("failed to write source: %v", err)
+	}
+
+	if len(lang.Compile) > 0 {
+		result, err := s.exec(ctx, lang, dir, expand(lang.Compile), "", s.cfg.CompileTimeout)
+		if err != nil {
+			if errors.Is(err, ErrExitCode) {
+				return result, ErrCompile
+			}
+			return result, err
+		}
+	}
+	return s.exec(ctx, lang, dir, expand(lang.Run), stdin, s.cfg.Timeout)
+}
+
+// exec выполняет команду в каталоге dir напрямую или в одноразовом контейнере
+func (s *Sandbox) exec(ctx context.Context, lang config.SandboxLanguage, dir string, args []string, stdin string, timeout time.Duration) (Result, error) {
+	// Наличие образа проверяется до таймаута: он ограничивает работу программы, а не подготовку docker
+	if s.cfg.Runner == RunnerDocker && !s.hasImage(ctx, lang.Image) {
+		return Result{}, fmt.Errorf("%w: %s", ErrImageMissing, lang.Image)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch s.cfg.Runner {
+	case RunnerLocal:
+		cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = dir
+	case RunnerDocker:
+		name := containerName()
+		dockerArgs := []string{
+			"run", "--rm", "-i", "--name", name, "--pull", "never",
+			"--network", "none",
+			"--memory", strconv.Itoa(s.cfg.MemoryMB) + "m", "--memory-swap", strconv.Itoa(s.cfg.MemoryMB) + "m",
+			"--pids-limit", strconv.Itoa(s.cfg.MaxProcesses),
+			"--cpus", "1",
+			"--read-only", "--tmpfs", "/tmp:rw,exec,size=64m",
+			"--user", "65534:65534",
+			"--cap-drop", "ALL",
+			"--security-opt", "no-new-privileges",
+			"-v", dir + ":/sandbox", "-w", "/sandbox",
+			lang.Image,
+		}
+		cmd = exec.CommandContext(ctx, "docker", append(dockerArgs, args...)...)
+		// Остановка клиента docker не останавливает контейнер
+		cmd.Cancel = func() error {
+			_ = exec.Command("docker", "kill", name).Run()
+			return cmd.Process.Kill()
+		}
+	default:
+		return Result{}, fmt.Errorf("unknown sandbox runner %q", s.cfg.Runner)
+	}
+	cmd.WaitDelay = time.Second
+
+	// Бесконечный вывод не ждёт таймаута: программа останавливается, как только превышен лимит
+	stdout := &limitedBuffer{limit: s.cfg.MaxOutputBytes, onExceed: cancel}
+	stderr := &limitedBuffer{limit: s.cfg.MaxOutputBytes}
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	started := time.Now()
+	err := cmd.Run()
+	result := Result{Stdout: stdout.String(), Stderr: stderr.String(), Duration: time.Since(started)}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	switch {
+	case stdout.exceeded:
+		return result, ErrOutputLimit
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return result, ErrTimeout
+	case err != nil:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return result, ErrExitCode
+		}
+		return result, fmt.Errorf("failed to run program: %v", err)
+	}
+	return result, nil
+}
+
+func containerName() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "codular-sandbox-" + hex.EncodeToString(b)
+}
+
+// limitedBuffer хранит не больше limit байт и отмечает превышение
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+	onExceed func()
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 && b.buf.Len()+len(p) > b.limit {
+		b.buf.Write(p[:max(b.limit-b.buf.Len(), 0)])
+		if !b.exceeded && b.onExceed != nil {
+			b.onExceed()
+		}
+		b.exceeded = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
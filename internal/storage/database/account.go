@@ -0,0 +1,370 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
+)
+
+type UserExport struct {
+	ExportedAt    time.Time            `json:"exported_at"`
+	Profile       ExportProfile        `json:"profile"`
+	Tasks         []ExportTask         `json:"tasks"`
+	Submissions   []ExportSubmission   `json:"submissions"`
+	HintUsage     []ExportHintUsage    `json:"hint_usage"`
+	TutorMessages []ExportTutorMessage `json:"tutor_messages"`
+	APITokens     []APIToken           `json:"api_tokens"`
+}
+
+type ExportProfile struct {
+	ID               int64     `json:"id"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type ExportTask struct {
+	Alias               string    `json:"alias"`
+	Type                string    `json:"type"`
+	Description         string    `json:"description"`
+	TaskCode            string    `json:"task_code"`
+	OriginalCode        string    `json:"original_code"`
+	Answers             []string  `json:"answers"`
+	ProgrammingLanguage string    `json:"programming_language"`
+	IsPublic            bool      `json:"is_public"`
+	PromptVersionID     *int64    `json:"prompt_version_id,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type ExportSubmission struct {
+	ID             int64     `json:"id"`
+	TaskAlias      string    `json:"task_alias"`
+	SubmissionCode []string  `json:"submission_code"`
+	Status         string    `json:"status"`
+	Score          int       `json:"score"`
+	Hints          []string  `json:"hints"`
+	SubmittedAt    time.Time `json:"submitted_at"`
+}
+
+type ExportHintUsage struct {
+	TaskAlias string    `json:"task_alias"`
+	Item      int       `json:"item"`
+	Level     int       `json:"level"`
+	UsedAt    time.Time `json:"used_at"`
+}
+
+type ExportTutorMessage struct {
+	ID        int64     `json:"id"`
+	TaskAlias string    `json:"task_alias"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UpdatePasswordHash обновляет хэш пароля пользователя
+func (s *Storage) UpdatePasswordHash(userID int64, passwordHash string) error {
+	query := `
+        UPDATE users
+        SET password_hash = $2
+        WHERE id = $1
+    `
+	result, err := s.db.Exec(context.Background(), query, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// DeleteUserTokens удаляет все access/refresh токены пользователя (завершает все сессии)
+func (s *Storage) DeleteUserTokens(userID int64) error {
+	query := `
+        DELETE FROM tokens
+        WHERE user_id = $1
+    `
+	if _, err := s.db.Exec(context.Background(), query, userID); err != nil {
+		return fmt.Errorf("failed to delete user tokens: %v", err)
+	}
+	return nil
+}
+
+// CreateEmailChangeRequest сохраняет запрос на смену email до подтверждения
+func (s *Storage) CreateEmailChangeRequest(userID int64, newEmail, tokenHash string, expiresAt time.Time) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	// Действует только последний запрос пользователя
+	if _, err := tx.Exec(context.Background(), `DELETE FROM email_change_requests WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete previous email change requests: %v", err)
+	}
+
+	query := `
+        INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	if _, err := tx.Exec(context.Background(), query, userID, newEmail, tokenHash, expiresAt, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to save email change request: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange применяет запрос на смену email по токену из письма
+func (s *Storage) ConfirmEmailChange(tokenHash string) (int64, string, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	query := `
+        DELETE FROM email_change_requests
+        WHERE token_hash = $1
+        RETURNING user_id, new_email, expires_at
+    `
+	var userID int64
+	var newEmail string
+	var expiresAt time.Time
+	err = tx.QueryRow(context.Background(), query, tokenHash).Scan(&userID, &newEmail, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", fmt.Errorf("email change request not found")
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get email change request: %v", err)
+	}
+	if time.Now().UTC().After(expiresAt) {
+		return 0, "", fmt.Errorf("email change request expired")
+	}
+
+	_, err = tx.Exec(context.Background(), `UPDATE users SET email = $2 WHERE id = $1`, userID, newEmail)
+	if err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return 0, "", fmt.Errorf("email already exists")
+		}
+		return 0, "", fmt.Errorf("failed to update email: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, "", fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return userID, newEmail, nil
+}
+
+// ExportUserData собирает все данные пользователя для выгрузки
+func (s *Storage) ExportUserData(userID int64) (UserExport, error) {
+	export := UserExport{
+		ExportedAt:    time.Now().UTC(),
+		Tasks:         []ExportTask{},
+		Submissions:   []ExportSubmission{},
+		HintUsage:     []ExportHintUsage{},
+		TutorMessages: []ExportTutorMessage{},
+	}
+
+	queryProfile := `
+        SELECT id, email, role, totp_enabled, created_at
+        FROM users
+        WHERE id = $1
+    `
+	err := s.db.QueryRow(context.Background(), queryProfile, userID).Scan(
+		&export.Profile.ID,
+		&export.Profile.Email,
+		&export.Profile.Role,
+		&export.Profile.TwoFactorEnabled,
+		&export.Profile.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return UserExport{}, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return UserExport{}, fmt.Errorf("failed to get user profile: %v", err)
+	}
+
+	queryTasks := `
+        SELECT aliases.alias, tasks.type, tasks.description, tasks.taskCode, COALESCE(tasks.userOriginalCode, ''),
+               tasks.answers, programming_languages.name, tasks.public, tasks.prompt_version_id, tasks.created_at
+        FROM tasks
+        JOIN aliases ON aliases.task_id = tasks.id
+        JOIN programming_languages ON tasks.programming_language_id = programming_languages.id
+        WHERE tasks.user_id = $1
+        ORDER BY tasks.created_at
+    `
+	rows, err := s.db.Query(context.Background(), queryTasks, userID)
+	if err != nil {
+		return UserExport{}, fmt.Errorf("failed to query user tasks: %v", err)
+	}
+	for rows.Next() {
+		var task ExportTask
+		err := rows.Scan(&task.Alias, &task.Type, &task.Description, &task.TaskCode, &task.OriginalCode,
+			&task.Answers, &task.ProgrammingLanguage, &task.IsPublic, &task.PromptVersionID, &task.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return UserExport{}, fmt.Errorf("failed to scan user task: %v", err)
+		}
+		export.Tasks = append(export.Tasks, task)
+	}
+	rows.Close()
+
+	querySubmissions := `
+        SELECT id, task_alias, COALESCE(submission_code, '{}'), status, COALESCE(score, 0), COALESCE(hints, '{}'), submitted_at
+        FROM submissions
+        WHERE user_id = $1
+        ORDER BY submitted_at
+    `
+	rows, err = s.db.Query(context.Background(), querySubmissions, userID)
+	if err != nil {
+		return UserExport{}, fmt.Errorf("failed to query user submissions: %v", err)
+	}
+	for rows.Next() {
+		var submission ExportSubmission
+		err := rows.Scan(&submission.ID, &submission.TaskAlias, &submission.SubmissionCode, &submission.Status,
+			&submission.Score, &submission.Hints, &submission.SubmittedAt)
+		if err != nil {
+			rows.Close()
+			return UserExport{}, fmt.Errorf("failed to scan user submission: %v", err)
+		}
+		export.Submissions = append(export.Submissions, submission)
+	}
+	rows.Close()
+
+	queryHintUsage := `
+        SELECT aliases.alias, hint_usage.item, hint_usage.level, hint_usage.used_at
+        FROM hint_usage
+        JOIN aliases ON aliases.task_id = hint_usage.task_id
+        WHERE hint_usage.user_id = $1
+        ORDER BY hint_usage.used_at, hint_usage.id
+    `
+	rows, err = s.db.Query(context.Background(), queryHintUsage, userID)
+	if err != nil {
+		return UserExport{}, fmt.Errorf("failed to query user hint usage: %v", err)
+	}
+	for rows.Next() {
+		var usage ExportHintUsage
+		if err := rows.Scan(&usage.TaskAlias, &usage.Item, &usage.Level, &usage.UsedAt); err != nil {
+			rows.Close()
+			return UserExport{}, fmt.Errorf("failed to scan user hint usage: %v", err)
+		}
+		export.HintUsage = append(export.HintUsage, usage)
+	}
+	rows.Close()
+
+	queryTutorMessages := `
+        SELECT tutor_messages.id, aliases.alias, tutor_messages.role, tutor_messages.content,
+               tutor_messages.status, tutor_messages.created_at
+        FROM tutor_messages
+        JOIN aliases ON aliases.task_id = tutor_messages.task_id
+        WHERE tutor_messages.user_id = $1
+        ORDER BY tutor_messages.id
+    `
+	rows, err = s.db.Query(context.Background(), queryTutorMessages, userID)
+	if err != nil {
+		return UserExport{}, fmt.Errorf("failed to query user tutor messages: %v", err)
+	}
+	for rows.Next() {
+		var message ExportTutorMessage
+		err := rows.Scan(&message.ID, &message.TaskAlias, &message.Role, &message.Content,
+			&message.Status, &message.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return UserExport{}, fmt.Errorf("failed to scan user tutor message: %v", err)
+		}
+		export.TutorMessages = append(export.TutorMessages, message)
+	}
+	rows.Close()
+
+	export.APITokens, err = s.ListAPITokens(userID)
+	if err != nil {
+		return UserExport{}, err
+	}
+
+	return export, nil
+}
+
+// DeleteUserCascade удаляет пользователя вместе со всеми его задачами и посылками
+func (s *Storage) DeleteUserCascade(userID int64) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	// Посылки к чужим задачам не удаляются каскадом по users, удаляем их явно
+	if _, err := tx.Exec(context.Background(), `DELETE FROM submissions WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user submissions: %v", err)
+	}
+	// Журнал LLM содержит код пользователя
+	if _, err := tx.Exec(context.Background(), `DELETE FROM llm_calls WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user llm calls: %v", err)
+	}
+	// Задачи, алиасы, токены и коды восстановления удаляются по ON DELETE CASCADE
+	result, err := tx.Exec(context.Background(), `DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// AnonymizeUser обезличивает аккаунт: личные данные и приватные задачи удаляются,
+// публичные задачи и посылки остаются без привязки к личности
+func (s *Storage) AnonymizeUser(userID int64) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	queryUser := `
+        UPDATE users
+        SET email = $2, password_hash = '', role = 'user', totp_secret = NULL, totp_enabled = FALSE, deleted_at = $3
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+	anonymousEmail := fmt.Sprintf("deleted-%d@deleted.invalid", userID)
+	result, err := tx.Exec(context.Background(), queryUser, userID, anonymousEmail, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to anonymize user: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	cleanup := []string{
+		`DELETE FROM tasks WHERE user_id = $1 AND public = FALSE`,
+		`UPDATE submissions SET user_id = NULL WHERE user_id = $1`,
+		`DELETE FROM tokens WHERE user_id = $1`,
+		`DELETE FROM api_tokens WHERE user_id = $1`,
+		`DELETE FROM two_factor_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM email_change_requests WHERE user_id = $1`,
+		`DELETE FROM llm_calls WHERE user_id = $1`,
+		`DELETE FROM hint_usage WHERE user_id = $1`,
+		`DELETE FROM tutor_messages WHERE user_id = $1`,
+	}
+	for _, query := range cleanup {
+		if _, err := tx.Exec(context.Background(), query, userID); err != nil {
+			return fmt.Errorf("failed to anonymize user data: %v", err)
+		}
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
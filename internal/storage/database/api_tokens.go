@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type APIToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIToken сохраняет хэш нового персонального токена доступа
+func (s *Storage) CreateAPIToken(userID int64, name, tokenHash, prefix string, scopes []string, expiresAt *time.Time) (APIToken, error) {
+	query := `
+        INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at
+    `
+	token := APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	err := s.db.QueryRow(context.Background(), query, userID, name, tokenHash, prefix, scopes, expiresAt, time.Now().UTC()).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return APIToken{}, fmt.Errorf("failed to create api token: %v", err)
+	}
+	return token, nil
+}
+
+// ListAPITokens возвращает все персональные токены пользователя без их значений
+func (s *Storage) ListAPITokens(userID int64) ([]APIToken, error) {
+	query := `
+        SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+        FROM api_tokens
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+    `
+	rows, err := s.db.Query(context.Background(), query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api tokens: %v", err)
+	}
+	defer rows.Close()
+
+	tokens := []APIToken{}
+	for rows.Next() {
+		var token APIToken
+		err := rows.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.Scopes, &token.ExpiresAt, &token.LastUsedAt, &token.RevokedAt, &token.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api token: %v", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken отзывает персональный токен пользователя
+func (s *Storage) RevokeAPIToken(userID, tokenID int64) error {
+	query := `
+        UPDATE api_tokens
+        SET revoked_at = $3
+        WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+    `
+	result, err := s.db.Exec(context.Background(), query, tokenID, userID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to revoke api token: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("api token not found")
+	}
+	return nil
+}
+
+// GetActiveAPIToken возвращает действующий (не отозванный и не истёкший) токен по хэшу
+func (s *Storage) GetActiveAPIToken(tokenHash string) (APIToken, error) {
+	query := `
+        SELECT id, user_id, name, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+        FROM api_tokens
+        WHERE token_hash = $1
+    `
+	var token APIToken
+	err := s.db.QueryRow(context.Background(), query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Prefix,
+		&token.Scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return APIToken{}, fmt.Errorf("api token not found")
+	}
+	if err != nil {
+		return APIToken{}, fmt.Errorf("failed to get api token: %v", err)
+	}
+	if token.RevokedAt != nil {
+		return APIToken{}, fmt.Errorf("api token revoked")
+	}
+	if token.ExpiresAt != nil && time.Now().UTC().After(*token.ExpiresAt) {
+		return APIToken{}, fmt.Errorf("api token expired")
+	}
+	return token, nil
+}
+
+// TouchAPIToken обновляет время последнего использования токена (не чаще раза в минуту)
+func (s *Storage) TouchAPIToken(tokenID int64) error {
+	query := `
+        UPDATE api_tokens
+        SET last_used_at = $2
+        WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2 - INTERVAL '1 minute')
+    `
+	_, err := s.db.Exec(context.Background(), query, tokenID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to update api token usage: %v", err)
+	}
+	return nil
+}
@@ -26,6 +26,23 @@ type TaskStatus struct {
 	Status string `json:"status"`
 	Result string `json:"result,omitempty"`
 	Error  string `json:"error,omitempty"`
+	// Model — модель, которая фактически сгенерировала задачу
+	Model string `json:"model,omitempty"`
+	// Progress — ход генерации, пока статус "Processing"
+	Progress *TaskProgress `json:"progress,omitempty"`
+	// UserID — кто запустил генерацию (нужен для отмены)
+	UserID int64 `json:"userId,omitempty"`
+}
+
+// TaskProgress — ход генерации задачи
+type TaskProgress struct {
+	Model string `json:"model,omitempty"`
+	// TokensReceived — число полученных фрагментов потокового ответа текущей модели
+	TokensReceived int   `json:"tokensReceived"`
+	ElapsedMs      int64 `json:"elapsedMs"`
+	// Description — описание задачи, как только оно разобрано из ответа
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
 type SubmissionStatus struct {
@@ -49,6 +66,11 @@ type TaskDetails struct {
 	UserOriginalCode      string `json:"user_original_code"`
 	Description           string `json:"description"`
 	ProgrammingLanguageID int64  `json:"programming_language_id"`
+	// Stdin и OutputNormalization — ввод программы и режим сравнения вывода (задачи output)
+	Stdin               string `json:"stdin,omitempty"`
+	OutputNormalization string `json:"output_normalization,omitempty"`
+	// TargetLanguageID — язык, на который переводится код (задачи translate); исходный — ProgrammingLanguageID
+	TargetLanguageID int64 `json:"target_language_id,omitempty"`
 }
 
 type Task struct {
@@ -101,7 +123,7 @@ func (s *Storage) GetUserByEmail(email string) (int64, string, error) {
 	query := `
         SELECT id, password_hash
         FROM users
-        WHERE email = $1
+        WHERE email = $1 AND deleted_at IS NULL
     `
 	var id int64
 	var passwordHash string
@@ -273,7 +295,8 @@ func (s *Storage) ListUserTasks(userID int64, offset, limit int) ([]Task, int, e
 // GetTaskDetailsByAlias возвращает детали задачи по алиасу
 func (s *Storage) GetTaskDetailsByAlias(alias string) (TaskDetails, error) {
 	query := `
-        SELECT tasks.id, tasks.user_id, tasks.type, tasks.userOriginalCode, tasks.programming_language_id, tasks.description, tasks.public
+        SELECT tasks.id, tasks.user_id, tasks.type, tasks.userOriginalCode, tasks.programming_language_id, tasks.description, tasks.public,
+               COALESCE(tasks.stdin, ''), COALESCE(tasks.output_normalization, ''), COALESCE(tasks.target_language_id, 0)
         FROM tasks
         JOIN aliases ON tasks.id = aliases.task_id
         WHERE aliases.alias = $1
@@ -287,6 +310,9 @@ func (s *Storage) GetTaskDetailsByAlias(alias string) (TaskDetails, error) {
 		&details.ProgrammingLanguageID,
 		&details.Description,
 		&details.IsPublic,
+		&details.Stdin,
+		&details.OutputNormalization,
+		&details.TargetLanguageID,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return TaskDetails{}, fmt.Errorf("task not found")
@@ -298,13 +324,13 @@ func (s *Storage) GetTaskDetailsByAlias(alias string) (TaskDetails, error) {
 }
 
 // UpdateTaskCodeAndAnswers обновляет код и ответы задачи
-func (s *Storage) UpdateTaskCodeAndAnswers(taskID int64, taskCode string, answers []string, description string) error {
+func (s *Storage) UpdateTaskCodeAndAnswers(taskID int64, taskCode string, answers []string, description string, promptVersionID int64) error {
 	query := `
         UPDATE tasks
-        SET taskCode = $1, answers = $2, created_at = $3, description = $4
-        WHERE id = $4
+        SET taskCode = $1, answers = $2, created_at = $3, description = $4, prompt_version_id = NULLIF($6, 0)
+        WHERE id = $5
     `
-	result, err := s.db.Exec(context.Background(), query, taskCode, answers, time.Now().UTC(), description, taskID)
+	result, err := s.db.Exec(context.Background(), query, taskCode, answers, time.Now().UTC(), description, taskID, promptVersionID)
 	if err != nil {
 		return fmt.Errorf("failed to update task: %v", err)
 	}
@@ -314,6 +340,28 @@ func (s *Storage) UpdateTaskCodeAndAnswers(taskID int64, taskCode string, answer
 	return nil
 }
 
+// SetTaskScrubReport сохраняет отчёт о скрытых в исходном коде секретах и подозрительном тексте;
+// nil очищает отчёт
+func (s *Storage) SetTaskScrubReport(taskID int64, report json.RawMessage) error {
+	query := `
+        UPDATE tasks
+        SET scrub_report = $1
+        WHERE id = $2
+    `
+	var value any
+	if report != nil {
+		value = string(report)
+	}
+	result, err := s.db.Exec(context.Background(), query, value, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to set task scrub report: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("task with ID %d not found", taskID)
+	}
+	return nil
+}
+
 // UpdateTaskPublicStatus обновляет статус public задачи
 func (s *Storage) UpdateTaskPublicStatus(taskID int64, public bool) error {
 	query := `
@@ -344,6 +392,31 @@ func (s *Storage) SetTaskStatus(alias string, status TaskStatus) error {
 	return nil
 }
 
+// RequestTaskCancel помечает генерацию задачи к отмене; пометку увидит обработчик на любом инстансе
+func (s *Storage) RequestTaskCancel(alias string, ttl time.Duration) error {
+	if err := s.rdb.Set(context.Background(), fmt.Sprintf("task_cancel:%s", alias), 1, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to request task cancel in Redis: %v", err)
+	}
+	return nil
+}
+
+// TaskCancelRequested сообщает, запрошена ли отмена генерации задачи
+func (s *Storage) TaskCancelRequested(alias string) (bool, error) {
+	n, err := s.rdb.Exists(context.Background(), fmt.Sprintf("task_cancel:%s", alias)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check task cancel in Redis: %v", err)
+	}
+	return n > 0, nil
+}
+
+// ClearTaskCancel снимает пометку об отмене (перед новой генерацией по тому же алиасу)
+func (s *Storage) ClearTaskCancel(alias string) error {
+	if err := s.rdb.Del(context.Background(), fmt.Sprintf("task_cancel:%s", alias)).Err(); err != nil {
+		return fmt.Errorf("failed to clear task cancel in Redis: %v", err)
+	}
+	return nil
+}
+
 // GetTaskStatus возвращает статус задачи из Redis по алиасу
 func (s *Storage) GetTaskStatus(alias string) (TaskStatus, error) {
 	statusKey := fmt.Sprintf("task_status:%s", alias)
@@ -397,7 +470,7 @@ func (s *Storage) GetRandomPublicTaskAliasByType(taskType string) (string, error
 }
 
 // SaveSkipsCodeWithAlias сохраняет код задачи с алиасом и user_id
-func (s *Storage) SaveSkipsCodeWithAlias(skipsCode string, userOriginalCode string, answers []string, programmingLanguageId, userID int64, alias string, description string) (int64, int64, error) {
+func (s *Storage) SaveSkipsCodeWithAlias(skipsCode string, userOriginalCode string, answers []string, programmingLanguageId, userID int64, alias string, description string, promptVersionID int64) (int64, int64, error) {
 	tx, err := s.db.Begin(context.Background())
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
@@ -406,12 +479,51 @@ func (s *Storage) SaveSkipsCodeWithAlias(skipsCode string, userOriginalCode stri
 
 	var taskID int64
 	queryTask := `
-        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, created_at, public)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, created_at, public, prompt_version_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0))
+        RETURNING id
+    `
+	createdAt := time.Now().UTC()
+	err = tx.QueryRow(context.Background(), queryTask, userID, "skips", skipsCode, userOriginalCode, description, answers, programmingLanguageId, createdAt, false, promptVersionID).Scan(&taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
+	}
+
+	var aliasID int64
+	queryAlias := `
+		INSERT INTO aliases (alias, task_id) 
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err = tx.QueryRow(context.Background(), queryAlias, alias, taskID).Scan(&aliasID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert alias: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return taskID, aliasID, nil
+}
+
+// SaveNoisesCodeWithAlias сохраняет код задачи с алиасом и user_id: answers — исходный код
+// и внесённые правки
+func (s *Storage) SaveNoisesCodeWithAlias(noisesCode string, userOriginalCode string, answers []string, programmingLanguageId, userID int64, alias string, description string, promptVersionID int64) (int64, int64, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskID int64
+	queryTask := `
+        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, created_at, public, prompt_version_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0))
         RETURNING id
     `
 	createdAt := time.Now().UTC()
-	err = tx.QueryRow(context.Background(), queryTask, userID, "skips", skipsCode, userOriginalCode, description, answers, programmingLanguageId, createdAt, false).Scan(&taskID)
+	err = tx.QueryRow(context.Background(), queryTask, userID, "noises", noisesCode, userOriginalCode, description, answers, programmingLanguageId, createdAt, false, promptVersionID).Scan(&taskID)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
 	}
@@ -434,8 +546,204 @@ func (s *Storage) SaveSkipsCodeWithAlias(skipsCode string, userOriginalCode stri
 	return taskID, aliasID, nil
 }
 
-// SaveNoisesCodeWithAlias сохраняет код задачи с алиасом и user_id
-func (s *Storage) SaveNoisesCodeWithAlias(noisesCode string, userOriginalCode string, programmingLanguageId, userID int64, alias string, description string) (int64, int64, error) {
+// SaveBugsCodeWithAlias сохраняет задачу «найди ошибку» с алиасом и user_id: answers — внесённые ошибки
+// с номерами строк и исправлениями
+func (s *Storage) SaveBugsCodeWithAlias(bugsCode string, userOriginalCode string, answers []string, programmingLanguageId, userID int64, alias string, description string, promptVersionID int64) (int64, int64, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskID int64
+	queryTask := `
+        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, created_at, public, prompt_version_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0))
+        RETURNING id
+    `
+	createdAt := time.Now().UTC()
+	err = tx.QueryRow(context.Background(), queryTask, userID, "bugs", bugsCode, userOriginalCode, description, answers, programmingLanguageId, createdAt, false, promptVersionID).Scan(&taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
+	}
+
+	var aliasID int64
+	queryAlias := `
+		INSERT INTO aliases (alias, task_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err = tx.QueryRow(context.Background(), queryAlias, alias, taskID).Scan(&aliasID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert alias: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return taskID, aliasID, nil
+}
+
+// SaveQuizCodeWithAlias сохраняет задачу с вопросами по коду с алиасом и user_id: answers — вопросы
+// с вариантами и правильными ответами
+func (s *Storage) SaveQuizCodeWithAlias(code string, userOriginalCode string, answers []string, programmingLanguageId, userID int64, alias string, description string, promptVersionID int64) (int64, int64, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskID int64
+	queryTask := `
+        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, created_at, public, prompt_version_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0))
+        RETURNING id
+    `
+	createdAt := time.Now().UTC()
+	err = tx.QueryRow(context.Background(), queryTask, userID, "quiz", code, userOriginalCode, description, answers, programmingLanguageId, createdAt, false, promptVersionID).Scan(&taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
+	}
+
+	var aliasID int64
+	queryAlias := `
+		INSERT INTO aliases (alias, task_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err = tx.QueryRow(context.Background(), queryAlias, alias, taskID).Scan(&aliasID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert alias: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return taskID, aliasID, nil
+}
+
+// SaveExplainCodeWithAlias сохраняет задачу «объясни код» с алиасом и user_id: answers — критерии
+// (ключевые пункты объяснения с весами)
+func (s *Storage) SaveExplainCodeWithAlias(code string, userOriginalCode string, answers []string, programmingLanguageId, userID int64, alias string, description string, promptVersionID int64) (int64, int64, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskID int64
+	queryTask := `
+        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, created_at, public, prompt_version_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0))
+        RETURNING id
+    `
+	createdAt := time.Now().UTC()
+	err = tx.QueryRow(context.Background(), queryTask, userID, "explain", code, userOriginalCode, description, answers, programmingLanguageId, createdAt, false, promptVersionID).Scan(&taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
+	}
+
+	var aliasID int64
+	queryAlias := `
+		INSERT INTO aliases (alias, task_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err = tx.QueryRow(context.Background(), queryAlias, alias, taskID).Scan(&aliasID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert alias: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return taskID, aliasID, nil
+}
+
+// SaveOutputCodeWithAlias сохраняет задачу «предскажи вывод» с алиасом и user_id: ответ — вывод,
+// полученный запуском кода в песочнице
+func (s *Storage) SaveOutputCodeWithAlias(code, stdin, expectedOutput, normalization string, programmingLanguageId, userID int64, alias string, description string) (int64, int64, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskID int64
+	queryTask := `
+        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, created_at, public, stdin, output_normalization)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id
+    `
+	createdAt := time.Now().UTC()
+	err = tx.QueryRow(context.Background(), queryTask, userID, "output", code, code, description, []string{expectedOutput}, programmingLanguageId, createdAt, false, stdin, normalization).Scan(&taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
+	}
+
+	var aliasID int64
+	queryAlias := `
+		INSERT INTO aliases (alias, task_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err = tx.QueryRow(context.Background(), queryAlias, alias, taskID).Scan(&aliasID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert alias: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return taskID, aliasID, nil
+}
+
+// SaveTranslateCodeWithAlias сохраняет задачу на перевод кода с алиасом и user_id: answers — тесты
+// (входные данные и вывод исходной программы), пустые, если программу не удалось запустить
+func (s *Storage) SaveTranslateCodeWithAlias(code string, tests []string, sourceLanguageId, targetLanguageId, userID int64, alias string, description string) (int64, int64, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	var taskID int64
+	queryTask := `
+        INSERT INTO tasks (user_id, type, taskCode, userOriginalCode, description, answers, programming_language_id, target_language_id, created_at, public)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        RETURNING id
+    `
+	createdAt := time.Now().UTC()
+	err = tx.QueryRow(context.Background(), queryTask, userID, "translate", code, code, description, tests, sourceLanguageId, targetLanguageId, createdAt, false).Scan(&taskID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
+	}
+
+	var aliasID int64
+	queryAlias := `
+		INSERT INTO aliases (alias, task_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`
+	err = tx.QueryRow(context.Background(), queryAlias, alias, taskID).Scan(&aliasID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to insert alias: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return taskID, aliasID, nil
+}
+
+// SaveParsonsCodeWithAlias сохраняет задачу Парсонса с алиасом и user_id: blocks — перемешанные блоки в JSON,
+// answers — индексы блоков в правильном порядке
+func (s *Storage) SaveParsonsCodeWithAlias(blocks string, userOriginalCode string, answers []string, programmingLanguageId, userID int64, alias string, description string) (int64, int64, error) {
 	tx, err := s.db.Begin(context.Background())
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
@@ -449,14 +757,14 @@ func (s *Storage) SaveNoisesCodeWithAlias(noisesCode string, userOriginalCode st
         RETURNING id
     `
 	createdAt := time.Now().UTC()
-	err = tx.QueryRow(context.Background(), queryTask, userID, "noises", noisesCode, userOriginalCode, description, []string{userOriginalCode}, programmingLanguageId, createdAt, false).Scan(&taskID)
+	err = tx.QueryRow(context.Background(), queryTask, userID, "parsons", blocks, userOriginalCode, description, answers, programmingLanguageId, createdAt, false).Scan(&taskID)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to insert task: %v", err)
 	}
 
 	var aliasID int64
 	queryAlias := `
-		INSERT INTO aliases (alias, task_id) 
+		INSERT INTO aliases (alias, task_id)
 		VALUES ($1, $2)
 		RETURNING id
 	`
@@ -591,10 +899,10 @@ func (s *Storage) GetCodeAnswers(codeAlias string) ([]string, error) {
 }
 
 // SavePendingSubmission сохраняет новую посылку
-func (s *Storage) SavePendingSubmission(taskAlias string, submissionCode []string) (int64, error) {
+func (s *Storage) SavePendingSubmission(taskAlias string, userID int64, submissionCode []string) (int64, error) {
 	query := `
-        INSERT INTO submissions (task_alias, submission_code, status, hints, submitted_at, score)
-        VALUES ($1, $2, 'Pending', NULL, $3, -1)
+        INSERT INTO submissions (task_alias, user_id, submission_code, status, hints, submitted_at, score)
+        VALUES ($1, $2, $3, 'Pending', NULL, $4, -1)
         RETURNING id
     `
 	var id int64
@@ -604,7 +912,7 @@ func (s *Storage) SavePendingSubmission(taskAlias string, submissionCode []strin
 	} else {
 		codeVal = submissionCode
 	}
-	err := s.db.QueryRow(context.Background(), query, taskAlias, codeVal, time.Now().UTC()).Scan(&id)
+	err := s.db.QueryRow(context.Background(), query, taskAlias, userID, codeVal, time.Now().UTC()).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("failed to save submission: %v", err)
 	}
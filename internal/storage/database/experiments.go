@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
+)
+
+type Experiment struct {
+	ID          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Flow        string              `json:"flow"`
+	Description string              `json:"description"`
+	Active      bool                `json:"active"`
+	CreatedBy   *int64              `json:"createdBy,omitempty"`
+	CreatedAt   time.Time           `json:"createdAt"`
+	EndedAt     *time.Time          `json:"endedAt,omitempty"`
+	Variants    []ExperimentVariant `json:"variants"`
+}
+
+// ExperimentVariant — вариант эксперимента: версия промпта (0 — активная) и модель (пусто — настроенная цепочка)
+type ExperimentVariant struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	PromptVersion int    `json:"promptVersion"`
+	Model         string `json:"model,omitempty"`
+	Weight        int    `json:"weight"`
+}
+
+// ExperimentVariantMetrics — показатели варианта
+type ExperimentVariantMetrics struct {
+	VariantID int64  `json:"variantId"`
+	Variant   string `json:"variant"`
+	// Generations и GenerationFailures заполняются для экспериментов с генерацией задач
+	Generations        int64 `json:"generations"`
+	GenerationFailures int64 `json:"generationFailures"`
+	// Submissions — посылки к задачам варианта (генерация) или оценённые вариантом (проверка);
+	// GradingFailures — посылки, которые не удалось оценить
+	Submissions     int64 `json:"submissions"`
+	GradingFailures int64 `json:"gradingFailures"`
+	// Attempts — пары (задача, пользователь), Solved — из них решённые
+	Attempts        int64   `json:"attempts"`
+	Solved          int64   `json:"solved"`
+	AverageAttempts float64 `json:"averageAttempts"`
+	BadHintReports  int64   `json:"badHintReports"`
+}
+
+// CreateExperiment создаёт активный эксперимент с вариантами
+func (s *Storage) CreateExperiment(experiment Experiment, createdBy int64) (Experiment, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return Experiment{}, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	query := `
+        INSERT INTO experiments (name, flow, description, active, created_by, created_at)
+        VALUES ($1, $2, $3, TRUE, NULLIF($4, 0), $5)
+        RETURNING id, created_at
+    `
+	err = tx.QueryRow(context.Background(), query, experiment.Name, experiment.Flow, experiment.Description, createdBy, time.Now().UTC()).
+		Scan(&experiment.ID, &experiment.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_experiments_active_flow") {
+			return Experiment{}, fmt.Errorf("active experiment already exists")
+		}
+		if strings.Contains(err.Error(), "unique constraint") {
+			return Experiment{}, fmt.Errorf("experiment already exists")
+		}
+		return Experiment{}, fmt.Errorf("failed to insert experiment: %v", err)
+	}
+
+	queryVariant := `
+        INSERT INTO experiment_variants (experiment_id, name, prompt_version, model, weight)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+	for i, v := range experiment.Variants {
+		err := tx.QueryRow(context.Background(), queryVariant, experiment.ID, v.Name, v.PromptVersion, v.Model, v.Weight).Scan(&experiment.Variants[i].ID)
+		if err != nil {
+			return Experiment{}, fmt.Errorf("failed to insert experiment variant: %v", err)
+		}
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return Experiment{}, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	experiment.Active = true
+	if createdBy > 0 {
+		experiment.CreatedBy = &createdBy
+	}
+	return experiment, nil
+}
+
+// ListExperiments возвращает эксперименты (новые первыми); activeOnly — только активные
+func (s *Storage) ListExperiments(activeOnly bool) ([]Experiment, error) {
+	query := `
+        SELECT id, name, flow, description, active, created_by, created_at, ended_at
+        FROM experiments
+        WHERE active OR NOT $1
+        ORDER BY id DESC
+    `
+	rows, err := s.db.Query(context.Background(), query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query experiments: %v", err)
+	}
+	defer rows.Close()
+
+	experiments := []Experiment{}
+	byID := make(map[int64]int)
+	for rows.Next() {
+		var e Experiment
+		if err := rows.Scan(&e.ID, &e.Name, &e.Flow, &e.Description, &e.Active, &e.CreatedBy, &e.CreatedAt, &e.EndedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment: %v", err)
+		}
+		e.Variants = []ExperimentVariant{}
+		byID[e.ID] = len(experiments)
+		experiments = append(experiments, e)
+	}
+	rows.Close()
+	if len(experiments) == 0 {
+		return experiments, nil
+	}
+
+	variants, err := s.db.Query(context.Background(), `
+        SELECT v.experiment_id, v.id, v.name, v.prompt_version, v.model, v.weight
+        FROM experiment_variants v
+        JOIN experiments e ON e.id = v.experiment_id
+        WHERE e.active OR NOT $1
+        ORDER BY v.id
+    `, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query experiment variants: %v", err)
+	}
+	defer variants.Close()
+	for variants.Next() {
+		var experimentID int64
+		var v ExperimentVariant
+		if err := variants.Scan(&experimentID, &v.ID, &v.Name, &v.PromptVersion, &v.Model, &v.Weight); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment variant: %v", err)
+		}
+		if i, ok := byID[experimentID]; ok {
+			experiments[i].Variants = append(experiments[i].Variants, v)
+		}
+	}
+	return experiments, nil
+}
+
+// GetExperiment возвращает эксперимент с вариантами
+func (s *Storage) GetExperiment(id int64) (Experiment, error) {
+	experiments, err := s.ListExperiments(false)
+	if err != nil {
+		return Experiment{}, err
+	}
+	for _, e := range experiments {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Experiment{}, fmt.Errorf("experiment not found")
+}
+
+// StopExperiment завершает эксперимент; записанные варианты задач и посылок сохраняются
+func (s *Storage) StopExperiment(id int64) error {
+	query := `
+        UPDATE experiments
+        SET active = FALSE, ended_at = $2
+        WHERE id = $1 AND active
+    `
+	result, err := s.db.Exec(context.Background(), query, id, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to stop experiment: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("experiment not found")
+	}
+	return nil
+}
+
+// RecordExperimentGeneration учитывает генерацию задачи вариантом; при успехе вариант записывается в задачу
+func (s *Storage) RecordExperimentGeneration(variantID, userID int64, alias string, succeeded bool) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	query := `
+        INSERT INTO experiment_generations (variant_id, user_id, task_alias, succeeded, created_at)
+        VALUES ($1, NULLIF($2, 0), $3, $4, $5)
+    `
+	if _, err := tx.Exec(context.Background(), query, variantID, userID, alias, succeeded, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record experiment generation: %v", err)
+	}
+	if succeeded {
+		queryTask := `
+            UPDATE tasks
+            SET experiment_variant_id = $1
+            WHERE id = (SELECT task_id FROM aliases WHERE alias = $2)
+        `
+		if _, err := tx.Exec(context.Background(), queryTask, variantID, alias); err != nil {
+			return fmt.Errorf("failed to set task experiment variant: %v", err)
+		}
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// SetSubmissionExperimentVariant записывает вариант, которым оценивается посылка
+func (s *Storage) SetSubmissionExperimentVariant(submissionID, variantID int64) error {
+	_, err := s.db.Exec(context.Background(), `UPDATE submissions SET experiment_variant_id = $2 WHERE id = $1`, submissionID, variantID)
+	if err != nil {
+		return fmt.Errorf("failed to set submission experiment variant: %v", err)
+	}
+	return nil
+}
+
+// GetExperimentMetrics считает показатели каждого варианта эксперимента
+func (s *Storage) GetExperimentMetrics(experiment Experiment, generation bool) ([]ExperimentVariantMetrics, error) {
+	// Посылки, относящиеся к варианту: к задачам, которые он сгенерировал, или оценённые им
+	subs := `
+        SELECT s.id, s.task_alias, s.user_id, s.status, s.score
+        FROM submissions s
+        WHERE s.experiment_variant_id = $1
+    `
+	if generation {
+		subs = `
+            SELECT s.id, s.task_alias, s.user_id, s.status, s.score
+            FROM submissions s
+            JOIN aliases a ON a.alias = s.task_alias
+            JOIN tasks t ON t.id = a.task_id
+            WHERE t.experiment_variant_id = $1
+        `
+	}
+	query := `
+        WITH subs AS (` + subs + `),
+        pairs AS (
+            SELECT task_alias, user_id, COUNT(*) AS attempts, BOOL_OR(status = 'Success') AS solved
+            FROM subs
+            GROUP BY task_alias, user_id
+        )
+        SELECT
+            (SELECT COUNT(*) FROM experiment_generations WHERE variant_id = $1),
+            (SELECT COUNT(*) FROM experiment_generations WHERE variant_id = $1 AND NOT succeeded),
+            (SELECT COUNT(*) FROM subs),
+            (SELECT COUNT(*) FROM subs WHERE status = 'Failed' AND score = -1),
+            (SELECT COUNT(*) FROM pairs),
+            (SELECT COUNT(*) FROM pairs WHERE solved),
+            COALESCE((SELECT AVG(attempts) FROM pairs), 0)::float8,
+            (SELECT COUNT(*) FROM hint_reports h JOIN subs ON subs.id = h.submission_id)
+    `
+
+	metrics := make([]ExperimentVariantMetrics, 0, len(experiment.Variants))
+	for _, v := range experiment.Variants {
+		m := ExperimentVariantMetrics{VariantID: v.ID, Variant: v.Name}
+		err := s.db.QueryRow(context.Background(), query, v.ID).Scan(&m.Generations, &m.GenerationFailures, &m.Submissions,
+			&m.GradingFailures, &m.Attempts, &m.Solved, &m.AverageAttempts, &m.BadHintReports)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get experiment metrics: %v", err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// ReportBadHints сохраняет жалобу пользователя на подсказки своей посылки
+func (s *Storage) ReportBadHints(submissionID, userID int64, comment string) error {
+	var ownerID *int64
+	err := s.db.QueryRow(context.Background(), `SELECT user_id FROM submissions WHERE id = $1`, submissionID).Scan(&ownerID)
+	if errors.Is(err, pgx.ErrNoRows) || err == nil && (ownerID == nil || *ownerID != userID) {
+		return fmt.Errorf("submission not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get submission: %v", err)
+	}
+
+	query := `
+        INSERT INTO hint_reports (submission_id, user_id, comment, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (submission_id, user_id) DO UPDATE SET comment = EXCLUDED.comment
+    `
+	if _, err := s.db.Exec(context.Background(), query, submissionID, userID, comment, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to save hint report: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+// SetTaskHintLadders сохраняет лестницы подсказок задачи; nil очищает их.
+// Открытые пользователями подсказки относились к прежним пропускам или правкам, поэтому сбрасываются
+func (s *Storage) SetTaskHintLadders(taskID int64, ladders json.RawMessage) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	var value any
+	if ladders != nil {
+		value = string(ladders)
+	}
+	result, err := tx.Exec(context.Background(), `UPDATE tasks SET hint_ladders = $1 WHERE id = $2`, value, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to set task hint ladders: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("task with ID %d not found", taskID)
+	}
+	if _, err := tx.Exec(context.Background(), `DELETE FROM hint_usage WHERE task_id = $1`, taskID); err != nil {
+		return fmt.Errorf("failed to reset hint usage: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// GetTaskHintLadders возвращает лестницы подсказок задачи или nil, если их нет
+func (s *Storage) GetTaskHintLadders(taskID int64) (json.RawMessage, error) {
+	var ladders []byte
+	err := s.db.QueryRow(context.Background(), `SELECT hint_ladders FROM tasks WHERE id = $1`, taskID).Scan(&ladders)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("task not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task hint ladders: %v", err)
+	}
+	return ladders, nil
+}
+
+// GetUsedHintLevels возвращает последнюю открытую пользователем ступень по номеру элемента задачи
+func (s *Storage) GetUsedHintLevels(taskID, userID int64) (map[int]int, error) {
+	query := `
+        SELECT item, MAX(level)
+        FROM hint_usage
+        WHERE task_id = $1 AND user_id = $2
+        GROUP BY item
+    `
+	rows, err := s.db.Query(context.Background(), query, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get used hints: %v", err)
+	}
+	defer rows.Close()
+
+	levels := make(map[int]int)
+	for rows.Next() {
+		var item, level int
+		if err := rows.Scan(&item, &level); err != nil {
+			return nil, fmt.Errorf("failed to scan used hint: %v", err)
+		}
+		levels[item] = level
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get used hints: %v", err)
+	}
+	return levels, nil
+}
+
+// RecordHintUsage отмечает, что пользователь открыл ступень подсказки, и запоминает максимальную
+// оценку после неё. Возвращает false, если эта ступень уже была открыта
+func (s *Storage) RecordHintUsage(taskID, userID int64, item, level, maxScore int) (bool, error) {
+	query := `
+        INSERT INTO hint_usage (task_id, user_id, item, level, max_score, used_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (task_id, user_id, item, level) DO NOTHING
+    `
+	result, err := s.db.Exec(context.Background(), query, taskID, userID, item, level, maxScore, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to record hint usage: %v", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// GetSubmissionMaxScore возвращает максимальную оценку посылки с учётом подсказок, которые
+// её автор открыл до отправки; 100, если подсказок не было
+func (s *Storage) GetSubmissionMaxScore(submissionID int64) (int, error) {
+	query := `
+        SELECT COALESCE(MIN(hint_usage.max_score), 100)
+        FROM submissions
+        JOIN aliases ON aliases.alias = submissions.task_alias
+        JOIN hint_usage ON hint_usage.task_id = aliases.task_id
+            AND hint_usage.user_id = submissions.user_id
+            AND hint_usage.used_at <= submissions.submitted_at
+        WHERE submissions.id = $1
+    `
+	var maxScore int
+	if err := s.db.QueryRow(context.Background(), query, submissionID).Scan(&maxScore); err != nil {
+		return 0, fmt.Errorf("failed to get submission max score: %v", err)
+	}
+	return maxScore, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const llmCacheStatsKey = "llm_cache:stats"
+
+func llmCacheKey(key string) string {
+	return fmt.Sprintf("llm_cache:%s", key)
+}
+
+// LLMCacheStats — число попаданий и промахов кэша для одного вида запросов
+type LLMCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// GetLLMCache возвращает закэшированный ответ LLM
+func (s *Storage) GetLLMCache(key string) (string, bool, error) {
+	value, err := s.rdb.Get(context.Background(), llmCacheKey(key)).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get llm cache from Redis: %v", err)
+	}
+	return value, true, nil
+}
+
+// SetLLMCache сохраняет ответ LLM в кэш
+func (s *Storage) SetLLMCache(key, value string, ttl time.Duration) error {
+	if err := s.rdb.Set(context.Background(), llmCacheKey(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set llm cache in Redis: %v", err)
+	}
+	return nil
+}
+
+// IncrLLMCacheStat учитывает попадание или промах кэша для вида запросов
+func (s *Storage) IncrLLMCacheStat(kind string, hit bool) error {
+	field := kind + ":misses"
+	if hit {
+		field = kind + ":hits"
+	}
+	if err := s.rdb.HIncrBy(context.Background(), llmCacheStatsKey, field, 1).Err(); err != nil {
+		return fmt.Errorf("failed to increment llm cache stat in Redis: %v", err)
+	}
+	return nil
+}
+
+// GetLLMCacheStats возвращает статистику кэша по видам запросов
+func (s *Storage) GetLLMCacheStats() (map[string]LLMCacheStats, error) {
+	fields, err := s.rdb.HGetAll(context.Background(), llmCacheStatsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get llm cache stats from Redis: %v", err)
+	}
+
+	stats := make(map[string]LLMCacheStats)
+	for field, value := range fields {
+		idx := strings.LastIndex(field, ":")
+		if idx < 0 {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		kind := field[:idx]
+		entry := stats[kind]
+		switch field[idx+1:] {
+		case "hits":
+			entry.Hits = count
+		case "misses":
+			entry.Misses = count
+		}
+		stats[kind] = entry
+	}
+	return stats, nil
+}
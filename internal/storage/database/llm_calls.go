@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"time"
+)
+
+// Статусы записей журнала LLM
+const (
+	LLMCallOK      = "ok"
+	LLMCallInvalid = "invalid"
+	LLMCallError   = "error"
+	LLMCallCached  = "cached"
+)
+
+// LLMCall — одно обращение к LLM (или ответ из кэша)
+type LLMCall struct {
+	ID               int64     `json:"id"`
+	UserID           *int64    `json:"userId,omitempty"`
+	Endpoint         string    `json:"endpoint"`
+	Kind             string    `json:"kind"`
+	TaskAlias        string    `json:"taskAlias,omitempty"`
+	SubmissionID     int64     `json:"submissionId,omitempty"`
+	PromptVersionID  int64     `json:"promptVersionId,omitempty"`
+	Model            string    `json:"model"`
+	Status           string    `json:"status"`
+	Error            string    `json:"error,omitempty"`
+	LatencyMs        int64     `json:"latencyMs"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+	Cost             float64   `json:"cost"`
+	RequestHash      string    `json:"requestHash"`
+	SystemPrompt     string    `json:"systemPrompt,omitempty"`
+	UserPrompt       string    `json:"userPrompt,omitempty"`
+	Response         string    `json:"response,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// LLMCallFilter — фильтр журнала; нулевые поля не учитываются
+type LLMCallFilter struct {
+	UserID       int64
+	TaskAlias    string
+	SubmissionID int64
+	Status       string
+	Offset       int
+	Limit        int
+}
+
+// RecordLLMCall сохраняет запись журнала LLM
+func (s *Storage) RecordLLMCall(call LLMCall) error {
+	query := `
+        INSERT INTO llm_calls (user_id, endpoint, kind, task_alias, submission_id, prompt_version_id, model, status, error,
+                               latency_ms, prompt_tokens, completion_tokens, cost, request_hash, system_prompt, user_prompt, response, created_at)
+        VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, 0), NULLIF($6, 0), $7, $8, NULLIF($9, ''),
+                $10, $11, $12, $13, $14, $15, $16, $17, $18)
+    `
+	_, err := s.db.Exec(context.Background(), query, call.UserID, call.Endpoint, call.Kind, call.TaskAlias, call.SubmissionID,
+		call.PromptVersionID, call.Model, call.Status, call.Error, call.LatencyMs, call.PromptTokens, call.CompletionTokens,
+		call.Cost, call.RequestHash, call.SystemPrompt, call.UserPrompt, call.Response, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record llm call: %v", err)
+	}
+	return nil
+}
+
+// ListLLMCalls возвращает записи журнала без текстов промптов и ответа (новые первыми) и общее число записей
+func (s *Storage) ListLLMCalls(filter LLMCallFilter) ([]LLMCall, int, error) {
+	where := "WHERE TRUE"
+	args := []interface{}{}
+	addCondition := func(column string, value interface{}) {
+		args = append(args, value)
+		where += " AND " + column + " = $" + strconv.Itoa(len(args))
+	}
+	if filter.UserID > 0 {
+		addCondition("user_id", filter.UserID)
+	}
+	if filter.TaskAlias != "" {
+		addCondition("task_alias", filter.TaskAlias)
+	}
+	if filter.SubmissionID > 0 {
+		addCondition("submission_id", filter.SubmissionID)
+	}
+	if filter.Status != "" {
+		addCondition("status", filter.Status)
+	}
+
+	var total int
+	if err := s.db.QueryRow(context.Background(), `SELECT COUNT(*) FROM llm_calls `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count llm calls: %v", err)
+	}
+
+	query := `
+        SELECT id, user_id, endpoint, kind, COALESCE(task_alias, ''), COALESCE(submission_id, 0), COALESCE(prompt_version_id, 0),
+               model, status, COALESCE(error, ''), latency_ms, prompt_tokens, completion_tokens, cost::float8, request_hash, created_at
+        FROM llm_calls ` + where + `
+        ORDER BY id DESC
+        OFFSET $` + strconv.Itoa(len(args)+1) + ` LIMIT $` + strconv.Itoa(len(args)+2)
+	rows, err := s.db.Query(context.Background(), query, append(args, filter.Offset, filter.Limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query llm calls: %v", err)
+	}
+	defer rows.Close()
+
+	calls := []LLMCall{}
+	for rows.Next() {
+		var c LLMCall
+		err := rows.Scan(&c.ID, &c.UserID, &c.Endpoint, &c.Kind, &c.TaskAlias, &c.SubmissionID, &c.PromptVersionID,
+			&c.Model, &c.Status, &c.Error, &c.LatencyMs, &c.PromptTokens, &c.CompletionTokens, &c.Cost, &c.RequestHash, &c.CreatedAt)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan llm call: %v", err)
+		}
+		calls = append(calls, c)
+	}
+	return calls, total, nil
+}
+
+// GetLLMCall возвращает запись журнала целиком
+func (s *Storage) GetLLMCall(id int64) (LLMCall, error) {
+	query := `
+        SELECT id, user_id, endpoint, kind, COALESCE(task_alias, ''), COALESCE(submission_id, 0), COALESCE(prompt_version_id, 0),
+               model, status, COALESCE(error, ''), latency_ms, prompt_tokens, completion_tokens, cost::float8, request_hash,
+               system_prompt, user_prompt, COALESCE(response, ''), created_at
+        FROM llm_calls
+        WHERE id = $1
+    `
+	var c LLMCall
+	err := s.db.QueryRow(context.Background(), query, id).Scan(&c.ID, &c.UserID, &c.Endpoint, &c.Kind, &c.TaskAlias,
+		&c.SubmissionID, &c.PromptVersionID, &c.Model, &c.Status, &c.Error, &c.LatencyMs, &c.PromptTokens, &c.CompletionTokens,
+		&c.Cost, &c.RequestHash, &c.SystemPrompt, &c.UserPrompt, &c.Response, &c.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return LLMCall{}, fmt.Errorf("llm call not found")
+	}
+	if err != nil {
+		return LLMCall{}, fmt.Errorf("failed to get llm call: %v", err)
+	}
+	return c, nil
+}
+
+// FindRecordedLLMResponse возвращает последний записанный ответ модели на запрос с таким хэшем
+func (s *Storage) FindRecordedLLMResponse(requestHash, model string) (string, bool, error) {
+	query := `
+        SELECT response
+        FROM llm_calls
+        WHERE request_hash = $1 AND model = $2 AND status IN ('ok', 'invalid') AND response IS NOT NULL
+        ORDER BY id DESC
+        LIMIT 1
+    `
+	var response string
+	err := s.db.QueryRow(context.Background(), query, requestHash, model).Scan(&response)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to find recorded llm response: %v", err)
+	}
+	return response, true, nil
+}
+
+// DeleteLLMCallsBefore удаляет записи журнала старше before
+func (s *Storage) DeleteLLMCallsBefore(before time.Time) (int64, error) {
+	result, err := s.db.Exec(context.Background(), `DELETE FROM llm_calls WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete llm calls: %v", err)
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type LLMUsage struct {
+	UserID           int64
+	Endpoint         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+// LLMUsageTotals — суммарное использование LLM за период
+type LLMUsageTotals struct {
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t LLMUsageTotals) Tokens() int64 {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+type LLMEndpointUsage struct {
+	Endpoint string `json:"endpoint"`
+	LLMUsageTotals
+}
+
+// RecordLLMUsage сохраняет расход токенов одного запроса к LLM
+func (s *Storage) RecordLLMUsage(usage LLMUsage) error {
+	query := `
+        INSERT INTO llm_usage (user_id, endpoint, model, prompt_tokens, completion_tokens, cost, created_at)
+        VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7)
+    `
+	_, err := s.db.Exec(context.Background(), query, usage.UserID, usage.Endpoint, usage.Model,
+		usage.PromptTokens, usage.CompletionTokens, usage.Cost, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record llm usage: %v", err)
+	}
+	return nil
+}
+
+// GetLLMUsageTotals возвращает суммарное использование LLM пользователем начиная с since
+func (s *Storage) GetLLMUsageTotals(userID int64, since time.Time) (LLMUsageTotals, error) {
+	query := `
+        SELECT COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost), 0)::float8
+        FROM llm_usage
+        WHERE user_id = $1 AND created_at >= $2
+    `
+	var totals LLMUsageTotals
+	err := s.db.QueryRow(context.Background(), query, userID, since).Scan(
+		&totals.Requests, &totals.PromptTokens, &totals.CompletionTokens, &totals.Cost)
+	if err != nil {
+		return LLMUsageTotals{}, fmt.Errorf("failed to get llm usage: %v", err)
+	}
+	return totals, nil
+}
+
+// GetLLMUsageByEndpoint возвращает использование LLM пользователем начиная с since в разбивке по эндпоинтам
+func (s *Storage) GetLLMUsageByEndpoint(userID int64, since time.Time) ([]LLMEndpointUsage, error) {
+	query := `
+        SELECT endpoint, COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost), 0)::float8
+        FROM llm_usage
+        WHERE user_id = $1 AND created_at >= $2
+        GROUP BY endpoint
+        ORDER BY endpoint
+    `
+	rows, err := s.db.Query(context.Background(), query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query llm usage: %v", err)
+	}
+	defer rows.Close()
+
+	usage := []LLMEndpointUsage{}
+	for rows.Next() {
+		var u LLMEndpointUsage
+		if err := rows.Scan(&u.Endpoint, &u.Requests, &u.PromptTokens, &u.CompletionTokens, &u.Cost); err != nil {
+			return nil, fmt.Errorf("failed to scan llm usage: %v", err)
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func loginFailuresKey(subject string) string {
+	return fmt.Sprintf("login_failures:%s", subject)
+}
+
+func loginBlockKey(subject string) string {
+	return fmt.Sprintf("login_block:%s", subject)
+}
+
+// IncrLoginFailures увеличивает счётчик неудачных попыток входа в Redis
+func (s *Storage) IncrLoginFailures(subject string, window time.Duration) (int64, error) {
+	key := loginFailuresKey(subject)
+	pipe := s.rdb.TxPipeline()
+	incr := pipe.Incr(context.Background(), key)
+	pipe.Expire(context.Background(), key, window)
+	if _, err := pipe.Exec(context.Background()); err != nil {
+		return 0, fmt.Errorf("failed to increment login failures in Redis: %v", err)
+	}
+	return incr.Val(), nil
+}
+
+// BlockLogin запрещает попытки входа для субъекта на указанное время
+func (s *Storage) BlockLogin(subject string, duration time.Duration) error {
+	if err := s.rdb.Set(context.Background(), loginBlockKey(subject), 1, duration).Err(); err != nil {
+		return fmt.Errorf("failed to set login block in Redis: %v", err)
+	}
+	return nil
+}
+
+// GetLoginBlock возвращает оставшееся время блокировки (0, если блокировки нет)
+func (s *Storage) GetLoginBlock(subject string) (time.Duration, error) {
+	ttl, err := s.rdb.PTTL(context.Background(), loginBlockKey(subject)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get login block from Redis: %v", err)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// ResetLoginFailures снимает блокировку и обнуляет счётчик неудачных попыток
+func (s *Storage) ResetLoginFailures(subject string) error {
+	if err := s.rdb.Del(context.Background(), loginFailuresKey(subject), loginBlockKey(subject)).Err(); err != nil {
+		return fmt.Errorf("failed to reset login failures in Redis: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type PromptVersion struct {
+	ID             int64      `json:"id"`
+	Name           string     `json:"name"`
+	Version        int        `json:"version"`
+	SystemTemplate string     `json:"systemTemplate"`
+	UserTemplate   string     `json:"userTemplate"`
+	Comment        string     `json:"comment"`
+	Active         bool       `json:"active"`
+	CreatedBy      *int64     `json:"createdBy,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	ActivatedAt    *time.Time `json:"activatedAt,omitempty"`
+}
+
+const promptVersionColumns = `id, name, version, system_template, user_template, comment, active, created_by, created_at, activated_at`
+
+func scanPromptVersion(row pgx.Row) (PromptVersion, error) {
+	var v PromptVersion
+	err := row.Scan(&v.ID, &v.Name, &v.Version, &v.SystemTemplate, &v.UserTemplate, &v.Comment, &v.Active, &v.CreatedBy, &v.CreatedAt, &v.ActivatedAt)
+	return v, err
+}
+
+// SeedPromptVersion создаёт первую активную версию промпта, если у него ещё нет ни одной версии
+func (s *Storage) SeedPromptVersion(name, systemTemplate, userTemplate string) error {
+	query := `
+        INSERT INTO prompt_versions (name, version, system_template, user_template, comment, active, created_at, activated_at)
+        SELECT $1, 1, $2, $3, 'seeded from config', TRUE, $4, $4
+        WHERE NOT EXISTS (SELECT 1 FROM prompt_versions WHERE name = $1)
+    `
+	_, err := s.db.Exec(context.Background(), query, name, systemTemplate, userTemplate, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to seed prompt version: %v", err)
+	}
+	return nil
+}
+
+// ListActivePromptVersions возвращает активную версию каждого промпта
+func (s *Storage) ListActivePromptVersions() ([]PromptVersion, error) {
+	query := `
+        SELECT ` + promptVersionColumns + `
+        FROM prompt_versions
+        WHERE active
+        ORDER BY name
+    `
+	return s.queryPromptVersions(query)
+}
+
+// ListPromptVersions возвращает все версии промпта (новые первыми)
+func (s *Storage) ListPromptVersions(name string) ([]PromptVersion, error) {
+	query := `
+        SELECT ` + promptVersionColumns + `
+        FROM prompt_versions
+        WHERE name = $1
+        ORDER BY version DESC
+    `
+	return s.queryPromptVersions(query, name)
+}
+
+func (s *Storage) queryPromptVersions(query string, args ...interface{}) ([]PromptVersion, error) {
+	rows, err := s.db.Query(context.Background(), query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt versions: %v", err)
+	}
+	defer rows.Close()
+
+	versions := []PromptVersion{}
+	for rows.Next() {
+		v, err := scanPromptVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan prompt version: %v", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// GetPromptVersion возвращает конкретную версию промпта
+func (s *Storage) GetPromptVersion(name string, version int) (PromptVersion, error) {
+	query := `
+        SELECT ` + promptVersionColumns + `
+        FROM prompt_versions
+        WHERE name = $1 AND version = $2
+    `
+	v, err := scanPromptVersion(s.db.QueryRow(context.Background(), query, name, version))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return PromptVersion{}, fmt.Errorf("prompt version not found")
+	}
+	if err != nil {
+		return PromptVersion{}, fmt.Errorf("failed to get prompt version: %v", err)
+	}
+	return v, nil
+}
+
+// CreatePromptVersion сохраняет новую (неактивную) версию промпта со следующим номером
+func (s *Storage) CreatePromptVersion(name, systemTemplate, userTemplate, comment string, createdBy int64) (PromptVersion, error) {
+	query := `
+        INSERT INTO prompt_versions (name, version, system_template, user_template, comment, active, created_by, created_at)
+        SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, FALSE, $5, $6
+        FROM prompt_versions
+        WHERE name = $1
+        RETURNING ` + promptVersionColumns
+	v, err := scanPromptVersion(s.db.QueryRow(context.Background(), query, name, systemTemplate, userTemplate, comment, createdBy, time.Now().UTC()))
+	if err != nil {
+		return PromptVersion{}, fmt.Errorf("failed to create prompt version: %v", err)
+	}
+	return v, nil
+}
+
+// ActivatePromptVersion делает версию активной, снимая флаг с предыдущей
+func (s *Storage) ActivatePromptVersion(name string, version int) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if _, err := tx.Exec(context.Background(), `UPDATE prompt_versions SET active = FALSE WHERE name = $1 AND active`, name); err != nil {
+		return fmt.Errorf("failed to deactivate prompt version: %v", err)
+	}
+
+	query := `
+        UPDATE prompt_versions
+        SET active = TRUE, activated_at = $3
+        WHERE name = $1 AND version = $2
+    `
+	result, err := tx.Exec(context.Background(), query, name, version, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to activate prompt version: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("prompt version not found")
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// GetUserRole возвращает роль пользователя
+func (s *Storage) GetUserRole(userID int64) (string, error) {
+	query := `
+        SELECT role
+        FROM users
+        WHERE id = $1
+    `
+	var role string
+	err := s.db.QueryRow(context.Background(), query, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user role: %v", err)
+	}
+	return role, nil
+}
+
+// PromoteAdmins выдаёт роль admin пользователям с указанными email
+func (s *Storage) PromoteAdmins(emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+	query := `
+        UPDATE users
+        SET role = 'admin'
+        WHERE email = ANY($1) AND role <> 'admin'
+    `
+	if _, err := s.db.Exec(context.Background(), query, emails); err != nil {
+		return fmt.Errorf("failed to promote admins: %v", err)
+	}
+	return nil
+}
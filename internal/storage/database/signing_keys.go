@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type SigningKey struct {
+	KID        string
+	Algorithm  string
+	PrivateKey string
+	CreatedAt  time.Time
+	RetireAt   time.Time
+}
+
+// SaveSigningKey сохраняет новый ключ подписи JWT
+func (s *Storage) SaveSigningKey(key SigningKey) error {
+	query := `
+        INSERT INTO jwt_signing_keys (kid, algorithm, private_key, created_at, retire_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `
+	_, err := s.db.Exec(context.Background(), query, key.KID, key.Algorithm, key.PrivateKey, key.CreatedAt, key.RetireAt)
+	if err != nil {
+		return fmt.Errorf("failed to save signing key: %v", err)
+	}
+	return nil
+}
+
+// ListActiveSigningKeys возвращает ключи, которые ещё можно использовать для проверки подписи (новые первыми)
+func (s *Storage) ListActiveSigningKeys() ([]SigningKey, error) {
+	query := `
+        SELECT kid, algorithm, private_key, created_at, retire_at
+        FROM jwt_signing_keys
+        WHERE retire_at > $1
+        ORDER BY created_at DESC
+    `
+	rows, err := s.db.Query(context.Background(), query, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query signing keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []SigningKey
+	for rows.Next() {
+		var key SigningKey
+		if err := rows.Scan(&key.KID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &key.RetireAt); err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// DeleteRetiredSigningKeys удаляет ключи, срок проверки которых истёк
+func (s *Storage) DeleteRetiredSigningKeys() error {
+	query := `
+        DELETE FROM jwt_signing_keys
+        WHERE retire_at <= $1
+    `
+	_, err := s.db.Exec(context.Background(), query, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete retired signing keys: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"strings"
+	"time"
+)
+
+// Авторы сообщений в чате с наставником
+const (
+	TutorRoleStudent = "student"
+	TutorRoleTutor   = "tutor"
+)
+
+// TutorMessage — сообщение чата с наставником. Ответ наставника создаётся со статусом Pending
+// и получает текст, когда модель ответит
+type TutorMessage struct {
+	ID        int64     `json:"id"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LatestSubmission — последняя посылка пользователя по задаче
+type LatestSubmission struct {
+	ID     int64
+	Code   []string
+	Status string
+	Score  int
+	Hints  []string
+}
+
+func tutorMessagesKey(userID int64) string {
+	return fmt.Sprintf("tutor_messages:%d", userID)
+}
+
+// IncrTutorMessages учитывает вопрос пользователя наставнику в Redis и возвращает число вопросов
+// в текущем окне и время до его окончания
+func (s *Storage) IncrTutorMessages(userID int64, window time.Duration) (int64, time.Duration, error) {
+	key := tutorMessagesKey(userID)
+	count, err := s.rdb.Incr(context.Background(), key).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to increment tutor messages in Redis: %v", err)
+	}
+	// Окно начинается с первого вопроса и не продлевается следующими
+	if count == 1 {
+		if err := s.rdb.Expire(context.Background(), key, window).Err(); err != nil {
+			return 0, 0, fmt.Errorf("failed to set tutor messages window in Redis: %v", err)
+		}
+	}
+	ttl, err := s.rdb.PTTL(context.Background(), key).Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get tutor messages window from Redis: %v", err)
+	}
+	if ttl < 0 {
+		ttl = window
+	}
+	return count, ttl, nil
+}
+
+// GetLatestSubmission возвращает последнюю проверенную или ожидающую проверки посылку пользователя по задаче
+func (s *Storage) GetLatestSubmission(alias string, userID int64) (LatestSubmission, error) {
+	query := `
+        SELECT id, COALESCE(submission_code, '{}'), status, COALESCE(score, 0), COALESCE(hints, '{}')
+        FROM submissions
+        WHERE task_alias = $1 AND user_id = $2
+        ORDER BY submitted_at DESC, id DESC
+        LIMIT 1
+    `
+	var submission LatestSubmission
+	err := s.db.QueryRow(context.Background(), query, alias, userID).Scan(
+		&submission.ID, &submission.Code, &submission.Status, &submission.Score, &submission.Hints)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return LatestSubmission{}, fmt.Errorf("submission not found")
+	}
+	if err != nil {
+		return LatestSubmission{}, fmt.Errorf("failed to get latest submission: %v", err)
+	}
+	return submission, nil
+}
+
+// ErrTutorReplyPending — пользователь ещё ждёт ответа наставника по задаче
+var ErrTutorReplyPending = errors.New("tutor reply is pending")
+
+// SaveTutorQuestion сохраняет вопрос пользователя и пустой ответ наставника со статусом Pending.
+// Ожидающий ответ у пользователя по задаче может быть только один (уникальный индекс), иначе
+// возвращается ErrTutorReplyPending; ответы, начатые раньше staleBefore, считаются брошенными
+// (например, инстанс упал) и получают статус Failed
+func (s *Storage) SaveTutorQuestion(taskID, userID int64, question string, staleBefore time.Time) (int64, int64, error) {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	queryStale := `
+        UPDATE tutor_messages SET status = 'Failed'
+        WHERE task_id = $1 AND user_id = $2 AND status = 'Pending' AND created_at < $3
+    `
+	if _, err := tx.Exec(context.Background(), queryStale, taskID, userID, staleBefore); err != nil {
+		return 0, 0, fmt.Errorf("failed to fail stale tutor replies: %v", err)
+	}
+
+	query := `
+        INSERT INTO tutor_messages (task_id, user_id, role, content, status, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id
+    `
+	now := time.Now().UTC()
+	var questionID, replyID int64
+	if err := tx.QueryRow(context.Background(), query, taskID, userID, TutorRoleStudent, question, "Done", now).Scan(&questionID); err != nil {
+		return 0, 0, fmt.Errorf("failed to save tutor question: %v", err)
+	}
+	if err := tx.QueryRow(context.Background(), query, taskID, userID, TutorRoleTutor, "", "Pending", now).Scan(&replyID); err != nil {
+		if strings.Contains(err.Error(), "unique constraint") {
+			return 0, 0, ErrTutorReplyPending
+		}
+		return 0, 0, fmt.Errorf("failed to save tutor reply: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return questionID, replyID, nil
+}
+
+// CompleteTutorReply сохраняет текст ответа наставника
+func (s *Storage) CompleteTutorReply(replyID int64, content string) error {
+	result, err := s.db.Exec(context.Background(), `UPDATE tutor_messages SET content = $2, status = 'Done' WHERE id = $1`, replyID, content)
+	if err != nil {
+		return fmt.Errorf("failed to complete tutor reply: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("tutor message with ID %d not found", replyID)
+	}
+	return nil
+}
+
+// FailTutorReply отмечает, что ответ наставника получить не удалось
+func (s *Storage) FailTutorReply(replyID int64) error {
+	result, err := s.db.Exec(context.Background(), `UPDATE tutor_messages SET status = 'Failed' WHERE id = $1`, replyID)
+	if err != nil {
+		return fmt.Errorf("failed to fail tutor reply: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("tutor message with ID %d not found", replyID)
+	}
+	return nil
+}
+
+// GetTutorMessages возвращает последние limit сообщений чата пользователя по задаче в порядке отправки
+func (s *Storage) GetTutorMessages(taskID, userID int64, limit int) ([]TutorMessage, error) {
+	query := `
+        SELECT id, role, content, status, created_at
+        FROM (
+            SELECT id, role, content, status, created_at
+            FROM tutor_messages
+            WHERE task_id = $1 AND user_id = $2
+            ORDER BY id DESC
+            LIMIT $3
+        ) latest
+        ORDER BY id
+    `
+	rows, err := s.db.Query(context.Background(), query, taskID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tutor messages: %v", err)
+	}
+	defer rows.Close()
+
+	messages := make([]TutorMessage, 0)
+	for rows.Next() {
+		var message TutorMessage
+		if err := rows.Scan(&message.ID, &message.Role, &message.Content, &message.Status, &message.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tutor message: %v", err)
+		}
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tutor messages: %v", err)
+	}
+	return messages, nil
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type TwoFactorState struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+// GetPasswordHashByID возвращает хэш пароля пользователя по его ID
+func (s *Storage) GetPasswordHashByID(userID int64) (string, error) {
+	query := `
+        SELECT password_hash
+        FROM users
+        WHERE id = $1
+    `
+	var passwordHash string
+	err := s.db.QueryRow(context.Background(), query, userID).Scan(&passwordHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %v", err)
+	}
+	return passwordHash, nil
+}
+
+// GetTwoFactorState возвращает состояние двухфакторной аутентификации пользователя
+func (s *Storage) GetTwoFactorState(userID int64) (TwoFactorState, error) {
+	query := `
+        SELECT COALESCE(totp_secret, ''), totp_enabled, totp_last_step
+        FROM users
+        WHERE id = $1
+    `
+	var state TwoFactorState
+	err := s.db.QueryRow(context.Background(), query, userID).Scan(&state.Secret, &state.Enabled, &state.LastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TwoFactorState{}, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return TwoFactorState{}, fmt.Errorf("failed to get two-factor state: %v", err)
+	}
+	return state, nil
+}
+
+// ErrTOTPAlreadyEnabled — 2FA уже включена, новый секрет не сохраняется
+var ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// SetPendingTOTPSecret сохраняет секрет, ожидающий подтверждения (2FA пока не включена)
+func (s *Storage) SetPendingTOTPSecret(userID int64, secret string) error {
+	query := `
+        UPDATE users
+        SET totp_secret = $2
+        WHERE id = $1 AND totp_enabled = FALSE
+    `
+	result, err := s.db.Exec(context.Background(), query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save totp secret: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP включает 2FA и заменяет коды восстановления
+func (s *Storage) EnableTOTP(userID int64, step int64, recoveryCodeHashes []string) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	queryUser := `
+        UPDATE users
+        SET totp_enabled = TRUE, totp_last_step = $2
+        WHERE id = $1 AND totp_secret IS NOT NULL
+    `
+	result, err := tx.Exec(context.Background(), queryUser, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %v", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("two-factor enrollment not started")
+	}
+
+	if _, err := tx.Exec(context.Background(), `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+
+	queryCode := `
+        INSERT INTO two_factor_recovery_codes (user_id, code_hash, created_at)
+        VALUES ($1, $2, $3)
+    `
+	createdAt := time.Now().UTC()
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.Exec(context.Background(), queryCode, userID, hash, createdAt); err != nil {
+			return fmt.Errorf("failed to save recovery code: %v", err)
+		}
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// DisableTOTP выключает 2FA и удаляет секрет и коды восстановления
+func (s *Storage) DisableTOTP(userID int64) error {
+	tx, err := s.db.Begin(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	queryUser := `
+        UPDATE users
+        SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0
+        WHERE id = $1
+    `
+	if _, err := tx.Exec(context.Background(), queryUser, userID); err != nil {
+		return fmt.Errorf("failed to disable totp: %v", err)
+	}
+	if _, err := tx.Exec(context.Background(), `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %v", err)
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// ConsumeTOTPStep запоминает использованный интервал; повторное использование кода возвращает false
+func (s *Storage) ConsumeTOTPStep(userID int64, step int64) (bool, error) {
+	query := `
+        UPDATE users
+        SET totp_last_step = $2
+        WHERE id = $1 AND totp_last_step < $2
+    `
+	result, err := s.db.Exec(context.Background(), query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to update totp step: %v", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode погашает неиспользованный код восстановления
+func (s *Storage) UseRecoveryCode(userID int64, codeHash string) (bool, error) {
+	query := `
+        UPDATE two_factor_recovery_codes
+        SET used_at = $3
+        WHERE id = (
+            SELECT id FROM two_factor_recovery_codes
+            WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+            LIMIT 1
+        )
+    `
+	result, err := s.db.Exec(context.Background(), query, userID, codeHash, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %v", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,202 @@
+package task_progress
+
+import (
+	"codular-backend/internal/config"
+	"codular-backend/internal/llm"
+	"codular-backend/internal/storage/database"
+	"codular-backend/lib/llmjson"
+	"codular-backend/lib/logger/sl"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Статусы задачи в Redis
+const (
+	StatusProcessing = "Processing"
+	StatusDone       = "Done"
+	StatusError      = "Error"
+	StatusCancelled  = "Cancelled"
+)
+
+// cancelTTL — сколько хранится пометка об отмене; дольше генерация не длится
+const cancelTTL = 10 * time.Minute
+
+type Storage interface {
+	SetTaskStatus(alias string, status database.TaskStatus) error
+	RequestTaskCancel(alias string, ttl time.Duration) error
+	TaskCancelRequested(alias string) (bool, error)
+	ClearTaskCancel(alias string) error
+}
+
+// Publisher публикует ход генерации в статусы задач
+type Publisher struct {
+	storage Storage
+	cfg     config.TaskProgress
+}
+
+// Default используется обработчиками; инициализируется в main через Init
+var Default *Publisher
+
+func Init(storage Storage, cfg config.TaskProgress) {
+	Default = &Publisher{storage: storage, cfg: cfg}
+}
+
+// Tracker следит за одной генерацией: раз в Interval пишет прогресс в статус "Processing"
+// и проверяет, не запрошена ли отмена. Отмена отменяет контекст, возвращённый Start
+type Tracker struct {
+	publisher *Publisher
+	alias     string
+	userID    int64
+	started   time.Time
+	cancel    context.CancelFunc
+	log       *slog.Logger
+
+	mu       sync.Mutex
+	progress llm.Progress
+
+	stop      chan struct{}
+	done      chan struct{}
+	cancelled bool
+}
+
+// Start запускает отслеживание генерации задачи alias. Если Default не инициализирован,
+// возвращается трекер, который ничего не публикует
+func Start(ctx context.Context, alias string, userID int64, log *slog.Logger) (context.Context, *Tracker) {
+	ctx, cancel := context.WithCancel(ctx)
+	t := &Tracker{
+		publisher: Default,
+		alias:     alias,
+		userID:    userID,
+		started:   time.Now(),
+		cancel:    cancel,
+		log:       log,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if Default == nil {
+		close(t.done)
+		return ctx, t
+	}
+	// Пометка могла остаться от прошлой генерации по этому алиасу (перегенерация)
+	if err := Default.storage.ClearTaskCancel(alias); err != nil {
+		log.Warn("failed to clear task cancel", sl.Err(err))
+	}
+	if Default.cfg.Interval <= 0 {
+		close(t.done)
+		return ctx, t
+	}
+	go t.run()
+	return ctx, t
+}
+
+// Initial возвращает статус новой генерации; время обновления позволяет отменить её,
+// даже если обработчик упал до первой публикации прогресса
+func Initial(userID int64) database.TaskStatus {
+	return database.TaskStatus{Status: StatusProcessing, UserID: userID, Progress: &database.TaskProgress{UpdatedAt: time.Now().UTC()}}
+}
+
+// Update запоминает последний прогресс ответа модели; подходит как llm.Options.OnProgress
+func (t *Tracker) Update(progress llm.Progress) {
+	t.mu.Lock()
+	t.progress = progress
+	t.mu.Unlock()
+}
+
+// Stop останавливает публикацию. Вызывается до записи итогового статуса, чтобы он не был перезаписан
+func (t *Tracker) Stop() {
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+	<-t.done
+	t.cancel()
+}
+
+// Cancelled сообщает, была ли генерация отменена пользователем. Пометка проверяется ещё раз:
+// отмена могла прийти после последней проверки или при выключенной публикации прогресса
+func (t *Tracker) Cancelled() bool {
+	<-t.done
+	if t.cancelled || t.publisher == nil {
+		return t.cancelled
+	}
+	requested, err := t.publisher.storage.TaskCancelRequested(t.alias)
+	if err != nil {
+		t.log.Warn("failed to check task cancel", sl.Err(err))
+	}
+	t.cancelled = requested
+	return requested
+}
+
+func (t *Tracker) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.publisher.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+
+		requested, err := t.publisher.storage.TaskCancelRequested(t.alias)
+		if err != nil {
+			t.log.Warn("failed to check task cancel", sl.Err(err))
+		}
+		if requested {
+			t.log.Info("task generation cancelled by user")
+			t.cancelled = true
+			t.cancel()
+			return
+		}
+
+		if err := t.publisher.storage.SetTaskStatus(t.alias, t.status()); err != nil {
+			t.log.Warn("failed to publish task progress", sl.Err(err))
+		}
+	}
+}
+
+func (t *Tracker) status() database.TaskStatus {
+	t.mu.Lock()
+	progress := t.progress
+	t.mu.Unlock()
+
+	description, _ := llmjson.PartialString(progress.Content, "description")
+	return database.TaskStatus{
+		Status: StatusProcessing,
+		UserID: t.userID,
+		Progress: &database.TaskProgress{
+			Model:          progress.Model,
+			TokensReceived: progress.Chunks,
+			ElapsedMs:      time.Since(t.started).Milliseconds(),
+			Description:    description,
+			UpdatedAt:      time.Now().UTC(),
+		},
+	}
+}
+
+// Cancel запрашивает отмену генерации. Если статус давно не обновлялся (обработчика нет в живых),
+// статус "Cancelled" выставляется сразу; иначе его выставит обработчик при следующей проверке
+func Cancel(alias string, status database.TaskStatus) (database.TaskStatus, error) {
+	if Default == nil {
+		return status, nil
+	}
+	if err := Default.storage.RequestTaskCancel(alias, cancelTTL); err != nil {
+		return status, err
+	}
+
+	// Без публикации прогресса обработчик пометку не проверяет — отменяем статус сразу
+	stale := Default.cfg.Interval <= 0 || status.Progress == nil || time.Since(status.Progress.UpdatedAt) > Default.cfg.StaleAfter
+	if !stale {
+		return status, nil
+	}
+
+	cancelled := database.TaskStatus{Status: StatusCancelled, UserID: status.UserID}
+	if err := Default.storage.SetTaskStatus(alias, cancelled); err != nil {
+		return status, err
+	}
+	return cancelled, nil
+}
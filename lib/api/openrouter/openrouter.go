@@ -1,30 +1,96 @@
 package openrouter
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// DefaultModel используется, если модель не задана
+const DefaultModel = "meta-llama/llama-4-scout:free"
+
+// DefaultTimeout — дедлайн одного запроса, если он не задан в клиенте
+const DefaultTimeout = 2 * time.Minute
+
+const chatURL = "https://openrouter.ai/api/v1/chat/completions"
+
+// ErrStalled — потоковый ответ перестал приходить дольше IdleTimeout
+var ErrStalled = errors.New("stream stalled")
+
 type OpenRouterClient struct {
 	APIKey      string
 	Model       string
 	Temperature float64
+	// Timeout — дедлайн одного запроса; 0 — без дополнительного дедлайна (только контекст)
+	Timeout time.Duration
+	// ResponseFormat — требуемый формат ответа; nil — свободный текст
+	ResponseFormat *ResponseFormat
+	// IdleTimeout — сколько потоковый ответ может молчать, прежде чем запрос будет прерван; 0 — без ограничения
+	IdleTimeout time.Duration
+}
+
+// ResponseFormat — структурированный ответ (response_format); поддерживается не всеми провайдерами
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string      `json:"name"`
+	Strict bool        `json:"strict"`
+	Schema interface{} `json:"schema"`
+}
+
+// JSONSchemaFormat возвращает формат ответа, требующий JSON по схеме
+func JSONSchemaFormat(name string, schema interface{}) *ResponseFormat {
+	return &ResponseFormat{Type: "json_schema", JSONSchema: &JSONSchema{Name: name, Strict: true, Schema: schema}}
+}
+
+// StatusError — ответ OpenRouter с кодом, отличным от 200
+type StatusError struct {
+	StatusCode int
+	// RetryAfter — значение заголовка Retry-After (0, если заголовка нет)
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request error. Status: %d. Response: %s", e.StatusCode, e.Body)
+}
+
+// UnsupportedResponseFormat сообщает, что провайдер отклонил запрос из-за response_format
+func (e *StatusError) UnsupportedResponseFormat() bool {
+	if e.StatusCode != http.StatusBadRequest && e.StatusCode != http.StatusNotFound {
+		return false
+	}
+	body := strings.ToLower(e.Body)
+	return strings.Contains(body, "response_format") || strings.Contains(body, "json_schema") ||
+		strings.Contains(body, "structured output") || strings.Contains(body, "requested parameters")
+}
+
+// Retryable сообщает, имеет ли смысл повторить запрос (rate limit или ошибка на стороне сервера)
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
 }
 
 func NewClient(apiKey, model string, temperature float64) *OpenRouterClient {
 	if model == "" {
-		model = "meta-llama/llama-4-scout:free"
+		model = DefaultModel
 	}
 	if temperature == 0 {
 		temperature = 0.7
 	}
-	return &OpenRouterClient{APIKey: apiKey, Model: model, Temperature: temperature}
+	return &OpenRouterClient{APIKey: apiKey, Model: model, Temperature: temperature, Timeout: DefaultTimeout}
 }
 
 type Message struct {
@@ -33,9 +99,25 @@ type Message struct {
 }
 
 type Request struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Temperature float64   `json:"temperature,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    float64         `json:"temperature,omitempty"`
+	Usage          *UsageOptions   `json:"usage,omitempty"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+}
+
+// UsageOptions включает в ответ стоимость запроса (usage accounting OpenRouter)
+type UsageOptions struct {
+	Include bool `json:"include"`
+}
+
+// Usage — расход токенов и стоимость запроса
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
 }
 
 type Choice struct {
@@ -44,18 +126,139 @@ type Choice struct {
 
 type Response struct {
 	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// StreamChunk — одно событие потокового ответа; usage приходит в последнем событии
+type StreamChunk struct {
+	Choices []StreamChoice `json:"choices"`
+	Usage   *Usage         `json:"usage,omitempty"`
+	Error   *StreamError   `json:"error,omitempty"`
 }
 
-// cleanLLMResponse удаляет Markdown-форматирование из ответа OpenRouter
-func CleanLLMResponse(response string) string {
-	// Удаляем ```json и ```, а также лишние пробелы и переносы строк
-	trimmed := strings.TrimSpace(response)
-	trimmed = strings.TrimPrefix(trimmed, "```json")
-	trimmed = strings.TrimSuffix(trimmed, "```")
-	return strings.TrimSpace(trimmed)
+type StreamChoice struct {
+	Delta        Message `json:"delta"`
+	FinishReason string  `json:"finish_reason,omitempty"`
 }
 
-func (client *OpenRouterClient) SendChat(systemPrompt, userPrompt string, temperature ...float64) (string, error) {
+// StreamError — ошибка, возникшая после начала потока (заголовки уже отправлены с кодом 200)
+type StreamError struct {
+	Code    interface{} `json:"code"`
+	Message string      `json:"message"`
+}
+
+// SendChat отправляет запрос и возвращает ответ модели вместе с расходом токенов
+func (client *OpenRouterClient) SendChat(systemPrompt, userPrompt string, temperature ...float64) (string, Usage, error) {
+	return client.SendChatContext(context.Background(), systemPrompt, userPrompt, temperature...)
+}
+
+// SendChatContext отправляет запрос с учётом контекста и дедлайна клиента
+func (client *OpenRouterClient) SendChatContext(ctx context.Context, systemPrompt, userPrompt string, temperature ...float64) (string, Usage, error) {
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+
+	resp, err := client.do(ctx, systemPrompt, userPrompt, false, temperature...)
+	if err != nil {
+		return "", Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("error reading response: %v", err)
+	}
+
+	var apiResponse Response
+	err = json.Unmarshal(body, &apiResponse)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("error parsing response: %v", err)
+	}
+	if len(apiResponse.Choices) == 0 {
+		return "", Usage{}, fmt.Errorf("no response received from OpenRouter")
+	}
+
+	return apiResponse.Choices[0].Message.Content, apiResponse.Usage, nil
+}
+
+// StreamChatContext отправляет запрос в потоковом режиме (SSE) и вызывает onDelta для каждого фрагмента ответа.
+// Возвращает полный ответ и расход токенов, как SendChatContext. Если поток молчит дольше IdleTimeout,
+// запрос прерывается с ErrStalled
+func (client *OpenRouterClient) StreamChatContext(ctx context.Context, systemPrompt, userPrompt string, onDelta func(string), temperature ...float64) (string, Usage, error) {
+	if client.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	// Сторож простоя: перезапускается каждым событием потока (комментарии-пинги OpenRouter не считаются)
+	var idle *time.Timer
+	if client.IdleTimeout > 0 {
+		idle = time.AfterFunc(client.IdleTimeout, func() { cancel(ErrStalled) })
+		defer idle.Stop()
+	}
+
+	resp, err := client.do(ctx, systemPrompt, userPrompt, true, temperature...)
+	if err != nil {
+		return "", Usage{}, streamErr(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var usage Usage
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+		if idle != nil {
+			idle.Reset(client.IdleTimeout)
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", Usage{}, fmt.Errorf("error parsing stream chunk: %v", err)
+		}
+		if chunk.Error != nil {
+			return "", Usage{}, chunk.Error.err()
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				if onDelta != nil {
+					onDelta(choice.Delta.Content)
+				}
+			}
+			if choice.FinishReason == "error" {
+				return "", Usage{}, fmt.Errorf("stream finished with error")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", Usage{}, streamErr(ctx, fmt.Errorf("error reading stream: %v", err))
+	}
+	if content.Len() == 0 {
+		return "", Usage{}, fmt.Errorf("no response received from OpenRouter")
+	}
+
+	return content.String(), usage, nil
+}
+
+// do отправляет запрос и возвращает ответ с кодом 200; иначе — *StatusError
+func (client *OpenRouterClient) do(ctx context.Context, systemPrompt, userPrompt string, stream bool, temperature ...float64) (*http.Response, error) {
 	temp := client.Temperature
 	if len(temperature) > 0 {
 		temp = temperature[0]
@@ -67,48 +270,73 @@ func (client *OpenRouterClient) SendChat(systemPrompt, userPrompt string, temper
 	}
 	messages = append(messages, Message{Role: "user", Content: userPrompt})
 
-	requestBody := Request{Model: client.Model, Messages: messages, Temperature: temp}
+	requestBody := Request{
+		Model:          client.Model,
+		Messages:       messages,
+		Temperature:    temp,
+		Usage:          &UsageOptions{Include: true},
+		ResponseFormat: client.ResponseFormat,
+		Stream:         stream,
+	}
 	jsonRequestBody, err := json.Marshal(requestBody)
 	if err != nil {
-		return "", fmt.Errorf("error marshaling request: %v", err)
+		return nil, fmt.Errorf("error marshaling request: %v", err)
 	}
 
-	url := "https://openrouter.ai/api/v1/chat/completions"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonRequestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", chatURL, bytes.NewBuffer(jsonRequestBody))
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+		return nil, fmt.Errorf("error creating request: %v", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+client.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("error sending request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("error reading response: %v", err)
+		return nil, fmt.Errorf("error sending request: %v", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("request error. Status: %d. Response: %s", resp.StatusCode, string(body))
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			Body:       string(body),
+		}
 	}
+	return resp, nil
+}
 
-	var apiResponse Response
-	err = json.Unmarshal(body, &apiResponse)
-	if err != nil {
-		return "", fmt.Errorf("error parsing response: %v", err)
+// streamErr подменяет ошибку чтения на ErrStalled, если поток был прерван сторожем простоя
+func streamErr(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), ErrStalled) {
+		return ErrStalled
 	}
-	if len(apiResponse.Choices) == 0 {
-		return "", fmt.Errorf("no response received from OpenRouter")
+	return err
+}
+
+// err превращает ошибку из потока в *StatusError, если OpenRouter передал HTTP-код
+func (e *StreamError) err() error {
+	if code, ok := e.Code.(float64); ok && code >= 400 {
+		return &StatusError{StatusCode: int(code), Body: e.Message}
 	}
+	return fmt.Errorf("stream error: %s", e.Message)
+}
 
-	responseText := apiResponse.Choices[0].Message.Content
-	if strings.HasPrefix(responseText, "\\boxed{") && strings.HasSuffix(responseText, "}") {
-		responseText = responseText[len("\\boxed{") : len(responseText)-1]
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP-даты
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
 	}
-	return responseText, nil
+	return 0
 }
 
 type SystemPrompts map[string]string
@@ -0,0 +1,53 @@
+package api_token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Prefix отличает персональные токены доступа от JWT в заголовке Authorization
+const Prefix = "cdl_"
+
+const (
+	ScopeTasksRead        = "tasks:read"
+	ScopeTasksWrite       = "tasks:write"
+	ScopeSubmissionsRead  = "submissions:read"
+	ScopeSubmissionsWrite = "submissions:write"
+)
+
+// Scopes перечисляет все допустимые области действия токенов
+var Scopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeSubmissionsRead, ScopeSubmissionsWrite}
+
+// Generate создаёт новый токен и возвращает его значение и короткий префикс для отображения
+func Generate() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := Prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, token[:len(Prefix)+6], nil
+}
+
+// Hash возвращает SHA-256 хэш токена, который хранится в базе
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken проверяет, что строка похожа на персональный токен
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// HasScope проверяет наличие области действия в списке
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
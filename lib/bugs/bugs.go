@@ -0,0 +1,115 @@
+// Package bugs строит задачи «найди ошибку»: в исходный код по строкам вносятся ошибки,
+// номера строк и исправления запоминаются, а ответ проверяется построчно без модели.
+package bugs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxBugs — сколько ошибок можно внести в одну задачу
+const MaxBugs = 10
+
+// Bug — внесённая ошибка
+type Bug struct {
+	// Line — номер строки (с 1)
+	Line int `json:"line"`
+	// Fixed — исходная строка, то есть исправление
+	Fixed string `json:"fixed"`
+	// Explanation — объяснение ошибки от модели; пользователю не показывается
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// Injection — ошибка, предложенная моделью: строка line заменяется на buggyLine
+type Injection struct {
+	Line        int    `json:"line"`
+	BuggyLine   string `json:"buggyLine"`
+	Explanation string `json:"explanation"`
+}
+
+// Number нумерует строки кода для модели: "1| ..."
+func Number(code string) string {
+	lines := splitLines(code)
+	var b strings.Builder
+	for i, line := range lines {
+		b.WriteString(strconv.Itoa(i + 1))
+		b.WriteString("| ")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Inject вносит ошибки в код. Код строится из исходного, а не берётся из ответа модели,
+// поэтому номера строк точны, а исправление — это исходная строка. Ошибки в пустых строках,
+// повторные, многострочные и не меняющие строку отбрасываются. Отступ исходной строки сохраняется
+func Inject(code string, injections []Injection) (string, []Bug) {
+	lines := splitLines(code)
+	var bugs []Bug
+	used := make(map[int]bool, len(injections))
+	for _, injection := range injections {
+		if injection.Line < 1 || injection.Line > len(lines) || used[injection.Line] || strings.Contains(injection.BuggyLine, "\n") {
+			continue
+		}
+		original := lines[injection.Line-1]
+		if strings.TrimSpace(original) == "" || Normalize(injection.BuggyLine) == Normalize(original) {
+			continue
+		}
+		used[injection.Line] = true
+		bugs = append(bugs, Bug{Line: injection.Line, Fixed: original, Explanation: injection.Explanation})
+
+		indent := original[:len(original)-len(strings.TrimLeft(original, " \t"))]
+		lines[injection.Line-1] = indent + strings.TrimSpace(injection.BuggyLine)
+	}
+	return strings.Join(lines, "\n"), bugs
+}
+
+// Restore применяет restore (возврат скрытых секретов) к коду задачи и исправлениям построчно.
+// Восстановленное значение может занимать несколько строк (приватный ключ), поэтому номера строк
+// ошибок сдвигаются соответственно
+func Restore(code string, bugs []Bug, restore func(string) string) (string, []Bug) {
+	lines := splitLines(code)
+	shift := make([]int, len(lines)+1)
+	for i, line := range lines {
+		lines[i] = restore(line)
+		shift[i+1] = shift[i] + strings.Count(lines[i], "\n")
+	}
+
+	restored := make([]Bug, len(bugs))
+	for i, bug := range bugs {
+		restored[i] = Bug{Line: bug.Line + shift[bug.Line-1], Fixed: restore(bug.Fixed), Explanation: restore(bug.Explanation)}
+	}
+	return strings.Join(lines, "\n"), restored
+}
+
+// Normalize убирает пробелы, чтобы исправление засчитывалось независимо от форматирования
+func Normalize(line string) string {
+	return strings.Join(strings.Fields(line), "")
+}
+
+func splitLines(code string) []string {
+	return strings.Split(strings.TrimRight(strings.ReplaceAll(code, "\r\n", "\n"), "\n"), "\n")
+}
+
+// Encode кодирует ошибки для хранения в ответах задачи: по одному JSON-объекту на ошибку
+func Encode(bugs []Bug) []string {
+	answers := make([]string, len(bugs))
+	for i, bug := range bugs {
+		encoded, _ := json.Marshal(bug)
+		answers[i] = string(encoded)
+	}
+	return answers
+}
+
+// Decode разбирает ответы задачи, сохранённые через Encode
+func Decode(answers []string) ([]Bug, error) {
+	bugs := make([]Bug, len(answers))
+	for i, answer := range answers {
+		if err := json.Unmarshal([]byte(answer), &bugs[i]); err != nil {
+			return nil, fmt.Errorf("invalid bug answer %d: %v", i+1, err)
+		}
+	}
+	return bugs, nil
+}
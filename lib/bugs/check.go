@@ -0,0 +1,100 @@
+package bugs
+
+import "sort"
+
+// Результат проверки строки
+const (
+	StatusFound         = "found"
+	StatusWrongFix      = "wrong_fix"
+	StatusMissed        = "missed"
+	StatusFalsePositive = "false_positive"
+)
+
+// Answer — строка, которую пользователь считает ошибочной, и необязательное исправление
+type Answer struct {
+	Line int    `json:"line" validate:"gte=1"`
+	Fix  string `json:"fix,omitempty"`
+}
+
+// LineResult — проверка одной строки
+type LineResult struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	// Fix — исправление пользователя, Expected — правильная строка (для модели, которая пишет подсказки)
+	Fix      string `json:"fix,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	// Explanation — объяснение ошибки, записанное при генерации
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// Grade — результат проверки ответа
+type Grade struct {
+	Score int
+	// Lines — результаты по строкам в порядке номеров
+	Lines []LineResult
+}
+
+// Solved сообщает, что все ошибки найдены, лишних строк нет и все исправления верны
+func (g Grade) Solved() bool {
+	for _, line := range g.Lines {
+		if line.Status != StatusFound {
+			return false
+		}
+	}
+	return true
+}
+
+// Wrong возвращает строки, с которыми пользователь ошибся
+func (g Grade) Wrong() []LineResult {
+	var wrong []LineResult
+	for _, line := range g.Lines {
+		if line.Status != StatusFound {
+			wrong = append(wrong, line)
+		}
+	}
+	return wrong
+}
+
+// Check проверяет ответ построчно. Каждая ошибка стоит одинаково: найденная строка с верным
+// исправлением или без него — полный балл, с неверным исправлением — половина; каждая лишняя
+// строка отнимает половину. Исправление сравнивается без учёта пробелов
+func Check(bugs []Bug, answers []Answer) Grade {
+	byLine := make(map[int]Bug, len(bugs))
+	for _, bug := range bugs {
+		byLine[bug.Line] = bug
+	}
+
+	var grade Grade
+	points := 0 // в половинах ошибки
+	answered := make(map[int]bool, len(answers))
+	for _, answer := range answers {
+		if answered[answer.Line] {
+			continue
+		}
+		answered[answer.Line] = true
+
+		bug, ok := byLine[answer.Line]
+		switch {
+		case !ok:
+			grade.Lines = append(grade.Lines, LineResult{Line: answer.Line, Status: StatusFalsePositive, Fix: answer.Fix})
+			points--
+		case answer.Fix != "" && Normalize(answer.Fix) != Normalize(bug.Fixed):
+			grade.Lines = append(grade.Lines, LineResult{Line: answer.Line, Status: StatusWrongFix, Fix: answer.Fix, Expected: bug.Fixed, Explanation: bug.Explanation})
+			points++
+		default:
+			grade.Lines = append(grade.Lines, LineResult{Line: answer.Line, Status: StatusFound})
+			points += 2
+		}
+	}
+	for _, bug := range bugs {
+		if !answered[bug.Line] {
+			grade.Lines = append(grade.Lines, LineResult{Line: bug.Line, Status: StatusMissed, Expected: bug.Fixed, Explanation: bug.Explanation})
+		}
+	}
+	sort.Slice(grade.Lines, func(i, j int) bool { return grade.Lines[i].Line < grade.Lines[j].Line })
+
+	if len(bugs) > 0 && points > 0 {
+		grade.Score = 100 * points / (2 * len(bugs))
+	}
+	return grade
+}
@@ -0,0 +1,59 @@
+package explain
+
+// Verdict — оценка моделью одного пункта; Point — номер пункта (с 1)
+type Verdict struct {
+	Point   int    `json:"point"`
+	Covered bool   `json:"covered"`
+	Hint    string `json:"hint"`
+}
+
+// PointResult — проверка одного пункта
+type PointResult struct {
+	Text    string
+	Covered bool
+	// Hint — наводящая подсказка модели к нераскрытому пункту
+	Hint string
+}
+
+// Grade — результат проверки объяснения
+type Grade struct {
+	Score int
+	// Points — результаты в порядке пунктов критериев
+	Points []PointResult
+}
+
+// Solved сообщает, что раскрыты все пункты
+func (g Grade) Solved() bool {
+	for _, p := range g.Points {
+		if !p.Covered {
+			return false
+		}
+	}
+	return true
+}
+
+// Check считает оценку как долю веса раскрытых пунктов. Оценку по каждому пункту даёт модель;
+// пункты, которые модель пропустила или указала дважды, считаются по первому вердикту или нераскрытыми
+func Check(points []Point, verdicts []Verdict) Grade {
+	byPoint := make(map[int]Verdict, len(verdicts))
+	for _, v := range verdicts {
+		if _, ok := byPoint[v.Point]; !ok {
+			byPoint[v.Point] = v
+		}
+	}
+
+	var grade Grade
+	total, covered := 0, 0
+	for i, p := range points {
+		v := byPoint[i+1]
+		grade.Points = append(grade.Points, PointResult{Text: p.Text, Covered: v.Covered, Hint: v.Hint})
+		total += p.Weight
+		if v.Covered {
+			covered += p.Weight
+		}
+	}
+	if total > 0 {
+		grade.Score = 100 * covered / total
+	}
+	return grade
+}
@@ -0,0 +1,62 @@
+// Package explain хранит критерии задач «объясни код»: ключевые пункты, которые должны быть
+// в объяснении студента, и считает оценку по пунктам, отмеченным моделью как раскрытые.
+package explain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxPoints — сколько пунктов может быть в критериях одной задачи
+const MaxPoints = 8
+
+// MaxWeight — наибольший вес пункта; вес 1 у второстепенных пунктов
+const MaxWeight = 3
+
+// Point — пункт критериев
+type Point struct {
+	Text   string `json:"point"`
+	Weight int    `json:"weight"`
+}
+
+// Prepare отбрасывает пустые и повторяющиеся пункты модели и приводит веса к 1..MaxWeight
+func Prepare(points []Point) []Point {
+	var prepared []Point
+	seen := make(map[string]bool, len(points))
+	for _, p := range points {
+		p.Text = strings.TrimSpace(p.Text)
+		key := strings.ToLower(p.Text)
+		if p.Text == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		p.Weight = min(max(p.Weight, 1), MaxWeight)
+		prepared = append(prepared, p)
+		if len(prepared) == MaxPoints {
+			break
+		}
+	}
+	return prepared
+}
+
+// Encode сохраняет критерии в ответах задачи: один JSON-объект на пункт
+func Encode(points []Point) []string {
+	answers := make([]string, len(points))
+	for i, p := range points {
+		encoded, _ := json.Marshal(p)
+		answers[i] = string(encoded)
+	}
+	return answers
+}
+
+// Decode разбирает ответы задачи, сохранённые через Encode
+func Decode(answers []string) ([]Point, error) {
+	points := make([]Point, len(answers))
+	for i, answer := range answers {
+		if err := json.Unmarshal([]byte(answer), &points[i]); err != nil {
+			return nil, fmt.Errorf("invalid rubric point %d: %v", i+1, err)
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,132 @@
+package hintladder
+
+import (
+	"codular-backend/lib/noises"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ForSkips строит лестницы к пропускам без модели: строка пропуска, вид ответа и ответ со скрытыми буквами.
+// Пропуски нумеруются по порядку меток placeholder в коде задачи
+func ForSkips(taskCode string, answers []string, placeholder string) []Ladder {
+	lines := placeholderLines(taskCode, placeholder)
+	ladders := make([]Ladder, len(answers))
+	for i, answer := range answers {
+		answer = strings.TrimSpace(answer)
+		where := fmt.Sprintf("Skip %d", i+1)
+		if i < len(lines) {
+			where = fmt.Sprintf("Skip %d on line %d", i+1, lines[i])
+		}
+		ladders[i] = Ladder{
+			Nudge:      where + ": read what the code around it does with this value.",
+			Pointer:    fmt.Sprintf("Skip %d: the answer is %s, %s long.", i+1, describe(answer), characters(utf8.RuneCountInString(answer))),
+			NearAnswer: fmt.Sprintf("Skip %d: %s", i+1, Mask(answer)),
+		}
+	}
+	return ladders
+}
+
+// ForNoises строит лестницы к правкам без модели: вид правки, её строки и почти готовое исправление
+func ForNoises(edits []noises.Edit) []Ladder {
+	ladders := make([]Ladder, len(edits))
+	for i, edit := range edits {
+		ladder := Ladder{Pointer: fmt.Sprintf("Edit %d: look at %s of the task code.", i+1, noises.LineRange(edit.NoisedLine, edit.NoisedCount))}
+		switch {
+		case edit.Kind == noises.KindNoise:
+			ladder.Nudge = fmt.Sprintf("Edit %d adds code that does not affect the result.", i+1)
+			ladder.NearAnswer = fmt.Sprintf("Edit %d: remove `%s`.", i+1, joinTrimmed(edit.Noised))
+		case edit.NoisedCount == 0:
+			ladder.Nudge = fmt.Sprintf("Edit %d removes part of the original program.", i+1)
+			ladder.NearAnswer = fmt.Sprintf("Edit %d: the missing code is `%s`.", i+1, Mask(joinTrimmed(edit.Original)))
+		default:
+			ladder.Nudge = fmt.Sprintf("Edit %d changes what the original program does.", i+1)
+			ladder.NearAnswer = fmt.Sprintf("Edit %d: the original code there is `%s`.", i+1, Mask(joinTrimmed(edit.Original)))
+		}
+		ladders[i] = ladder
+	}
+	return ladders
+}
+
+// Mask скрывает в тексте все буквы и цифры слов, кроме первой: len(arr) - 1 → l__(a__) - 1
+func Mask(text string) string {
+	var builder strings.Builder
+	inWord := false
+	for _, r := range text {
+		word := r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && inWord {
+			builder.WriteRune('_')
+		} else {
+			builder.WriteRune(r)
+		}
+		inWord = word
+	}
+	return builder.String()
+}
+
+// describe называет вид ответа, не раскрывая его
+func describe(answer string) string {
+	switch {
+	case answer == "":
+		return "empty"
+	case strings.HasSuffix(answer, `"`) || strings.HasSuffix(answer, "'"):
+		return "a string literal"
+	case strings.Trim(answer, "0123456789.-") == "":
+		return "a number"
+	case isName(answer):
+		return "a single name"
+	case strings.HasSuffix(answer, ")") && strings.Contains(answer, "(") && isName(strings.TrimSpace(answer[:strings.Index(answer, "(")])):
+		return "a function call"
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", " in ", " is "} {
+		if strings.Contains(answer, op) {
+			return "a comparison"
+		}
+	}
+	return "an expression"
+}
+
+// isName сообщает, что текст — имя, возможно составное: count, self.items, std::max
+func isName(text string) bool {
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if r != '_' && r != '.' && r != ':' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return !unicode.IsDigit([]rune(text)[0])
+}
+
+// placeholderLines возвращает номера строк (с 1) каждой метки пропуска в коде задачи
+func placeholderLines(code, placeholder string) []int {
+	if placeholder == "" {
+		return nil
+	}
+	var lines []int
+	for i, line := range strings.Split(code, "\n") {
+		for range strings.Count(line, placeholder) {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+func characters(n int) string {
+	if n == 1 {
+		return "1 character"
+	}
+	return fmt.Sprintf("%d characters", n)
+}
+
+func joinTrimmed(lines []string) string {
+	trimmed := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			trimmed = append(trimmed, line)
+		}
+	}
+	return strings.Join(trimmed, " ")
+}
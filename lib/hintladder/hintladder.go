@@ -0,0 +1,118 @@
+// Package hintladder описывает лестницы подсказок к задаче: у каждого пропуска или правки
+// три подсказки, от общего намёка до почти готового ответа. Каждая открытая ступень
+// снижает максимальную оценку следующих посылок пользователя.
+package hintladder
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Ступени лестницы
+const (
+	// LevelNudge — общий намёк: что делает это место кода
+	LevelNudge = 1
+	// LevelPointer — где именно ошибка или какой вид у ответа
+	LevelPointer = 2
+	// LevelNearAnswer — ответ, в котором скрыта лишь часть символов
+	LevelNearAnswer = 3
+)
+
+// MaxLevel — последняя ступень лестницы
+const MaxLevel = LevelNearAnswer
+
+var levelNames = [...]string{"", "nudge", "pointer", "near_answer"}
+
+// penalties — какую часть стоимости пропуска или правки (в процентах) теряет пользователь,
+// открыв подсказки до ступени включительно
+var penalties = [...]int{0, 20, 50, 80}
+
+// LevelName возвращает имя ступени для ответа API
+func LevelName(level int) string {
+	if level < 1 || level > MaxLevel {
+		return ""
+	}
+	return levelNames[level]
+}
+
+// Ladder — подсказки к одному пропуску или одной правке
+type Ladder struct {
+	Nudge      string `json:"nudge"`
+	Pointer    string `json:"pointer"`
+	NearAnswer string `json:"nearAnswer"`
+}
+
+// Hint возвращает подсказку ступени level
+func (l Ladder) Hint(level int) string {
+	switch level {
+	case LevelNudge:
+		return l.Nudge
+	case LevelPointer:
+		return l.Pointer
+	case LevelNearAnswer:
+		return l.NearAnswer
+	}
+	return ""
+}
+
+// Fill дополняет пустые ступени лестниц подсказками из fallback; лишние лестницы отбрасываются,
+// недостающие берутся из fallback целиком
+func Fill(ladders, fallback []Ladder) []Ladder {
+	result := make([]Ladder, len(fallback))
+	for i, f := range fallback {
+		var l Ladder
+		if i < len(ladders) {
+			l = ladders[i]
+		}
+		if l.Nudge == "" {
+			l.Nudge = f.Nudge
+		}
+		if l.Pointer == "" {
+			l.Pointer = f.Pointer
+		}
+		if l.NearAnswer == "" {
+			l.NearAnswer = f.NearAnswer
+		}
+		result[i] = l
+	}
+	return result
+}
+
+// MaxScore возвращает наибольшую оценку, доступную после открытия подсказок: levels — последняя
+// открытая ступень по номеру элемента (с 1), items — число пропусков или правок в задаче
+func MaxScore(items int, levels map[int]int) int {
+	if items <= 0 {
+		return 100
+	}
+	penalty := 0
+	for _, level := range levels {
+		penalty += penalties[min(max(level, 0), MaxLevel)]
+	}
+	return max(100-penalty/items, 0)
+}
+
+// Apply ограничивает оценку посылки максимальной оценкой после подсказок
+func Apply(score, maxScore int) int {
+	return score * maxScore / 100
+}
+
+// Encode сохраняет лестницы задачи в JSON; nil для задачи без лестниц
+func Encode(ladders []Ladder) json.RawMessage {
+	if len(ladders) == 0 {
+		return nil
+	}
+	encoded, _ := json.Marshal(ladders)
+	return encoded
+}
+
+// Decode разбирает лестницы, сохранённые через Encode
+func Decode(data json.RawMessage) ([]Ladder, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var ladders []Ladder
+	if err := json.Unmarshal(data, &ladders); err != nil {
+		return nil, fmt.Errorf("invalid hint ladders: %v", err)
+	}
+	return ladders, nil
+}
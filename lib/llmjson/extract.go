@@ -0,0 +1,109 @@
+package llmjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxCandidates ограничивает перебор объектов в длинных ответах
+const maxCandidates = 20
+
+// Extract находит в ответе LLM первый JSON-объект, подходящий под схему,
+// при необходимости исправляя его, и возвращает его в каноническом виде
+func Extract(response string, schema *Schema) ([]byte, error) {
+	text := unwrap(response)
+
+	var lastErr error
+	for _, candidate := range candidates(text) {
+		for _, raw := range []string{candidate, Repair(candidate)} {
+			var value interface{}
+			if err := json.Unmarshal([]byte(raw), &value); err != nil {
+				lastErr = fmt.Errorf("invalid JSON: %v", err)
+				continue
+			}
+			normalized, err := schema.Normalize(value)
+			if err != nil {
+				lastErr = fmt.Errorf("response does not match schema: %v", err)
+				continue
+			}
+			return json.Marshal(normalized)
+		}
+	}
+
+	if lastErr == nil {
+		return nil, fmt.Errorf("no JSON object found in response")
+	}
+	return nil, lastErr
+}
+
+// unwrap снимает обёртки, которые добавляют некоторые модели: \boxed{...} и блоки ```json
+func unwrap(response string) string {
+	text := strings.TrimSpace(response)
+	if strings.HasPrefix(text, "\\boxed{") && strings.HasSuffix(text, "}") {
+		text = text[len("\\boxed{") : len(text)-1]
+	}
+	if start := strings.Index(text, "```"); start >= 0 {
+		body := text[start+3:]
+		if newline := strings.IndexByte(body, '\n'); newline >= 0 && !strings.ContainsAny(body[:newline], "{[") {
+			body = body[newline+1:]
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			body = body[:end]
+		}
+		if strings.Contains(body, "{") {
+			text = body
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// candidates возвращает подстроки, начинающиеся с '{' и заканчивающиеся парной '}'.
+// Незакрытый объект (обрезанный ответ) берётся до конца текста — его достроит Repair
+func candidates(text string) []string {
+	var result []string
+	for start := 0; start < len(text) && len(result) < maxCandidates; start++ {
+		if text[start] != '{' {
+			continue
+		}
+		end := matchingBrace(text, start)
+		if end < 0 {
+			result = append(result, text[start:])
+			continue
+		}
+		result = append(result, text[start:end+1])
+	}
+	return result
+}
+
+func matchingBrace(text string, start int) int {
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,54 @@
+package llmjson
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PartialString ищет в незавершённом ответе строковое поле key и возвращает его значение,
+// если строка уже закрыта. Используется для показа прогресса потоковой генерации,
+// поэтому ищется первое вхождение ключа на любом уровне вложенности
+func PartialString(partial, key string) (string, bool) {
+	needle := `"` + key + `"`
+	for offset := 0; ; {
+		i := strings.Index(partial[offset:], needle)
+		if i < 0 {
+			return "", false
+		}
+		rest := strings.TrimLeft(partial[offset+i+len(needle):], " \t\r\n")
+		offset += i + len(needle)
+		if !strings.HasPrefix(rest, ":") {
+			// Ключ встретился как значение, ищем дальше
+			continue
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		if !strings.HasPrefix(rest, `"`) {
+			return "", false
+		}
+		end := closingQuote(rest)
+		if end < 0 {
+			return "", false
+		}
+		var value string
+		if err := json.Unmarshal([]byte(rest[:end+1]), &value); err != nil {
+			return "", false
+		}
+		return value, true
+	}
+}
+
+// closingQuote возвращает позицию кавычки, закрывающей строку, начинающуюся в s[0], или -1
+func closingQuote(s string) int {
+	escaped := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,140 @@
+package llmjson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Repair исправляет типичные дефекты JSON из ответов LLM:
+// неэкранированные переводы строк и табуляции внутри строк, недопустимые escape-последовательности
+// (например, \d из регулярных выражений), висячие запятые, «умные» кавычки, комментарии //,
+// литералы Python (True, False, None) и незакрытые строки и скобки в обрезанном ответе
+func Repair(raw string) string {
+	var b strings.Builder
+	var stack []byte
+	inString := false
+	// smartQuoted — строка открыта «умной» кавычкой и закрывается парной ей
+	smartQuoted := false
+
+	runes := []rune(raw)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if inString {
+			switch {
+			case r == '\\':
+				if i+1 < len(runes) && validEscape(runes, i+1) {
+					b.WriteRune(r)
+					b.WriteRune(runes[i+1])
+					i++
+				} else {
+					b.WriteString(`\\`)
+				}
+			case r == '"' && !smartQuoted, r == '”' && smartQuoted:
+				inString = false
+				b.WriteRune('"')
+			case r == '"':
+				b.WriteString(`\"`)
+			case r == '\n':
+				b.WriteString(`\n`)
+			case r == '\r':
+				b.WriteString(`\r`)
+			case r == '\t':
+				b.WriteString(`\t`)
+			case r < 0x20:
+				fmt.Fprintf(&b, `\u%04x`, r)
+			default:
+				b.WriteRune(r)
+			}
+			continue
+		}
+
+		switch {
+		case r == '"' || r == '“' || r == '”':
+			inString = true
+			smartQuoted = r == '“'
+			b.WriteRune('"')
+		case r == '{':
+			stack = append(stack, '}')
+			b.WriteRune(r)
+		case r == '[':
+			stack = append(stack, ']')
+			b.WriteRune(r)
+		case r == '}' || r == ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			b.WriteRune(r)
+		case r == ',':
+			if next := nextSignificant(runes, i+1); next != '}' && next != ']' && next != 0 {
+				b.WriteRune(r)
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		default:
+			if word, literal := pythonLiteral(runes, i); word != "" {
+				b.WriteString(literal)
+				i += len(word) - 1
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+
+	if inString {
+		b.WriteRune('"')
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteByte(stack[i])
+	}
+	return b.String()
+}
+
+func validEscape(runes []rune, i int) bool {
+	switch runes[i] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return true
+	case 'u':
+		if i+4 >= len(runes) {
+			return false
+		}
+		for _, r := range runes[i+1 : i+5] {
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// nextSignificant возвращает следующий непробельный символ или 0 в конце текста
+func nextSignificant(runes []rune, i int) rune {
+	for ; i < len(runes); i++ {
+		switch runes[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		return runes[i]
+	}
+	return 0
+}
+
+func pythonLiteral(runes []rune, i int) (string, string) {
+	if i > 0 && isWordRune(runes[i-1]) {
+		return "", ""
+	}
+	for word, literal := range map[string]string{"True": "true", "False": "false", "None": "null"} {
+		end := i + len(word)
+		if end <= len(runes) && string(runes[i:end]) == word && (end == len(runes) || !isWordRune(runes[end])) {
+			return word, literal
+		}
+	}
+	return "", ""
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
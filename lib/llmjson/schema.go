@@ -0,0 +1,174 @@
+package llmjson
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema — подмножество JSON Schema, достаточное для ответов LLM.
+// Сериализуется как есть и передаётся провайдеру в response_format
+type Schema struct {
+	Type                 string             `json:"type"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+func String() *Schema {
+	return &Schema{Type: "string"}
+}
+
+// Enum — строка из фиксированного набора значений
+func Enum(values ...string) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+func Integer(min, max float64) *Schema {
+	return &Schema{Type: "integer", Minimum: &min, Maximum: &max}
+}
+
+func Boolean() *Schema {
+	return &Schema{Type: "boolean"}
+}
+
+func Array(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// Object — объект, все поля которого обязательны, а лишние запрещены (требование strict-режима)
+func Object(properties map[string]*Schema) *Schema {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+	noAdditional := false
+	return &Schema{Type: "object", Properties: properties, Required: required, AdditionalProperties: &noAdditional}
+}
+
+// Normalize проверяет значение по схеме и исправляет типичные отклонения:
+// числа вместо строк и строки вместо чисел, одиночное значение вместо массива,
+// отсутствующий массив, регистр значения enum, лишние поля
+func (s *Schema) Normalize(value interface{}) (interface{}, error) {
+	return s.normalize(value, "$")
+}
+
+func (s *Schema) normalize(value interface{}, path string) (interface{}, error) {
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: expected object", path)
+		}
+		result := make(map[string]interface{}, len(s.Properties))
+		for name, property := range s.Properties {
+			field, found := object[name]
+			if !found || field == nil {
+				if property.Type == "array" {
+					result[name] = []interface{}{}
+					continue
+				}
+				if contains(s.Required, name) {
+					return nil, fmt.Errorf("%s.%s: required field is missing", path, name)
+				}
+				continue
+			}
+			normalized, err := property.normalize(field, path+"."+name)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = normalized
+		}
+		return result, nil
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		result := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			normalized, err := s.Items.normalize(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, normalized)
+		}
+		return result, nil
+
+	case "string":
+		var str string
+		switch v := value.(type) {
+		case string:
+			str = v
+		case float64:
+			str = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			str = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("%s: expected string", path)
+		}
+		if len(s.Enum) == 0 {
+			return str, nil
+		}
+		for _, allowed := range s.Enum {
+			if strings.EqualFold(strings.TrimSpace(str), allowed) {
+				return allowed, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %q is not one of %s", path, str, strings.Join(s.Enum, ", "))
+
+	case "integer", "number":
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: expected %s", path, s.Type)
+			}
+			number = parsed
+		default:
+			return nil, fmt.Errorf("%s: expected %s", path, s.Type)
+		}
+		if s.Type == "integer" && number != math.Trunc(number) {
+			return nil, fmt.Errorf("%s: expected integer", path)
+		}
+		if s.Minimum != nil && number < *s.Minimum {
+			return nil, fmt.Errorf("%s: %v is less than %v", path, number, *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			return nil, fmt.Errorf("%s: %v is greater than %v", path, number, *s.Maximum)
+		}
+		return number, nil
+
+	case "boolean":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+		if v, ok := value.(string); ok {
+			if parsed, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: expected boolean", path)
+	}
+	return value, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package noises
+
+import "codular-backend/lib/textdiff"
+
+// EditResult — проверка одной правки
+type EditResult struct {
+	Edit     Edit
+	Reverted bool
+}
+
+// Change — участок решения, который отличается от исходного кода, но не относится ни к одной
+// правке. Строки решения нумеруются с 1; при нулевом числе строк в этом месте удалён код
+type Change struct {
+	Line  int
+	Count int
+}
+
+// Grade — результат проверки решения
+type Grade struct {
+	Score int
+	// Edits — результаты в порядке правок задачи
+	Edits []EditResult
+	// Extra — лишние изменения решения
+	Extra []Change
+}
+
+// Solved сообщает, что все правки отменены и других изменений нет
+func (g Grade) Solved() bool {
+	for _, edit := range g.Edits {
+		if !edit.Reverted {
+			return false
+		}
+	}
+	return len(g.Extra) == 0
+}
+
+// Check сравнивает решение с исходным кодом. Шумовая вставка не отменена, если в том же месте
+// решения осталась хотя бы одна её строка; изменение не отменено, если его строки исходного кода
+// не восстановлены. Остальные различия считаются лишними изменениями. Оценка — доля отменённых
+// правок среди всех правок и лишних изменений
+func Check(original string, edits []Edit, solution string) Grade {
+	a, b := significant(original), significant(solution)
+	hunks := textdiff.Hunks(a.texts, b.texts)
+	explained := make([]bool, len(hunks))
+
+	var grade Grade
+	reverted := 0
+	for _, edit := range edits {
+		start, end := a.index(edit.OriginalLine, edit.OriginalCount)
+		noise := make(map[string]bool, len(edit.Noised))
+		for _, line := range edit.Noised {
+			noise[textdiff.Normalize(line, textdiff.Collapse)] = true
+		}
+
+		result := EditResult{Edit: edit, Reverted: true}
+		for i, h := range hunks {
+			var missed bool
+			if edit.Kind == KindNoise {
+				missed = h.AStart <= start && start <= h.AEnd && containsAny(b.texts[h.BStart:h.BEnd], noise)
+			} else {
+				missed = h.AStart < end && start < h.AEnd
+			}
+			if missed {
+				result.Reverted = false
+				explained[i] = true
+			}
+		}
+		if result.Reverted {
+			reverted++
+		}
+		grade.Edits = append(grade.Edits, result)
+	}
+
+	for i, h := range hunks {
+		if explained[i] {
+			continue
+		}
+		line, count, _ := b.span(h.BStart, h.BEnd)
+		grade.Extra = append(grade.Extra, Change{Line: line, Count: count})
+	}
+
+	if total := len(edits) + len(grade.Extra); total > 0 {
+		grade.Score = 100 * reverted / total
+	} else {
+		grade.Score = 100
+	}
+	return grade
+}
+
+func containsAny(texts []string, set map[string]bool) bool {
+	for _, text := range texts {
+		if set[text] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+// Package noises описывает правки задачи с шумами как построчное различие с исходным кодом
+// и проверяет решения по этому различию без модели.
+package noises
+
+import (
+	"codular-backend/lib/textdiff"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Виды правок
+const (
+	// KindNoise — вставка строк, которые не влияют на результат
+	KindNoise = "noise"
+	// KindSemantic — изменение или удаление строк исходного кода, меняющее поведение
+	KindSemantic = "semantic"
+)
+
+// Edit — одна правка, внесённая в исходный код. Строки нумеруются с 1, как в unified diff:
+// при нулевом числе строк правка стоит после строки с указанным номером (0 — в начале кода)
+type Edit struct {
+	Kind string `json:"kind"`
+	// OriginalLine и OriginalCount — заменённые строки исходного кода
+	OriginalLine  int `json:"originalLine"`
+	OriginalCount int `json:"originalCount"`
+	// NoisedLine и NoisedCount — строки кода задачи, которые их заменили
+	NoisedLine  int      `json:"noisedLine"`
+	NoisedCount int      `json:"noisedCount"`
+	Original    []string `json:"original,omitempty"`
+	Noised      []string `json:"noised,omitempty"`
+}
+
+// MaxLines — сколько строк может быть в коде, который сравнивается по различию
+const MaxLines = 2000
+
+// Пределы сопоставления строк внутри участка замены: в больших участках строки не делятся на пары,
+// а длинные строки не считаются похожими
+const (
+	maxPairedLines  = 10000
+	maxSimilarCells = 1 << 20
+)
+
+// TooLong сообщает, что в коде больше MaxLines строк
+func TooLong(code string) bool {
+	return strings.Count(code, "\n") >= MaxLines
+}
+
+// Edits сравнивает исходный код с кодом задачи и возвращает внесённые правки.
+// Пустые строки и различия в пробелах правками не считаются
+func Edits(original, noised string) []Edit {
+	a, b := significant(original), significant(noised)
+	hunks := textdiff.Hunks(a.texts, b.texts)
+
+	edits := make([]Edit, 0, len(hunks))
+	for _, h := range splitHunks(a.texts, b.texts, hunks) {
+		edit := Edit{Kind: KindSemantic}
+		if h.AStart == h.AEnd {
+			edit.Kind = KindNoise
+		}
+		edit.OriginalLine, edit.OriginalCount, edit.Original = a.span(h.AStart, h.AEnd)
+		edit.NoisedLine, edit.NoisedCount, edit.Noised = b.span(h.BStart, h.BEnd)
+		edits = append(edits, edit)
+	}
+	return edits
+}
+
+// Encode сохраняет ответ задачи: первым элементом — исходный код, за ним по JSON-объекту на правку
+func Encode(original string, edits []Edit) []string {
+	answers := make([]string, 0, len(edits)+1)
+	answers = append(answers, original)
+	for _, edit := range edits {
+		encoded, _ := json.Marshal(edit)
+		answers = append(answers, string(encoded))
+	}
+	return answers
+}
+
+// Decode разбирает ответ задачи, сохранённый через Encode. У задач, созданных до сохранения
+// правок, ответ — только исходный код, и правок нет
+func Decode(answers []string) (string, []Edit, error) {
+	if len(answers) == 0 {
+		return "", nil, fmt.Errorf("noises task has no answers")
+	}
+	edits := make([]Edit, len(answers)-1)
+	for i, answer := range answers[1:] {
+		if err := json.Unmarshal([]byte(answer), &edits[i]); err != nil {
+			return "", nil, fmt.Errorf("invalid noises edit %d: %v", i+1, err)
+		}
+	}
+	return answers[0], edits, nil
+}
+
+// LineRange описывает строки так же, как они хранятся в правке: при нулевом числе строк — место после строки
+func LineRange(line, count int) string {
+	switch {
+	case count == 0 && line == 0:
+		return "at the beginning"
+	case count == 0:
+		return fmt.Sprintf("after line %d", line)
+	case count == 1:
+		return fmt.Sprintf("line %d", line)
+	}
+	return fmt.Sprintf("lines %d-%d", line, line+count-1)
+}
+
+// splitHunks делит участки замены на правки: строка исходного кода и похожая на неё строка
+// кода задачи — отдельное изменение, а строки задачи без пары — отдельные вставки.
+// Иначе шумовая вставка рядом с изменённой строкой попала бы в одну правку с ней
+func splitHunks(a, b []string, hunks []textdiff.Hunk) []textdiff.Hunk {
+	var result []textdiff.Hunk
+	for _, h := range hunks {
+		if h.AStart == h.AEnd || h.BStart == h.BEnd || (h.AEnd-h.AStart)*(h.BEnd-h.BStart) > maxPairedLines {
+			result = append(result, h)
+			continue
+		}
+		removed, added := a[h.AStart:h.AEnd], b[h.BStart:h.BEnd]
+		table := make([][]int, len(removed)+1)
+		for i := range table {
+			table[i] = make([]int, len(added)+1)
+		}
+		for i := len(removed) - 1; i >= 0; i-- {
+			for j := len(added) - 1; j >= 0; j-- {
+				if similar(removed[i], added[j]) {
+					table[i][j] = table[i+1][j+1] + 1
+				} else {
+					table[i][j] = max(table[i+1][j], table[i][j+1])
+				}
+			}
+		}
+
+		// Строки без пары между соседними парами собираются в один участок
+		i, j := 0, 0
+		pending := textdiff.Hunk{AStart: h.AStart, AEnd: h.AStart, BStart: h.BStart, BEnd: h.BStart}
+		flush := func() {
+			if pending.AStart != pending.AEnd || pending.BStart != pending.BEnd {
+				result = append(result, pending)
+			}
+		}
+		for i < len(removed) || j < len(added) {
+			switch {
+			case i < len(removed) && j < len(added) && similar(removed[i], added[j]) && table[i][j] == table[i+1][j+1]+1:
+				flush()
+				result = append(result, textdiff.Hunk{AStart: h.AStart + i, AEnd: h.AStart + i + 1, BStart: h.BStart + j, BEnd: h.BStart + j + 1})
+				i++
+				j++
+				pending = textdiff.Hunk{AStart: h.AStart + i, AEnd: h.AStart + i, BStart: h.BStart + j, BEnd: h.BStart + j}
+			case j == len(added) || i < len(removed) && table[i+1][j] >= table[i][j+1]:
+				i++
+				pending.AEnd = h.AStart + i
+			default:
+				j++
+				pending.BEnd = h.BStart + j
+			}
+		}
+		flush()
+	}
+	return result
+}
+
+// similar сообщает, что строка кода задачи — изменённая строка исходного кода, а не новая:
+// у них совпадает не меньше половины символов
+func similar(a, b string) bool {
+	if len(a)*len(b) > maxSimilarCells {
+		return false
+	}
+	table := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		prev := 0
+		for j := 1; j <= len(b); j++ {
+			current := table[j]
+			if a[i-1] == b[j-1] {
+				table[j] = prev + 1
+			} else {
+				table[j] = max(table[j], table[j-1])
+			}
+			prev = current
+		}
+	}
+	return 4*table[len(b)] >= len(a)+len(b)
+}
+
+// lines — непустые строки кода: нормализованный текст для сравнения и номера строк в коде
+type lines struct {
+	raw     []string
+	texts   []string
+	numbers []int
+}
+
+func significant(code string) lines {
+	result := lines{raw: strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")}
+	for i, line := range result.raw {
+		text := textdiff.Normalize(line, textdiff.Collapse)
+		if text == "" {
+			continue
+		}
+		result.texts = append(result.texts, text)
+		result.numbers = append(result.numbers, i+1)
+	}
+	return result
+}
+
+// span переводит непустые строки [start, end) в номер первой строки, число строк и их текст.
+// Для пустого участка возвращается номер строки, после которой он стоит
+func (l lines) span(start, end int) (int, int, []string) {
+	if start == end {
+		if start == 0 {
+			return 0, 0, nil
+		}
+		return l.numbers[start-1], 0, nil
+	}
+	first, last := l.numbers[start], l.numbers[end-1]
+	return first, last - first + 1, l.raw[first-1 : last]
+}
+
+// index возвращает участок непустых строк, который занимают строки [line, line+count) кода
+func (l lines) index(line, count int) (int, int) {
+	start := 0
+	for start < len(l.numbers) && l.numbers[start] < line || count == 0 && start < len(l.numbers) && l.numbers[start] == line {
+		start++
+	}
+	end := start
+	for end < len(l.numbers) && l.numbers[end] < line+count {
+		end++
+	}
+	return start, end
+}
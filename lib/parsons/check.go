@@ -0,0 +1,100 @@
+package parsons
+
+// Grade — результат проверки порядка блоков
+type Grade struct {
+	// Score — доля правильных блоков, стоящих в правильном относительном порядке (0–100)
+	Score int
+	// Misplaced — позиции решения (с 0), блоки на которых стоят не в том порядке
+	Misplaced []int
+	// Distractors — позиции решения, на которых стоят ложные блоки
+	Distractors []int
+	// Missing — сколько правильных блоков не использовано
+	Missing int
+}
+
+// Solved сообщает, что решение полностью верно
+func (g Grade) Solved() bool {
+	return g.Score == 100
+}
+
+// Check сравнивает порядок submitted (индексы blocks) с правильным order. Блоки сравниваются
+// по тексту, поэтому одинаковые строки (например, закрывающие скобки) взаимозаменяемы.
+// Частичный балл — длина наибольшей общей подпоследовательности, делённая на длину
+// большей из последовательностей, так что и лишние, и пропущенные блоки снижают оценку
+func Check(blocks []string, order, submitted []int) Grade {
+	correct := make([]string, len(order))
+	inSolution := make(map[string]int, len(order))
+	for i, index := range order {
+		correct[i] = blocks[index]
+		inSolution[blocks[index]]++
+	}
+	answer := make([]string, len(submitted))
+	for i, index := range submitted {
+		answer[i] = blocks[index]
+	}
+
+	matched := lcs(correct, answer)
+
+	var grade Grade
+	used := 0
+	for position, block := range answer {
+		switch {
+		case matched[position]:
+			used++
+		case inSolution[block] == 0:
+			grade.Distractors = append(grade.Distractors, position)
+		default:
+			grade.Misplaced = append(grade.Misplaced, position)
+			used++
+		}
+	}
+	grade.Missing = max(len(correct)-used, 0)
+
+	length := max(len(correct), len(answer))
+	if length > 0 {
+		grade.Score = 100 * countTrue(matched) / length
+	}
+	return grade
+}
+
+// lcs находит наибольшую общую подпоследовательность a и b и отмечает входящие в неё позиции b
+func lcs(a, b []string) []bool {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	matched := make([]bool, len(b))
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			matched[j] = true
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matched
+}
+
+func countTrue(values []bool) int {
+	count := 0
+	for _, v := range values {
+		if v {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,71 @@
+package parsons
+
+import (
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+)
+
+type mutation struct {
+	re      *regexp.Regexp
+	replace func(match string) string
+}
+
+// mutations — правдоподобные ошибки, из которых делаются ложные строки
+var mutations = []mutation{
+	// Сравнение окружено пробелами, иначе не отличить его от ->, <<, шаблонов и #include <...>
+	{regexp.MustCompile(` (?:<=|>=|==|!=|<|>) `), func(op string) string {
+		return map[string]string{" <= ": " < ", " >= ": " > ", " == ": " != ", " != ": " == ", " < ": " <= ", " > ": " >= "}[op]
+	}},
+	{regexp.MustCompile(`\b\d+\b`), func(number string) string {
+		n, _ := strconv.Atoi(number)
+		return strconv.Itoa(n + 1)
+	}},
+	{regexp.MustCompile(`&&|\|\||\band\b|\bor\b`), func(op string) string {
+		return map[string]string{"&&": "||", "||": "&&", "and": "or", "or": "and"}[op]
+	}},
+	{regexp.MustCompile(`\b(?:true|false|True|False)\b`), func(value string) string {
+		return map[string]string{"true": "false", "false": "true", "True": "False", "False": "True"}[value]
+	}},
+	{regexp.MustCompile(`\+=|-=|\+\+|--`), func(op string) string {
+		return map[string]string{"+=": "-=", "-=": "+=", "++": "--", "--": "++"}[op]
+	}},
+}
+
+// Distractors возвращает до n ложных блоков: копии случайных блоков с одной правдоподобной ошибкой
+// (сравнение, граница, логический оператор). Ложный блок не совпадает ни с одним настоящим
+func Distractors(blocks []string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(blocks))
+	for _, block := range blocks {
+		seen[block] = true
+	}
+
+	var distractors []string
+	for _, i := range rand.Perm(len(blocks)) {
+		if len(distractors) == n {
+			break
+		}
+		for _, m := range rand.Perm(len(mutations)) {
+			mutated, ok := mutate(blocks[i], mutations[m])
+			if ok && !seen[mutated] {
+				seen[mutated] = true
+				distractors = append(distractors, mutated)
+				break
+			}
+		}
+	}
+	return distractors
+}
+
+// mutate применяет мутацию к одному случайному совпадению в блоке
+func mutate(block string, m mutation) (string, bool) {
+	matches := m.re.FindAllStringIndex(block, -1)
+	if len(matches) == 0 {
+		return "", false
+	}
+	match := matches[rand.IntN(len(matches))]
+	return block[:match[0]] + m.replace(block[match[0]:match[1]]) + block[match[1]:], true
+}
@@ -0,0 +1,76 @@
+// Package parsons строит задачи Парсонса: код делится на логические блоки, которые перемешиваются
+// вместе с ложными строками, а решение — порядок блоков — проверяется без модели.
+package parsons
+
+import (
+	"errors"
+	"math/rand/v2"
+)
+
+// MaxDistractors — сколько ложных строк можно добавить в задачу
+const MaxDistractors = 5
+
+// ErrTooShort — в коде меньше двух блоков, переставлять нечего
+var ErrTooShort = errors.New("code has fewer than two lines to reorder")
+
+// Task — перемешанные блоки и правильный порядок
+type Task struct {
+	// Blocks — блоки в том порядке, в каком их видит пользователь; среди них есть ложные
+	Blocks []string
+	// Order — индексы Blocks в правильном порядке; ложные блоки в него не входят
+	Order []int
+}
+
+// Build делит код на блоки, добавляет до distractors ложных строк и перемешивает блоки
+func Build(code, language string, distractors int) (Task, error) {
+	blocks := Split(code, language)
+	if len(blocks) < 2 {
+		return Task{}, ErrTooShort
+	}
+	fakes := Distractors(blocks, min(distractors, MaxDistractors))
+
+	all := append(append([]string{}, blocks...), fakes...)
+	perm := shuffle(all, len(blocks))
+
+	task := Task{Blocks: make([]string, len(all)), Order: make([]int, len(blocks))}
+	for position, original := range perm {
+		task.Blocks[position] = all[original]
+		if original < len(blocks) {
+			task.Order[original] = position
+		}
+	}
+	return task, nil
+}
+
+// shuffle возвращает перестановку: perm[позиция] = исходный индекс. Правильные блоки
+// (первые correct) не должны остаться на своих местах все сразу
+func shuffle(blocks []string, correct int) []int {
+	perm := make([]int, len(blocks))
+	for i := range perm {
+		perm[i] = i
+	}
+	for attempt := 0; attempt < 10; attempt++ {
+		rand.Shuffle(len(perm), func(i, j int) { perm[i], perm[j] = perm[j], perm[i] })
+		if !solvedAsShown(blocks, perm, correct) {
+			return perm
+		}
+	}
+	// Все попытки дали исходный порядок (например, почти все блоки одинаковые)
+	perm[0], perm[len(perm)-1] = perm[len(perm)-1], perm[0]
+	return perm
+}
+
+// solvedAsShown сообщает, читаются ли правильные блоки в перестановке в исходном порядке
+func solvedAsShown(blocks []string, perm []int, correct int) bool {
+	next := 0
+	for _, original := range perm {
+		if original >= correct {
+			continue
+		}
+		if blocks[original] != blocks[next] {
+			return false
+		}
+		next++
+	}
+	return true
+}
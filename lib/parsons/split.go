@@ -0,0 +1,119 @@
+package parsons
+
+import "strings"
+
+// Split делит код на логические блоки: строку вместе с её продолжениями (незакрытые скобки,
+// перенос через \, многострочные строки Python). Пустые строки отбрасываются.
+// В Python отступ задаёт структуру программы, поэтому он сохраняется (за вычетом общего);
+// в остальных языках отступ подсказал бы порядок, поэтому он убирается
+func Split(code, language string) []string {
+	python := isPython(language)
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	indent := commonIndent(lines)
+
+	var blocks []string
+	var current []string
+	state := scanState{python: python}
+	for _, line := range lines {
+		if len(current) == 0 && strings.TrimSpace(line) == "" {
+			continue
+		}
+		line = strings.TrimRight(line, " \t")
+		if python {
+			line = strings.TrimPrefix(line, indent)
+		} else {
+			line = strings.TrimLeft(line, " \t")
+		}
+		current = append(current, line)
+
+		state.scan(line)
+		// Декоратор относится к следующему за ним определению
+		decorator := python && strings.HasPrefix(strings.TrimSpace(line), "@") && state.depth == 0
+		if state.continues() || decorator {
+			continue
+		}
+		blocks = append(blocks, strings.Join(current, "\n"))
+		current = nil
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+	return blocks
+}
+
+func isPython(language string) bool {
+	language = strings.ToLower(language)
+	return language == "python" || language == "python3" || language == "py"
+}
+
+// scanState — состояние разбора между строками
+type scanState struct {
+	python bool
+	// depth — глубина незакрытых скобок; фигурные учитываются только в Python,
+	// иначе тело функции целиком стало бы одним блоком
+	depth int
+	// tripleQuote — открытая многострочная строка Python (""" или ''')
+	tripleQuote string
+	// backslash — строка закончилась переносом через \
+	backslash bool
+}
+
+func (s *scanState) continues() bool {
+	return s.depth > 0 || s.tripleQuote != "" || s.backslash
+}
+
+// scan обновляет состояние по строке, пропуская строковые литералы и комментарии
+func (s *scanState) scan(line string) {
+	s.backslash = strings.HasSuffix(line, "\\")
+	for i := 0; i < len(line); i++ {
+		if s.tripleQuote != "" {
+			end := strings.Index(line[i:], s.tripleQuote)
+			if end < 0 {
+				return
+			}
+			i += end + 2
+			s.tripleQuote = ""
+			continue
+		}
+
+		c := line[i]
+		switch {
+		case s.python && (strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], "'''")):
+			s.tripleQuote = line[i : i+3]
+			i += 2
+		case c == '"' || c == '\'' || c == '`':
+			for i++; i < len(line) && line[i] != c; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case s.python && c == '#', !s.python && strings.HasPrefix(line[i:], "//"):
+			return
+		case c == '(' || c == '[' || (s.python && c == '{'):
+			s.depth++
+		case c == ')' || c == ']' || (s.python && c == '}'):
+			if s.depth > 0 {
+				s.depth--
+			}
+		}
+	}
+}
+
+// commonIndent возвращает отступ, общий для всех непустых строк
+func commonIndent(lines []string) string {
+	indent, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = lead, false
+			continue
+		}
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	return indent
+}
@@ -0,0 +1,71 @@
+package quiz
+
+// Результат проверки вопроса
+const (
+	StatusCorrect    = "correct"
+	StatusWrong      = "wrong"
+	StatusUnanswered = "unanswered"
+)
+
+// Answer — вариант, выбранный пользователем; идентификаторы берутся из View
+type Answer struct {
+	Question int `json:"question" validate:"gte=0"`
+	Option   int `json:"option" validate:"gte=0"`
+}
+
+// QuestionResult — проверка одного вопроса
+type QuestionResult struct {
+	Question int    `json:"question"`
+	Text     string `json:"text"`
+	Status   string `json:"status"`
+}
+
+// Grade — результат проверки ответа
+type Grade struct {
+	Score int
+	// Questions — результаты в порядке вопросов задачи
+	Questions []QuestionResult
+}
+
+// Solved сообщает, что на все вопросы дан правильный ответ
+func (g Grade) Solved() bool {
+	for _, q := range g.Questions {
+		if q.Status != StatusCorrect {
+			return false
+		}
+	}
+	return true
+}
+
+// Check проверяет ответы; все вопросы стоят одинаково. Если на вопрос ответили несколько раз,
+// учитывается первый ответ. Идентификаторы должны быть проверены вызывающим
+func Check(questions []Question, answers []Answer) Grade {
+	chosen := make(map[int]int, len(answers))
+	for _, answer := range answers {
+		if _, ok := chosen[answer.Question]; !ok {
+			chosen[answer.Question] = answer.Option
+		}
+	}
+
+	var grade Grade
+	correct := 0
+	for i, q := range questions {
+		result := QuestionResult{Question: i, Text: q.Text}
+		option, ok := chosen[i]
+		switch {
+		case !ok:
+			result.Status = StatusUnanswered
+		case option == q.Correct:
+			result.Status = StatusCorrect
+			correct++
+		default:
+			result.Status = StatusWrong
+		}
+		grade.Questions = append(grade.Questions, result)
+	}
+
+	if len(questions) > 0 {
+		grade.Score = 100 * correct / len(questions)
+	}
+	return grade
+}
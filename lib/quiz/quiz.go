@@ -0,0 +1,128 @@
+// Package quiz строит задачи с вопросами по коду: у каждого вопроса один правильный вариант
+// и несколько правдоподобных неправильных. Ответы проверяются без модели.
+package quiz
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// MaxQuestions — сколько вопросов можно задать в одной задаче
+const MaxQuestions = 10
+
+// Ограничения на число вариантов ответа в вопросе
+const (
+	MinOptions = 2
+	MaxOptions = 6
+)
+
+// Question — вопрос с вариантами ответа
+type Question struct {
+	Text    string   `json:"question"`
+	Options []string `json:"options"`
+	// Correct — индекс правильного варианта в Options
+	Correct int `json:"correct"`
+	// Explanation — почему ответ правильный; пользователю не показывается
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// Option — вариант ответа, как его видит пользователь. ID — индекс варианта в сохранённом вопросе
+type Option struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// View — вопрос без правильного ответа. ID — индекс вопроса в задаче
+type View struct {
+	ID       int      `json:"id"`
+	Question string   `json:"question"`
+	Options  []Option `json:"options"`
+}
+
+// Prepare отбрасывает некорректные вопросы модели (пустой текст, неверное число вариантов,
+// повторяющиеся варианты, неверный индекс правильного) и перемешивает варианты,
+// чтобы правильный не стоял на одном и том же месте
+func Prepare(questions []Question) []Question {
+	var prepared []Question
+	seenQuestions := make(map[string]bool)
+	for _, q := range questions {
+		q.Text = strings.TrimSpace(q.Text)
+		key := strings.ToLower(q.Text)
+		if q.Text == "" || seenQuestions[key] || len(q.Options) < MinOptions || len(q.Options) > MaxOptions || q.Correct < 0 || q.Correct >= len(q.Options) {
+			continue
+		}
+
+		options := make([]string, len(q.Options))
+		seenOptions := make(map[string]bool, len(q.Options))
+		valid := true
+		for i, option := range q.Options {
+			option = strings.TrimSpace(option)
+			if option == "" || seenOptions[option] {
+				valid = false
+				break
+			}
+			seenOptions[option] = true
+			options[i] = option
+		}
+		if !valid {
+			continue
+		}
+
+		correct := options[q.Correct]
+		rand.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+		for i, option := range options {
+			if option == correct {
+				q.Correct = i
+			}
+		}
+		q.Options = options
+		seenQuestions[key] = true
+		prepared = append(prepared, q)
+		if len(prepared) == MaxQuestions {
+			break
+		}
+	}
+	return prepared
+}
+
+// Shuffle возвращает вопросы для очередной попытки: порядок вопросов и вариантов случайный,
+// а идентификаторы остаются прежними, поэтому ответ проверяется независимо от перемешивания
+func Shuffle(questions []Question) []View {
+	views := make([]View, len(questions))
+	for i, q := range questions {
+		options := make([]Option, len(q.Options))
+		for j, option := range q.Options {
+			options[j] = Option{ID: j, Text: option}
+		}
+		rand.Shuffle(len(options), func(a, b int) { options[a], options[b] = options[b], options[a] })
+		views[i] = View{ID: i, Question: q.Text, Options: options}
+	}
+	rand.Shuffle(len(views), func(a, b int) { views[a], views[b] = views[b], views[a] })
+	return views
+}
+
+// Encode сохраняет вопросы в ответах задачи: один JSON-объект на вопрос
+func Encode(questions []Question) []string {
+	answers := make([]string, len(questions))
+	for i, q := range questions {
+		encoded, _ := json.Marshal(q)
+		answers[i] = string(encoded)
+	}
+	return answers
+}
+
+// Decode разбирает ответы задачи, сохранённые через Encode
+func Decode(answers []string) ([]Question, error) {
+	questions := make([]Question, len(answers))
+	for i, answer := range answers {
+		if err := json.Unmarshal([]byte(answer), &questions[i]); err != nil {
+			return nil, fmt.Errorf("invalid quiz answer %d: %v", i+1, err)
+		}
+		if questions[i].Correct < 0 || questions[i].Correct >= len(questions[i].Options) {
+			return nil, fmt.Errorf("invalid quiz answer %d: correct option out of range", i+1)
+		}
+	}
+	return questions, nil
+}
@@ -0,0 +1,126 @@
+package scrub
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxExcerpt — длина фрагмента, сохраняемого в отчёте
+const maxExcerpt = 120
+
+type injectionPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// injectionPatterns — фразы, которыми пытаются переписать инструкции модели или повлиять на оценку.
+// Если в шаблоне есть группа, подозрительным считается только её текст (границы слов для кириллицы)
+var injectionPatterns = []injectionPattern{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget)\s+(?:all\s+|any\s+|everything\s+)?(?:of\s+)?(?:the\s+|your\s+|my\s+)?(?:previous|prior|above|earlier|preceding|system|original)?\s*(?:instructions?|prompts?|rules|directions|guidelines|messages)\b`)},
+	{"role_override", regexp.MustCompile(`(?i)\byou\s+are\s+(?:now|no\s+longer)\b|\bfrom\s+now\s+on\s+you\b|\bact\s+as\s+(?:a|an|the)\s+\w+\s+(?:that|who)\b`)},
+	{"new_instructions", regexp.MustCompile(`(?i)\b(?:new|updated|real|actual)\s+(?:system\s+)?instructions?\s*:`)},
+	{"prompt_exfiltration", regexp.MustCompile(`(?i)\b(?:reveal|print|show|output|repeat|leak)\s+(?:me\s+)?(?:your|the)\s+(?:system\s+)?(?:prompt|instructions)\b`)},
+	{"chat_markup", regexp.MustCompile(`(?i)<\|(?:im_start|im_end|system|user|assistant)\|>|\[/?INST\]|<<SYS>>|^\s*(?:###\s*)?(?:system|assistant)\s*:`)},
+	{"grading_manipulation", regexp.MustCompile(`(?i)\b(?:mark|grade|score|rate|treat|consider)\s+(?:this|the|my)\s+(?:solution|answer|submission|code|task)\s+as\s+(?:correct|ok|passed|valid|100)\b|\b(?:return|respond\s+with|output)\s+(?:status\s+)?["']?ok["']?\s+(?:for|regardless)\b`)},
+	{"ignore_instructions_ru", regexp.MustCompile(`(?i)(?:игнорируй|проигнорируй|забудь|отмени|не\s+обращай\s+внимания\s+на)\s+(?:все\s+|всё\s+)?(?:предыдущие\s+|прошлые\s+|эти\s+|свои\s+|системные\s+)?(?:инструкции|указания|правила|промпты?)`)},
+	{"role_override_ru", regexp.MustCompile(`(?i)(?:^|[^\p{L}])(ты\s+(?:теперь|больше\s+не))(?:$|[^\p{L}])`)},
+	{"grading_manipulation_ru", regexp.MustCompile(`(?i)(?:засчитай|оцени|отметь)\s+(?:это\s+|мо[её]\s+)?(?:решение|ответ|код)?\s*(?:как\s+)?(?:верн|правильн|на\s+100)`)},
+}
+
+type finding struct {
+	start, end int
+	injection  *Injection
+}
+
+// findInjections ищет подозрительные фразы в комментариях и строковых литералах
+func findInjections(code string) []finding {
+	var findings []finding
+	for _, seg := range textSegments(code) {
+		text := code[seg.start:seg.end]
+		for _, p := range injectionPatterns {
+			for _, m := range p.re.FindAllStringSubmatchIndex(text, -1) {
+				if len(m) > 2 && m[2] >= 0 {
+					m = m[2:]
+				}
+				start, end := seg.start+m[0], seg.start+m[1]
+				findings = append(findings, finding{
+					start: start,
+					end:   end,
+					injection: &Injection{
+						Pattern: p.name,
+						Line:    lineAt(code, start),
+						Excerpt: excerpt(text),
+					},
+				})
+			}
+		}
+	}
+	return findings
+}
+
+type segment struct {
+	start, end int
+}
+
+// textSegments выделяет комментарии и строковые литералы. Разбор не зависит от языка и приблизителен:
+// поддерживаются //, #, -- , /* */, <!-- -->, кавычки ', ", ` и тройные кавычки
+func textSegments(code string) []segment {
+	var segments []segment
+	n := len(code)
+	for i := 0; i < n; {
+		switch {
+		case strings.HasPrefix(code[i:], "//") || code[i] == '#' || strings.HasPrefix(code[i:], "-- "):
+			end := strings.IndexByte(code[i:], '\n')
+			if end < 0 {
+				end = n - i
+			}
+			segments = append(segments, segment{i, i + end})
+			i += end
+		case strings.HasPrefix(code[i:], "/*"):
+			i = appendUntil(&segments, code, i, 2, "*/")
+		case strings.HasPrefix(code[i:], "<!--"):
+			i = appendUntil(&segments, code, i, 4, "-->")
+		case strings.HasPrefix(code[i:], `"""`) || strings.HasPrefix(code[i:], "'''"):
+			i = appendUntil(&segments, code, i, 3, code[i:i+3])
+		case code[i] == '"' || code[i] == '\'' || code[i] == '`':
+			quote := code[i]
+			j := i + 1
+			for j < n && code[j] != quote && (quote == '`' || code[j] != '\n') {
+				if code[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j > n {
+				j = n
+			}
+			segments = append(segments, segment{i + 1, j})
+			i = j + 1
+		default:
+			i++
+		}
+	}
+	return segments
+}
+
+// appendUntil добавляет сегмент от открывающей последовательности длины open до closing
+// и возвращает позицию после него
+func appendUntil(segments *[]segment, code string, i, open int, closing string) int {
+	start := i + open
+	end := strings.Index(code[start:], closing)
+	if end < 0 {
+		*segments = append(*segments, segment{start, len(code)})
+		return len(code)
+	}
+	*segments = append(*segments, segment{start, start + end})
+	return start + end + len(closing)
+}
+
+func excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxExcerpt {
+		return text
+	}
+	return string([]rune(text)[:maxExcerpt]) + "…"
+}
@@ -0,0 +1,216 @@
+// Package scrub готовит пользовательский код к отправке в стороннюю модель: заменяет секреты
+// обратимыми метками и находит в комментариях и строках текст, похожий на prompt injection.
+package scrub
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Виды скрытых фрагментов
+const (
+	KindPrivateKey = "private_key"
+	KindSecret     = "secret"
+	KindEmail      = "email"
+	KindIP         = "ip"
+	KindInjection  = "injection"
+)
+
+type detector struct {
+	name string
+	kind string
+	re   *regexp.Regexp
+	// group — номер группы со значением, которое нужно скрыть (0 — всё совпадение)
+	group int
+}
+
+// detectors проверяются по порядку; при пересечении побеждает более ранний
+var detectors = []detector{
+	{"private_key", KindPrivateKey, regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`), 0},
+	{"aws_access_key", KindSecret, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), 0},
+	{"github_token", KindSecret, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), 0},
+	{"slack_token", KindSecret, regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), 0},
+	{"google_api_key", KindSecret, regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), 0},
+	{"api_key", KindSecret, regexp.MustCompile(`\b(?:sk-(?:or-v1-|proj-|ant-)?[A-Za-z0-9_-]{20,}|[sr]k_(?:live|test)_[0-9A-Za-z]{16,})`), 0},
+	{"jwt", KindSecret, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`), 0},
+	{"url_password", KindSecret, regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@'"]+:([^\s@'"/]+)@`), 1},
+	{"bearer_token", KindSecret, regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{16,}=*)`), 1},
+	{"assignment", KindSecret, regexp.MustCompile(`(?i)\b[\w.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|apikey|access[_-]?key|private[_-]?key|credentials?)[\w.-]*["']?\s*(?::=|=>|[:=])\s*(["'])([^"'\n]{4,})["']`), 2},
+	{"email", KindEmail, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`), 0},
+	{"ipv4", KindIP, regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`), 0},
+}
+
+// publicIPs не считаются чувствительными
+var publicIPs = map[string]bool{"127.0.0.1": true, "0.0.0.0": true, "255.255.255.255": true}
+
+var placeholderRe = regexp.MustCompile(`^__[A-Z_]+_\d+__$`)
+
+// Options — что делать с кодом
+type Options struct {
+	// Secrets — заменять секреты, email и IP метками
+	Secrets bool
+	// SanitizeInjections — заменять подозрительный текст метками (иначе он только отмечается)
+	SanitizeInjections bool
+}
+
+// Redaction — скрытый фрагмент. Исходное значение в отчёт не попадает
+type Redaction struct {
+	Kind        string `json:"kind"`
+	Detector    string `json:"detector"`
+	Placeholder string `json:"placeholder"`
+	Line        int    `json:"line"`
+}
+
+// Injection — подозрение на prompt injection в комментарии или строке
+type Injection struct {
+	Pattern string `json:"pattern"`
+	Line    int    `json:"line"`
+	Excerpt string `json:"excerpt"`
+	// Sanitized — текст заменён меткой
+	Sanitized bool `json:"sanitized"`
+}
+
+// Result — код, безопасный для отправки, и отчёт о том, что в нём изменено
+type Result struct {
+	Code       string      `json:"-"`
+	Redactions []Redaction `json:"redactions"`
+	Injections []Injection `json:"injections"`
+
+	originals map[string]string
+}
+
+// Flagged сообщает, было ли что-то скрыто или найдено
+func (r *Result) Flagged() bool {
+	return len(r.Redactions) > 0 || len(r.Injections) > 0
+}
+
+// Report возвращает отчёт в JSON для сохранения у задачи или nil, если в коде ничего не найдено
+func (r *Result) Report() json.RawMessage {
+	if r == nil || !r.Flagged() {
+		return nil
+	}
+	report, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	return report
+}
+
+// Restore возвращает скрытые значения на место меток (в ответе модели)
+func (r *Result) Restore(text string) string {
+	if len(r.originals) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, len(r.originals)*2)
+	for placeholder, original := range r.originals {
+		pairs = append(pairs, placeholder, original)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+type span struct {
+	start, end int
+	kind       string
+	detector   string
+	injection  *Injection
+}
+
+// Scrub находит секреты и подозрительный текст в коде и заменяет их метками вида __SECRET_1__.
+// Одинаковые значения получают одинаковые метки, поэтому ответ модели восстанавливается через Restore
+func Scrub(code string, opts Options) *Result {
+	result := &Result{Code: code, Redactions: []Redaction{}, Injections: []Injection{}, originals: map[string]string{}}
+
+	var spans []span
+	if opts.Secrets {
+		for _, d := range detectors {
+			for _, m := range d.re.FindAllStringSubmatchIndex(code, -1) {
+				start, end := m[2*d.group], m[2*d.group+1]
+				if start < 0 {
+					continue
+				}
+				value := code[start:end]
+				if placeholderRe.MatchString(value) || (d.kind == KindIP && publicIPs[value]) {
+					continue
+				}
+				spans = appendSpan(spans, span{start: start, end: end, kind: d.kind, detector: d.name})
+			}
+		}
+	}
+
+	for _, finding := range findInjections(code) {
+		finding := finding
+		finding.injection.Sanitized = opts.SanitizeInjections
+		result.Injections = append(result.Injections, *finding.injection)
+		if opts.SanitizeInjections {
+			spans = appendSpan(spans, span{start: finding.start, end: finding.end, kind: KindInjection, detector: finding.injection.Pattern})
+		}
+	}
+
+	if len(spans) == 0 {
+		return result
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	counters := map[string]int{}
+	byValue := map[string]string{}
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		value := code[s.start:s.end]
+		key := s.kind + "\x00" + value
+		placeholder, seen := byValue[key]
+		if !seen {
+			counters[s.kind]++
+			placeholder = fmt.Sprintf("__%s_%d__", label(s.kind), counters[s.kind])
+			byValue[key] = placeholder
+			result.originals[placeholder] = value
+		}
+		if s.kind != KindInjection {
+			result.Redactions = append(result.Redactions, Redaction{
+				Kind:        s.kind,
+				Detector:    s.detector,
+				Placeholder: placeholder,
+				Line:        lineAt(code, s.start),
+			})
+		}
+		b.WriteString(code[last:s.start])
+		b.WriteString(placeholder)
+		last = s.end
+	}
+	b.WriteString(code[last:])
+	result.Code = b.String()
+	return result
+}
+
+// appendSpan добавляет фрагмент, если он не пересекается с уже найденными
+func appendSpan(spans []span, s span) []span {
+	for _, existing := range spans {
+		if s.start < existing.end && existing.start < s.end {
+			return spans
+		}
+	}
+	return append(spans, s)
+}
+
+func label(kind string) string {
+	switch kind {
+	case KindPrivateKey:
+		return "PRIVATE_KEY"
+	case KindEmail:
+		return "EMAIL"
+	case KindIP:
+		return "IP"
+	case KindInjection:
+		return "REDACTED_TEXT"
+	default:
+		return "SECRET"
+	}
+}
+
+// lineAt возвращает номер строки (с 1) для позиции в тексте
+func lineAt(text string, pos int) int {
+	return strings.Count(text[:pos], "\n") + 1
+}
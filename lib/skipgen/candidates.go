@@ -0,0 +1,252 @@
+package skipgen
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind — вид фрагмента, который становится пропуском
+type Kind string
+
+const (
+	KindCondition Kind = "condition"
+	KindLoopBound Kind = "loop_bound"
+	KindReturn    Kind = "return"
+	KindArgument  Kind = "argument"
+)
+
+// kindOrder — порядок, в котором виды фрагментов чередуются при выборе
+var kindOrder = []Kind{KindCondition, KindLoopBound, KindReturn, KindArgument}
+
+// maxSpanLength — самый длинный фрагмент (в символах), который имеет смысл угадывать целиком
+const maxSpanLength = 60
+
+// trivialSpans — фрагменты, которые угадываются без понимания кода
+var trivialSpans = map[string]bool{
+	"0": true, "1": true, "-1": true, "true": true, "false": true, "True": true, "False": true,
+	"None": true, "null": true, "nullptr": true, "this": true, "self": true, `""`: true, "''": true,
+}
+
+// Ключевые слова, после которых скобки — не вызов функции
+var (
+	pythonKeywords = map[string]bool{
+		"if": true, "elif": true, "while": true, "for": true, "return": true, "not": true, "and": true, "or": true,
+		"in": true, "is": true, "lambda": true, "assert": true, "del": true, "yield": true, "with": true, "except": true,
+		"raise": true, "await": true, "def": true, "class": true, "import": true, "from": true, "else": true,
+	}
+	cLikeKeywords = map[string]bool{
+		"if": true, "while": true, "for": true, "switch": true, "catch": true, "return": true, "sizeof": true,
+		"synchronized": true, "try": true, "do": true, "else": true, "throw": true, "case": true, "decltype": true,
+		"alignof": true, "typeid": true, "static_assert": true, "noexcept": true,
+	}
+	// cLikeExpressionKeywords — слова, за которыми в выражении может идти идентификатор
+	cLikeExpressionKeywords = map[string]bool{
+		"return": true, "new": true, "throw": true, "case": true, "delete": true, "instanceof": true,
+		"else": true, "co_return": true, "co_yield": true, "sizeof": true,
+	}
+	// declarationSuffixes — слова после списка параметров в объявлении функции
+	declarationSuffixes = map[string]bool{"const": true, "override": true, "noexcept": true, "throws": true, "final": true}
+)
+
+// candidate — фрагмент кода, который может стать пропуском
+type candidate struct {
+	kind       Kind
+	start, end int
+	line       int
+	// weak — тривиальный фрагмент (0, true, строковый литерал); берётся, только если не хватает остальных
+	weak bool
+}
+
+func (c candidate) overlaps(other candidate) bool {
+	return c.start < other.end && other.start < c.end
+}
+
+// collector собирает фрагменты-кандидаты из синтаксических деревьев кода
+type collector struct {
+	code       string
+	lang       language
+	skip       string
+	candidates []candidate
+	seen       map[[2]int]bool
+}
+
+// add добавляет узел как кандидата; слишком длинные, многострочные и неразобранные узлы
+// заменяются своими частями, если это возможно
+func (c *collector) add(kind Kind, n *node) {
+	if n == nil || n.kind == nodeRaw {
+		return
+	}
+	text := c.code[n.start:n.end]
+	if strings.Contains(text, "\n") || utf8.RuneCountInString(text) > maxSpanLength {
+		if n.kind == nodeBinary && n.op != "=" || n.kind == nodeTernary {
+			for _, child := range n.children {
+				c.add(kind, child)
+			}
+		}
+		return
+	}
+	key := [2]int{n.start, n.end}
+	if c.seen[key] || c.skip != "" && strings.Contains(text, c.skip) {
+		return
+	}
+	c.seen[key] = true
+	c.candidates = append(c.candidates, candidate{kind: kind, start: n.start, end: n.end, line: n.line, weak: isTrivial(text, n)})
+}
+
+func isTrivial(text string, n *node) bool {
+	if trivialSpans[text] {
+		return true
+	}
+	// Строковый литерал, в том числе с префиксом: f"...", L"..."
+	return n.kind == nodeAtom && (strings.HasSuffix(text, `"`) || strings.HasSuffix(text, "'"))
+}
+
+// condition добавляет условие; составное условие делится на простые части
+func (c *collector) condition(n *node) {
+	n = unwrap(n)
+	if n == nil {
+		return
+	}
+	switch {
+	case n.kind == nodeBinary && (n.op == "&&" || n.op == "||" || n.op == "and" || n.op == "or"):
+		c.condition(n.children[0])
+		c.condition(n.children[1])
+	case n.kind == nodeUnary && (n.op == "!" || n.op == "not") && isLogical(unwrap(n.children[0])):
+		c.condition(n.children[0])
+	default:
+		c.add(KindCondition, n)
+	}
+}
+
+// comparisonBound добавляет границу цикла из условия вида i < n; иное условие добавляется целиком
+func (c *collector) comparisonBound(n *node) {
+	n = unwrap(n)
+	if n == nil {
+		return
+	}
+	switch n.op {
+	case "<", "<=", ">", ">=", "!=":
+		if n.kind == nodeBinary {
+			c.add(KindLoopBound, n.children[1])
+			return
+		}
+	}
+	c.condition(n)
+}
+
+// loopBounds добавляет границы цикла по коллекции: аргументы range или саму коллекцию
+func (c *collector) loopBounds(n *node) {
+	n = unwrap(n)
+	if n == nil {
+		return
+	}
+	if n.kind == nodeCall && n.children[0].kind == nodeAtom {
+		switch c.code[n.children[0].start:n.children[0].end] {
+		case "range":
+			for _, arg := range n.children[1:] {
+				c.add(KindLoopBound, arg)
+			}
+			return
+		case "reversed", "enumerate":
+			if len(n.children) > 1 {
+				c.loopBounds(n.children[1])
+				return
+			}
+		}
+	}
+	c.add(KindLoopBound, n)
+}
+
+// calls добавляет аргументы вызовов функций; объявления функций пропускаются
+func (c *collector) calls(tokens []token) {
+	keywords := cLikeKeywords
+	if c.lang == langPython {
+		keywords = pythonKeywords
+	}
+	for i := 0; i+1 < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind != tokIdent || keywords[t.text] || tokens[i+1].kind != tokOpen || tokens[i+1].text != "(" {
+			continue
+		}
+		closing := matchClosing(tokens, i+1)
+		if closing < 0 || c.declaration(tokens, i, closing) {
+			continue
+		}
+		for _, arg := range splitTopLevel(tokens[i+2:closing], ",") {
+			n := parseExpression(arg, c.lang)
+			// Именованный аргумент Python: пропуском становится значение
+			if n != nil && c.lang == langPython && n.kind == nodeBinary && n.op == "=" {
+				n = n.children[1]
+			}
+			c.add(KindArgument, n)
+		}
+	}
+}
+
+// declaration сообщает, что скобки после имени — список параметров, а не аргументы вызова
+func (c *collector) declaration(tokens []token, name, closing int) bool {
+	if name > 0 {
+		prev := tokens[name-1]
+		if prev.kind == tokOp && prev.text == "@" {
+			return true
+		}
+		if c.lang == langPython {
+			return prev.kind == tokIdent && (prev.text == "def" || prev.text == "class")
+		}
+		if prev.kind == tokIdent && !cLikeExpressionKeywords[prev.text] {
+			// Тип перед именем: определение функции, прототип или переменная с аргументами конструктора
+			if closing+1 >= len(tokens) || tokens[closing+1].text != ";" {
+				return true
+			}
+		}
+	}
+	if c.lang == langPython {
+		return false
+	}
+	if closing+1 < len(tokens) {
+		next := tokens[closing+1]
+		if next.kind == tokOpen && next.text == "{" || next.kind == tokIdent && declarationSuffixes[next.text] {
+			return true
+		}
+	}
+	for _, param := range splitTopLevel(tokens[name+2:closing], ",") {
+		if isParameter(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// isParameter сообщает, похож ли фрагмент на объявление параметра: int a, String[] args, vector<int>& v
+func isParameter(tokens []token) bool {
+	for i := 1; i < len(tokens); i++ {
+		t, prev := tokens[i], tokens[i-1]
+		if t.kind != tokIdent {
+			continue
+		}
+		switch {
+		case prev.kind == tokIdent && !cLikeExpressionKeywords[prev.text]:
+			return true
+		case prev.kind == tokClose && prev.text == "]":
+			return true
+		case prev.kind == tokOp && (prev.text == "&" || prev.text == "*") && i >= 2 && tokens[i-2].text == ">":
+			return true
+		}
+	}
+	return false
+}
+
+// unwrap снимает лишние скобки вокруг выражения
+func unwrap(n *node) *node {
+	for n != nil && n.kind == nodeGroup {
+		if len(n.children) == 0 {
+			return nil
+		}
+		n = n.children[0]
+	}
+	return n
+}
+
+func isLogical(n *node) bool {
+	return n != nil && n.kind == nodeBinary && (n.op == "&&" || n.op == "||" || n.op == "and" || n.op == "or")
+}
@@ -0,0 +1,260 @@
+package skipgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokNumber
+	tokString
+	tokOp
+	tokOpen
+	tokClose
+	// tokNewline — конец логической строки (только Python)
+	tokNewline
+)
+
+type token struct {
+	kind       tokenKind
+	text       string
+	start, end int
+	line       int
+}
+
+// operators — операторы всех поддерживаемых языков, от длинных к коротким
+var operators = []string{
+	">>>=", "<<=", ">>=", "**=", "//=", ">>>", "...", "->", "::", "++", "--", "&&", "||", "==", "!=", "<=", ">=",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", ":=", "<<", ">>", "**", "//",
+}
+
+// pythonStringPrefixes — префиксы строк Python (f"...", rb'...')
+var pythonStringPrefixes = map[string]bool{"r": true, "u": true, "f": true, "b": true, "rb": true, "br": true, "fr": true, "rf": true}
+
+// cppStringPrefixes — префиксы строк C++ (L"...", R"(...)")
+var cppStringPrefixes = map[string]bool{"L": true, "u": true, "U": true, "u8": true, "R": true, "LR": true, "uR": true, "UR": true, "u8R": true}
+
+type lexer struct {
+	code   string
+	python bool
+	pos    int
+	line   int
+	depth  int
+	tokens []token
+}
+
+// tokenize разбивает код на токены, пропуская комментарии и директивы препроцессора.
+// Для Python отмечаются концы логических строк: перевод строки вне скобок и без «\»
+func tokenize(code string, python bool) ([]token, error) {
+	l := &lexer{code: code, python: python, line: 1}
+	for l.pos < len(l.code) {
+		if err := l.next(); err != nil {
+			return nil, err
+		}
+	}
+	if python {
+		l.newline()
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) emit(kind tokenKind, start int) {
+	l.tokens = append(l.tokens, token{kind: kind, text: l.code[start:l.pos], start: start, end: l.pos, line: l.line})
+}
+
+func (l *lexer) newline() {
+	if n := len(l.tokens); n > 0 && l.tokens[n-1].kind != tokNewline {
+		l.tokens = append(l.tokens, token{kind: tokNewline, start: l.pos, end: l.pos, line: l.line})
+	}
+}
+
+func (l *lexer) next() error {
+	c := l.code[l.pos]
+	rest := l.code[l.pos:]
+	switch {
+	case c == '\n':
+		if l.python && l.depth == 0 {
+			l.newline()
+		}
+		l.pos++
+		l.line++
+	case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+		l.pos++
+	case c == '\\' && strings.HasPrefix(rest, "\\\n"):
+		l.pos += 2
+		l.line++
+	case l.python && c == '#':
+		l.skipLine()
+	case !l.python && strings.HasPrefix(rest, "//"):
+		l.skipLine()
+	case !l.python && strings.HasPrefix(rest, "/*"):
+		end := strings.Index(rest[2:], "*/")
+		if end < 0 {
+			return fmt.Errorf("unterminated comment at line %d", l.line)
+		}
+		l.advance(end + 4)
+	case !l.python && c == '#' && l.atLineStart():
+		// Директива препроцессора с продолжениями строк
+		for l.pos < len(l.code) && l.code[l.pos] != '\n' {
+			if strings.HasPrefix(l.code[l.pos:], "\\\n") {
+				l.pos++
+				l.line++
+			}
+			l.pos++
+		}
+	case c == '"' || c == '\'' || (!l.python && c == '`'):
+		return l.lexString(l.pos, l.pos)
+	case isIdentStart(rest):
+		start := l.pos
+		for l.pos < len(l.code) && isIdentPart(l.code[l.pos:]) {
+			_, size := utf8.DecodeRuneInString(l.code[l.pos:])
+			l.pos += size
+		}
+		word := l.code[start:l.pos]
+		if l.pos < len(l.code) && (l.code[l.pos] == '"' || l.code[l.pos] == '\'') {
+			if l.python && pythonStringPrefixes[strings.ToLower(word)] {
+				return l.lexString(start, l.pos)
+			}
+			if !l.python && cppStringPrefixes[word] {
+				return l.lexString(start, l.pos)
+			}
+		}
+		l.emit(tokIdent, start)
+	case c >= '0' && c <= '9' || (c == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9'):
+		start := l.pos
+		for l.pos < len(l.code) {
+			ch := l.code[l.pos]
+			if ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_' || ch == '.' || ch == '\'' && !l.python {
+				l.pos++
+				continue
+			}
+			// Экспонента со знаком: 1e-5
+			if (ch == '-' || ch == '+') && strings.ContainsAny(l.code[l.pos-1:l.pos], "eEpP") && !strings.HasPrefix(l.code[start:], "0x") {
+				l.pos++
+				continue
+			}
+			break
+		}
+		l.emit(tokNumber, start)
+	case c == '(' || c == '[' || c == '{':
+		l.depth++
+		l.pos++
+		l.emit(tokOpen, l.pos-1)
+	case c == ')' || c == ']' || c == '}':
+		if l.depth > 0 {
+			l.depth--
+		}
+		l.pos++
+		l.emit(tokClose, l.pos-1)
+	default:
+		start := l.pos
+		for _, op := range operators {
+			if strings.HasPrefix(rest, op) {
+				l.pos += len(op)
+				l.emit(tokOp, start)
+				return nil
+			}
+		}
+		_, size := utf8.DecodeRuneInString(rest)
+		l.pos += size
+		l.emit(tokOp, start)
+	}
+	return nil
+}
+
+// lexString разбирает строковый литерал; start — начало с префиксом, quote — позиция кавычки
+func (l *lexer) lexString(start, quote int) error {
+	prefix := l.code[start:quote]
+	rest := l.code[quote:]
+	startLine := l.line
+
+	// Сырые строки C++: R"delim(...)delim"
+	if strings.HasSuffix(prefix, "R") && !l.python {
+		open := strings.IndexByte(rest, '(')
+		if open < 0 {
+			return fmt.Errorf("invalid raw string at line %d", startLine)
+		}
+		closing := ")" + rest[1:open] + "\""
+		end := strings.Index(rest[open:], closing)
+		if end < 0 {
+			return fmt.Errorf("unterminated raw string at line %d", startLine)
+		}
+		l.advance(quote - l.pos + open + end + len(closing))
+		l.emit(tokString, start)
+		return nil
+	}
+
+	// Тройные кавычки: строки Python и текстовые блоки Java
+	for _, triple := range []string{`"""`, `'''`} {
+		if strings.HasPrefix(rest, triple) && (l.python || triple == `"""`) {
+			end := strings.Index(rest[3:], triple)
+			if end < 0 {
+				return fmt.Errorf("unterminated string at line %d", startLine)
+			}
+			l.advance(quote - l.pos + end + 6)
+			l.emit(tokString, start)
+			return nil
+		}
+	}
+
+	q := rest[0]
+	raw := l.python && strings.ContainsAny(prefix, "rR")
+	l.pos = quote + 1
+	for l.pos < len(l.code) {
+		ch := l.code[l.pos]
+		switch {
+		case ch == '\\' && !raw || ch == '\\' && raw && l.pos+1 < len(l.code) && l.code[l.pos+1] == q:
+			if l.pos+1 < len(l.code) && l.code[l.pos+1] == '\n' {
+				l.line++
+			}
+			l.pos += 2
+			continue
+		case ch == '\n' && q != '`':
+			return fmt.Errorf("unterminated string at line %d", startLine)
+		case ch == '\n':
+			l.line++
+		case ch == q:
+			l.pos++
+			l.emit(tokString, start)
+			l.tokens[len(l.tokens)-1].line = startLine
+			return nil
+		}
+		l.pos++
+	}
+	return fmt.Errorf("unterminated string at line %d", startLine)
+}
+
+// advance сдвигает позицию на n байт, считая переводы строк
+func (l *lexer) advance(n int) {
+	l.line += strings.Count(l.code[l.pos:l.pos+n], "\n")
+	l.pos += n
+}
+
+func (l *lexer) skipLine() {
+	for l.pos < len(l.code) && l.code[l.pos] != '\n' {
+		l.pos++
+	}
+}
+
+func (l *lexer) atLineStart() bool {
+	i := l.pos - 1
+	for i >= 0 && (l.code[i] == ' ' || l.code[i] == '\t') {
+		i--
+	}
+	return i < 0 || l.code[i] == '\n'
+}
+
+func isIdentStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func isIdentPart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,384 @@
+package skipgen
+
+import "fmt"
+
+type nodeKind int
+
+const (
+	// nodeAtom — идентификатор, литерал или литерал-коллекция
+	nodeAtom nodeKind = iota
+	nodeGroup
+	nodeUnary
+	nodeBinary
+	nodeTernary
+	// nodeCall — вызов: children[0] — вызываемое выражение, остальные — аргументы
+	nodeCall
+	nodeIndex
+	nodeMember
+	// nodeRaw — фрагмент, который не удалось разобрать; внутрь него пропуски не ставятся
+	nodeRaw
+)
+
+// node — узел синтаксического дерева выражения; start и end — смещения в исходном коде
+type node struct {
+	kind       nodeKind
+	op         string
+	start, end int
+	line       int
+	children   []*node
+}
+
+// Приоритеты бинарных операторов; общие для всех языков, операторы одного языка
+// в выражениях другого просто не встречаются
+const (
+	precComma = iota + 1
+	precAssign
+	precTernary
+	precOr
+	precAnd
+	precBitOr
+	precBitXor
+	precBitAnd
+	precEquality
+	precRelational
+	precShift
+	precAdditive
+	precMultiplicative
+	precPower
+)
+
+var binaryPrecedence = map[string]int{
+	",": precComma,
+	"=": precAssign, "+=": precAssign, "-=": precAssign, "*=": precAssign, "/=": precAssign, "%=": precAssign,
+	"&=": precAssign, "|=": precAssign, "^=": precAssign, "<<=": precAssign, ">>=": precAssign, ">>>=": precAssign,
+	"**=": precAssign, "//=": precAssign, ":=": precAssign,
+	"?": precTernary, "if": precTernary,
+	"||": precOr, "or": precOr,
+	"&&": precAnd, "and": precAnd,
+	"|": precBitOr, "^": precBitXor, "&": precBitAnd,
+	"==": precEquality, "!=": precEquality, "is": precEquality, "is not": precEquality, "in": precEquality, "not in": precEquality,
+	"<": precRelational, ">": precRelational, "<=": precRelational, ">=": precRelational, "instanceof": precRelational,
+	"<<": precShift, ">>": precShift, ">>>": precShift,
+	"+": precAdditive, "-": precAdditive,
+	"*": precMultiplicative, "/": precMultiplicative, "%": precMultiplicative, "//": precMultiplicative, "@": precMultiplicative,
+	"**": precPower,
+}
+
+var unaryOperators = map[string]bool{"!": true, "-": true, "+": true, "~": true, "++": true, "--": true, "*": true, "&": true}
+
+type parser struct {
+	tokens []token
+	pos    int
+	lang   language
+}
+
+// parseExpression строит дерево выражения из токенов. Если выражение не разбирается
+// (шаблоны, лямбды, срезы), возвращается один узел nodeRaw на весь фрагмент
+func parseExpression(tokens []token, lang language) *node {
+	if len(tokens) == 0 {
+		return nil
+	}
+	p := &parser{tokens: tokens, lang: lang}
+	expr, err := p.binary(precComma)
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return &node{kind: nodeRaw, start: tokens[0].start, end: tokens[len(tokens)-1].end, line: tokens[0].line}
+	}
+	return expr
+}
+
+func (p *parser) peek(offset int) *token {
+	if p.pos+offset < len(p.tokens) {
+		return &p.tokens[p.pos+offset]
+	}
+	return nil
+}
+
+func (p *parser) is(offset int, kind tokenKind, text string) bool {
+	t := p.peek(offset)
+	return t != nil && t.kind == kind && t.text == text
+}
+
+// binaryOperator возвращает оператор в текущей позиции и число его токенов
+func (p *parser) binaryOperator() (string, int) {
+	t := p.peek(0)
+	if t == nil || t.kind != tokOp && t.kind != tokIdent {
+		return "", 0
+	}
+	if t.kind == tokIdent {
+		switch {
+		case p.lang != langPython && t.text != "instanceof":
+			return "", 0
+		case t.text == "not" && p.is(1, tokIdent, "in"):
+			return "not in", 2
+		case t.text == "is" && p.is(1, tokIdent, "not"):
+			return "is not", 2
+		}
+	}
+	if _, ok := binaryPrecedence[t.text]; ok {
+		return t.text, 1
+	}
+	return "", 0
+}
+
+func (p *parser) binary(minPrec int) (*node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, width := p.binaryOperator()
+		prec := binaryPrecedence[op]
+		if width == 0 || prec < minPrec {
+			return left, nil
+		}
+		p.pos += width
+
+		switch op {
+		case "?":
+			middle, err := p.binary(precAssign)
+			if err != nil {
+				return nil, err
+			}
+			if !p.is(0, tokOp, ":") {
+				return nil, fmt.Errorf("expected ':' in conditional expression")
+			}
+			p.pos++
+			right, err := p.binary(precTernary)
+			if err != nil {
+				return nil, err
+			}
+			left = &node{kind: nodeTernary, op: op, start: left.start, end: right.end, line: left.line, children: []*node{left, middle, right}}
+			continue
+		case "if":
+			condition, err := p.binary(precOr)
+			if err != nil {
+				return nil, err
+			}
+			if !p.is(0, tokIdent, "else") {
+				return nil, fmt.Errorf("expected 'else' in conditional expression")
+			}
+			p.pos++
+			right, err := p.binary(precTernary)
+			if err != nil {
+				return nil, err
+			}
+			left = &node{kind: nodeTernary, op: op, start: left.start, end: right.end, line: left.line, children: []*node{left, condition, right}}
+			continue
+		}
+
+		// Присваивание и возведение в степень правоассоциативны
+		next := prec + 1
+		if prec == precAssign || prec == precPower {
+			next = prec
+		}
+		if op == "," && p.peek(0) == nil {
+			// Завершающая запятая кортежа: return a, b,
+			return left, nil
+		}
+		right, err := p.binary(next)
+		if err != nil {
+			return nil, err
+		}
+		left = &node{kind: nodeBinary, op: op, start: left.start, end: right.end, line: left.line, children: []*node{left, right}}
+	}
+}
+
+func (p *parser) unary() (*node, error) {
+	t := p.peek(0)
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	switch {
+	case t.kind == tokOp && unaryOperators[t.text], t.kind == tokIdent && p.lang == langPython && t.text == "await":
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeUnary, op: t.text, start: t.start, end: operand.end, line: t.line, children: []*node{operand}}, nil
+	case t.kind == tokIdent && p.lang == langPython && t.text == "not":
+		p.pos++
+		operand, err := p.binary(precEquality)
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeUnary, op: t.text, start: t.start, end: operand.end, line: t.line, children: []*node{operand}}, nil
+	case t.kind == tokIdent && p.lang != langPython && t.text == "new":
+		p.pos++
+		operand, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeUnary, op: t.text, start: t.start, end: operand.end, line: t.line, children: []*node{operand}}, nil
+	case t.kind == tokIdent && t.text == "lambda", t.kind == tokOp && t.text == "->":
+		return nil, fmt.Errorf("lambda expressions are not parsed")
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (*node, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek(0)
+		switch {
+		case t == nil:
+			return expr, nil
+		case t.kind == tokOpen && t.text == "(":
+			closing, err := p.closing(p.pos)
+			if err != nil {
+				return nil, err
+			}
+			call := &node{kind: nodeCall, start: expr.start, end: p.tokens[closing].end, line: expr.line, children: []*node{expr}}
+			for _, arg := range splitTopLevel(p.tokens[p.pos+1:closing], ",") {
+				if parsed := parseExpression(arg, p.lang); parsed != nil {
+					call.children = append(call.children, parsed)
+				}
+			}
+			p.pos = closing + 1
+			expr = call
+		case t.kind == tokOpen && t.text == "[":
+			closing, err := p.closing(p.pos)
+			if err != nil {
+				return nil, err
+			}
+			index := &node{kind: nodeIndex, start: expr.start, end: p.tokens[closing].end, line: expr.line, children: []*node{expr}}
+			if inner := parseExpression(p.tokens[p.pos+1:closing], p.lang); inner != nil {
+				index.children = append(index.children, inner)
+			}
+			p.pos = closing + 1
+			expr = index
+		case t.kind == tokOp && (t.text == "." || t.text == "::" || t.text == "->" && p.lang == langCpp):
+			name := p.peek(1)
+			if name == nil || name.kind != tokIdent {
+				return nil, fmt.Errorf("expected member name after %q", t.text)
+			}
+			p.pos += 2
+			expr = &node{kind: nodeMember, op: t.text, start: expr.start, end: name.end, line: expr.line, children: []*node{expr}}
+		case t.kind == tokOp && (t.text == "++" || t.text == "--") && p.lang != langPython:
+			p.pos++
+			expr = &node{kind: nodeUnary, op: t.text, start: expr.start, end: t.end, line: expr.line, children: []*node{expr}}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+func (p *parser) primary() (*node, error) {
+	t := p.peek(0)
+	if t == nil {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	switch t.kind {
+	case tokIdent, tokNumber:
+		p.pos++
+		return &node{kind: nodeAtom, start: t.start, end: t.end, line: t.line}, nil
+	case tokString:
+		// Соседние строковые литералы склеиваются: "a" "b"
+		end := t.end
+		for p.pos++; p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokString; p.pos++ {
+			end = p.tokens[p.pos].end
+		}
+		return &node{kind: nodeAtom, start: t.start, end: end, line: t.line}, nil
+	case tokOpen:
+		closing, err := p.closing(p.pos)
+		if err != nil {
+			return nil, err
+		}
+		inner := p.tokens[p.pos+1 : closing]
+		start, end := t.start, p.tokens[closing].end
+		p.pos = closing + 1
+		if t.text != "(" {
+			// Литерал списка, словаря или инициализатора
+			return &node{kind: nodeAtom, start: start, end: end, line: t.line}, nil
+		}
+		if p.lang != langPython && isCast(inner) && p.startsOperand() {
+			operand, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return &node{kind: nodeUnary, op: "cast", start: start, end: operand.end, line: t.line, children: []*node{operand}}, nil
+		}
+		group := &node{kind: nodeGroup, start: start, end: end, line: t.line}
+		if parsed := parseExpression(inner, p.lang); parsed != nil {
+			group.children = []*node{parsed}
+		}
+		return group, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+// startsOperand сообщает, может ли текущий токен начинать операнд приведения типа
+func (p *parser) startsOperand() bool {
+	t := p.peek(0)
+	if t == nil {
+		return false
+	}
+	return t.kind == tokIdent || t.kind == tokNumber || t.kind == tokString || t.kind == tokOpen && t.text == "(" ||
+		t.kind == tokOp && (t.text == "!" || t.text == "~")
+}
+
+func (p *parser) closing(open int) (int, error) {
+	if closing := matchClosing(p.tokens, open); closing >= 0 {
+		return closing, nil
+	}
+	return 0, fmt.Errorf("unbalanced %q", p.tokens[open].text)
+}
+
+// isCast сообщает, похоже ли содержимое скобок на имя типа: (int), (long long), (String)
+func isCast(inner []token) bool {
+	if len(inner) == 0 {
+		return false
+	}
+	for _, t := range inner {
+		switch {
+		case t.kind == tokIdent:
+		case t.kind == tokOp && (t.text == "." || t.text == "::" || t.text == "*" || t.text == "&" || t.text == "<" || t.text == ">"):
+		case (t.kind == tokOpen || t.kind == tokClose) && (t.text == "[" || t.text == "]"):
+		default:
+			return false
+		}
+	}
+	return inner[0].kind == tokIdent
+}
+
+// matchClosing возвращает индекс скобки, закрывающей скобку open, или -1
+func matchClosing(tokens []token, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].kind {
+		case tokOpen:
+			depth++
+		case tokClose:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel делит токены по разделителю вне скобок. Пустые части сохраняются,
+// чтобы в заголовке for (;;) части не сдвигались
+func splitTopLevel(tokens []token, separator string) [][]token {
+	var parts [][]token
+	depth, start := 0, 0
+	for i, t := range tokens {
+		switch {
+		case t.kind == tokOpen:
+			depth++
+		case t.kind == tokClose:
+			depth--
+		case depth == 0 && t.kind == tokOp && t.text == separator:
+			parts = append(parts, tokens[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tokens[start:])
+}
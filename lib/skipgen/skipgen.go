@@ -0,0 +1,268 @@
+// Package skipgen строит задачи с пропусками без модели: код разбирается в синтаксические деревья,
+// и пропусками становятся осмысленные фрагменты — условия, границы циклов, аргументы вызовов
+// и возвращаемые выражения. Выбор детерминирован: один и тот же код даёт одну и ту же задачу.
+package skipgen
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnsupportedLanguage — язык не разбирается генератором
+var ErrUnsupportedLanguage = errors.New("programming language is not supported by the syntax generator")
+
+// ErrNotEnoughFragments — в коде меньше подходящих фрагментов, чем запрошено пропусков
+var ErrNotEnoughFragments = errors.New("code has too few fragments for the requested number of skips")
+
+type language int
+
+const (
+	langPython language = iota
+	langJava
+	langCpp
+)
+
+func parseLanguage(name string) (language, bool) {
+	switch strings.ToLower(name) {
+	case "python", "python3", "py":
+		return langPython, true
+	case "java":
+		return langJava, true
+	case "c++", "cpp":
+		return langCpp, true
+	}
+	return 0, false
+}
+
+// Supports сообщает, разбирает ли генератор код на этом языке
+func Supports(language string) bool {
+	_, ok := parseLanguage(language)
+	return ok
+}
+
+// Task — код с пропусками и ответы к ним
+type Task struct {
+	Code string
+	// Answers — исходные фрагменты в порядке пропусков
+	Answers []string
+	// Kinds — вид каждого пропуска, в порядке ответов
+	Kinds []Kind
+}
+
+// Generate заменяет ровно number фрагментов кода меткой placeholder. Фрагменты не пересекаются,
+// виды чередуются, а сами пропуски разносятся по коду как можно дальше друг от друга;
+// тривиальные фрагменты (0, true, строки) берутся, только если остальных не хватает
+func Generate(code, language string, number int, placeholder string) (Task, error) {
+	lang, ok := parseLanguage(language)
+	if !ok {
+		return Task{}, ErrUnsupportedLanguage
+	}
+	if number < 1 {
+		return Task{}, fmt.Errorf("number of skips must be positive")
+	}
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+
+	tokens, err := tokenize(code, lang == langPython)
+	if err != nil {
+		return Task{}, fmt.Errorf("failed to parse code: %v", err)
+	}
+
+	c := &collector{code: code, lang: lang, skip: placeholder, seen: make(map[[2]int]bool)}
+	if lang == langPython {
+		c.python(tokens)
+	} else {
+		c.cLike(tokens)
+	}
+
+	chosen := choose(c.candidates, number)
+	if len(chosen) < number {
+		return Task{}, fmt.Errorf("%w: found %d, requested %d", ErrNotEnoughFragments, len(chosen), number)
+	}
+	sort.Slice(chosen, func(i, j int) bool { return chosen[i].start < chosen[j].start })
+
+	task := Task{Answers: make([]string, len(chosen)), Kinds: make([]Kind, len(chosen))}
+	var builder strings.Builder
+	last := 0
+	for i, fragment := range chosen {
+		builder.WriteString(code[last:fragment.start])
+		builder.WriteString(placeholder)
+		task.Answers[i] = code[fragment.start:fragment.end]
+		task.Kinds[i] = fragment.kind
+		last = fragment.end
+	}
+	builder.WriteString(code[last:])
+	task.Code = builder.String()
+	return task, nil
+}
+
+// python собирает кандидатов по логическим строкам Python
+func (c *collector) python(tokens []token) {
+	for len(tokens) > 0 {
+		end := 0
+		for end < len(tokens) && tokens[end].kind != tokNewline {
+			end++
+		}
+		line := tokens[:end]
+		tokens = tokens[min(end+1, len(tokens)):]
+		if len(line) == 0 || line[0].kind == tokOp && line[0].text == "@" {
+			continue
+		}
+
+		switch line[0].text {
+		case "if", "elif", "while":
+			if colon := findTopLevel(line, 1, tokOp, ":"); colon > 1 {
+				c.condition(parseExpression(line[1:colon], c.lang))
+			}
+		case "for":
+			in := findTopLevel(line, 1, tokIdent, "in")
+			if in > 0 {
+				if colon := findTopLevel(line, in+1, tokOp, ":"); colon > in+1 {
+					c.loopBounds(parseExpression(line[in+1:colon], c.lang))
+				}
+			}
+		}
+		// return может стоять и после двоеточия: if x: return y
+		if ret := findTopLevel(line, 0, tokIdent, "return"); ret >= 0 && ret+1 < len(line) {
+			c.add(KindReturn, parseExpression(line[ret+1:], c.lang))
+		}
+		c.calls(line)
+	}
+}
+
+// cLike собирает кандидатов в коде Java и C++
+func (c *collector) cLike(tokens []token) {
+	for i := 0; i+1 < len(tokens); i++ {
+		t := tokens[i]
+		if t.kind != tokIdent {
+			continue
+		}
+		switch t.text {
+		case "if", "while":
+			if closing := openParen(tokens, i+1); closing > 0 {
+				c.condition(parseExpression(tokens[i+2:closing], c.lang))
+			}
+		case "for":
+			if closing := openParen(tokens, i+1); closing > 0 {
+				c.forHeader(tokens[i+2 : closing])
+			}
+		case "return":
+			end := i + 1
+			for depth := 0; end < len(tokens); end++ {
+				if tokens[end].kind == tokOpen {
+					depth++
+				} else if tokens[end].kind == tokClose {
+					depth--
+				}
+				if depth < 0 || depth == 0 && tokens[end].text == ";" {
+					break
+				}
+			}
+			c.add(KindReturn, parseExpression(tokens[i+1:end], c.lang))
+		}
+	}
+	c.calls(tokens)
+}
+
+// forHeader разбирает заголовок цикла for: начальное значение, граница в условии и шаг;
+// для цикла по коллекции (for (x : items)) границей считается коллекция
+func (c *collector) forHeader(header []token) {
+	parts := splitTopLevel(header, ";")
+	if len(parts) != 3 {
+		if colon := findTopLevel(header, 0, tokOp, ":"); colon >= 0 {
+			c.add(KindLoopBound, parseExpression(header[colon+1:], c.lang))
+		}
+		return
+	}
+	for _, init := range splitTopLevel(parts[0], ",") {
+		if assign := findTopLevel(init, 0, tokOp, "="); assign >= 0 {
+			c.add(KindLoopBound, parseExpression(init[assign+1:], c.lang))
+		}
+	}
+	c.comparisonBound(parseExpression(parts[1], c.lang))
+	for _, update := range splitTopLevel(parts[2], ",") {
+		n := parseExpression(update, c.lang)
+		if n != nil && n.kind == nodeBinary && binaryPrecedence[n.op] == precAssign {
+			c.add(KindLoopBound, n.children[1])
+		}
+	}
+}
+
+// openParen возвращает индекс скобки, закрывающей «(» в позиции open, или -1
+func openParen(tokens []token, open int) int {
+	if open >= len(tokens) || tokens[open].kind != tokOpen || tokens[open].text != "(" {
+		return -1
+	}
+	return matchClosing(tokens, open)
+}
+
+// findTopLevel ищет токен вне скобок, начиная с позиции from; -1, если его нет
+func findTopLevel(tokens []token, from int, kind tokenKind, text string) int {
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.kind == tokOpen:
+			depth++
+		case t.kind == tokClose:
+			depth--
+		case i >= from && depth == 0 && t.kind == kind && t.text == text:
+			return i
+		}
+	}
+	return -1
+}
+
+// choose выбирает до number непересекающихся кандидатов. Виды берутся по очереди, а среди
+// кандидатов одного вида — самый удалённый по строкам от уже выбранных (при равенстве — первый в коде)
+func choose(candidates []candidate, number int) []candidate {
+	var chosen []candidate
+	for _, weak := range []bool{false, true} {
+		for progress := true; progress && len(chosen) < number; {
+			progress = false
+			for _, kind := range kindOrder {
+				if len(chosen) == number {
+					break
+				}
+				if best, ok := farthest(candidates, chosen, kind, weak); ok {
+					chosen = append(chosen, best)
+					progress = true
+				}
+			}
+		}
+	}
+	return chosen
+}
+
+func farthest(candidates, chosen []candidate, kind Kind, weak bool) (candidate, bool) {
+	var best candidate
+	bestDistance, found := -1, false
+	for _, candidate := range candidates {
+		if candidate.kind != kind || candidate.weak != weak {
+			continue
+		}
+		distance := 1 << 30
+		free := true
+		for _, taken := range chosen {
+			if candidate.overlaps(taken) {
+				free = false
+				break
+			}
+			distance = min(distance, abs(candidate.line-taken.line))
+		}
+		if !free {
+			continue
+		}
+		if distance > bestDistance || distance == bestDistance && candidate.start < best.start {
+			best, bestDistance, found = candidate, distance, true
+		}
+	}
+	return best, found
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,273 @@
+// Package textdiff сравнивает вывод программы с ожидаемым: нормализует пробелы
+// и строит построчное различие. Участки различия (Hunks) используются и для сравнения кода.
+package textdiff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Режимы нормализации
+const (
+	// Exact — различаются любые символы, кроме вида перевода строки
+	Exact = "exact"
+	// Trailing — не учитываются пробелы в конце строк и пустые строки в конце вывода
+	Trailing = "trailing"
+	// Collapse — дополнительно любые последовательности пробелов и табуляций считаются одним пробелом,
+	// а пробелы в начале строки не учитываются
+	Collapse = "collapse"
+)
+
+// Modes — допустимые режимы
+var Modes = []string{Exact, Trailing, Collapse}
+
+var spacesRe = regexp.MustCompile(`[ \t]+`)
+
+// ValidMode сообщает, известен ли режим
+func ValidMode(mode string) bool {
+	for _, m := range Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize приводит текст к виду, в котором он сравнивается в режиме mode
+func Normalize(text, mode string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if mode == Exact {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if mode == Collapse {
+			line = strings.TrimLeft(spacesRe.ReplaceAllString(line, " "), " ")
+		}
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// Result — результат сравнения
+type Result struct {
+	Equal bool
+	// Score — доля совпавших строк (0–100)
+	Score int
+	// FirstMismatch — номер первой различающейся строки ожидаемого вывода (с 1), 0 при совпадении
+	FirstMismatch int
+	// Diff — различие в формате unified diff без заголовков: " " — общая строка, "-" — ожидалась, "+" — выведена
+	Diff []string
+}
+
+// Compare сравнивает actual с expected в режиме mode. В Diff попадает не больше maxLines строк
+// (0 — без ограничения); видимые пробелы и табуляции в различающихся строках помечаются как · и →,
+// чтобы было видно, в чём разница
+func Compare(expected, actual, mode string, maxLines int) Result {
+	expected, actual = Normalize(expected, mode), Normalize(actual, mode)
+	if expected == actual {
+		return Result{Equal: true, Score: 100}
+	}
+
+	a, b := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	ops := diff(a, b)
+
+	result := Result{}
+	common, line := 0, 0
+	for _, op := range ops {
+		switch op.kind {
+		case ' ':
+			common++
+			line++
+		case '-':
+			line++
+			if result.FirstMismatch == 0 {
+				result.FirstMismatch = line
+			}
+		case '+':
+			if result.FirstMismatch == 0 {
+				result.FirstMismatch = line + 1
+			}
+		}
+	}
+	result.Score = 100 * common / max(len(a), len(b))
+	result.Diff = format(ops, maxLines)
+	return result
+}
+
+// Hunk — участок различия: строки a[AStart:AEnd] заменены строками b[BStart:BEnd].
+// У вставки AStart == AEnd, у удаления BStart == BEnd
+type Hunk struct {
+	AStart, AEnd int
+	BStart, BEnd int
+}
+
+// Hunks возвращает участки, которыми различаются последовательности строк a и b
+func Hunks(a, b []string) []Hunk {
+	var hunks []Hunk
+	i, j := 0, 0
+	open := false
+	for _, o := range diff(a, b) {
+		if o.kind == ' ' {
+			open = false
+			i++
+			j++
+			continue
+		}
+		if !open {
+			hunks = append(hunks, Hunk{AStart: i, AEnd: i, BStart: j, BEnd: j})
+			open = true
+		}
+		last := &hunks[len(hunks)-1]
+		if o.kind == '-' {
+			i++
+			last.AEnd = i
+		} else {
+			j++
+			last.BEnd = j
+		}
+	}
+	return hunks
+}
+
+type op struct {
+	kind byte
+	text string
+}
+
+// maxEditSteps ограничивает поиск среднего участка: если различие больше, участок считается
+// заменённым целиком. Так время сравнения растёт линейно с размером вывода, а не с квадратом
+const maxEditSteps = 1000
+
+// diff строит различие алгоритмом Майерса в линейной памяти
+func diff(a, b []string) []op {
+	ops := make([]op, 0, max(len(a), len(b)))
+	return appendDiff(ops, a, b)
+}
+
+func appendDiff(ops []op, a, b []string) []op {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		ops = append(ops, op{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	if x, y, ok := middleSnake(a, b); ok && x+y > 0 && x+y < len(a)+len(b) {
+		ops = appendDiff(ops, a[:x], b[:y])
+		ops = appendDiff(ops, a[x:], b[y:])
+	} else {
+		for _, line := range a {
+			ops = append(ops, op{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, op{'+', line})
+		}
+	}
+
+	for _, line := range common {
+		ops = append(ops, op{' ', line})
+	}
+	return ops
+}
+
+// middleSnake находит точку, делящую кратчайший путь правок между a и b примерно пополам.
+// a и b не начинаются и не заканчиваются общей строкой. ok == false, если одна из
+// последовательностей пуста или различие больше maxEditSteps
+func middleSnake(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	delta := n - m
+	odd := delta%2 != 0
+	limit := min((n+m+1)/2, maxEditSteps)
+	offset := limit + 1
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			x0, y0 := x, x-k
+			y := y0
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if odd && k >= delta-(d-1) && k <= delta+(d-1) && x+backward[offset+delta-k] >= n {
+				return x0, y0, true
+			}
+		}
+		// Обратный поиск идёт с конца: x и y отсчитываются от конца a и b
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if !odd && delta-k >= -d && delta-k <= d && x+forward[offset+delta-k] >= n {
+				return n - x, m - y, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// format оставляет изменённые строки с одной строкой контекста вокруг
+func format(ops []op, maxLines int) []string {
+	near := make([]bool, len(ops))
+	for i, o := range ops {
+		if o.kind == ' ' {
+			continue
+		}
+		for k := max(i-1, 0); k <= min(i+1, len(ops)-1); k++ {
+			near[k] = true
+		}
+	}
+
+	var lines []string
+	skipped := false
+	for i, o := range ops {
+		if !near[i] {
+			skipped = true
+			continue
+		}
+		if skipped && len(lines) > 0 {
+			lines = append(lines, "…")
+		}
+		skipped = false
+		text := o.text
+		if o.kind != ' ' {
+			text = visible(text)
+		}
+		lines = append(lines, string(o.kind)+text)
+		if maxLines > 0 && len(lines) >= maxLines {
+			lines = append(lines, fmt.Sprintf("… (%d more lines)", len(ops)-i-1))
+			break
+		}
+	}
+	return lines
+}
+
+func visible(text string) string {
+	return strings.NewReplacer(" ", "·", "\t", "→").Replace(text)
+}
@@ -0,0 +1,38 @@
+// Package translate хранит тесты задач на перевод кода между языками: входные данные
+// и вывод исходной программы, с которым сравнивается вывод решения.
+package translate
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MaxTests — сколько входных данных можно задать для одной задачи
+const MaxTests = 10
+
+// Test — входные данные и вывод исходной программы на них
+type Test struct {
+	Stdin  string `json:"stdin"`
+	Output string `json:"output"`
+}
+
+// Encode сохраняет тесты в ответах задачи: один JSON-объект на тест
+func Encode(tests []Test) []string {
+	answers := make([]string, len(tests))
+	for i, test := range tests {
+		encoded, _ := json.Marshal(test)
+		answers[i] = string(encoded)
+	}
+	return answers
+}
+
+// Decode разбирает ответы задачи, сохранённые через Encode
+func Decode(answers []string) ([]Test, error) {
+	tests := make([]Test, len(answers))
+	for i, answer := range answers {
+		if err := json.Unmarshal([]byte(answer), &tests[i]); err != nil {
+			return nil, fmt.Errorf("invalid translate test %d: %v", i+1, err)
+		}
+	}
+	return tests, nil
+}